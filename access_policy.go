@@ -0,0 +1,371 @@
+package pubnub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxGrantTTL is the maximum TTL in minutes of a grant token.
+const maxGrantTTL = 525600
+
+// policyVariable matches the templated variables of an access policy, for ex. {userId}.
+var policyVariable = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// AccessPolicy describes the grant tokens of roles, from JSON or YAML:
+//
+//	roles:
+//	  member:
+//	    ttl: 60
+//	    authorizedUuid: "{userId}"
+//	    channels:
+//	      "inbox-{userId}": [read, write]
+//	    channelPatterns:
+//	      "^room-.*$": [read, join]
+//	    uuids:
+//	      "{userId}": [get, update]
+//
+// The names and patterns of the resources and the authorized UUID are
+// templates, with variables substituted by Compile. The variables are escaped
+// in the patterns.
+type AccessPolicy struct {
+	Roles map[string]AccessPolicyRole `json:"roles" yaml:"roles"`
+}
+
+// AccessPolicyRole is the grant of a role of an AccessPolicy. The resources map
+// their name or pattern to the names of their permissions: read, write,
+// manage, delete, get, update and join for the channels, read and manage for
+// the channel groups and get, update and delete for the UUIDs.
+type AccessPolicyRole struct {
+	// TTL in minutes of the token, from 1 to 525600.
+	TTL                  int                    `json:"ttl" yaml:"ttl"`
+	AuthorizedUUID       string                 `json:"authorizedUuid" yaml:"authorizedUuid"`
+	Meta                 map[string]interface{} `json:"meta" yaml:"meta"`
+	Channels             map[string][]string    `json:"channels" yaml:"channels"`
+	ChannelGroups        map[string][]string    `json:"channelGroups" yaml:"channelGroups"`
+	UUIDs                map[string][]string    `json:"uuids" yaml:"uuids"`
+	ChannelPatterns      map[string][]string    `json:"channelPatterns" yaml:"channelPatterns"`
+	ChannelGroupPatterns map[string][]string    `json:"channelGroupPatterns" yaml:"channelGroupPatterns"`
+	UUIDPatterns         map[string][]string    `json:"uuidPatterns" yaml:"uuidPatterns"`
+}
+
+// AccessGrant is a role of an AccessPolicy compiled for a subject, with the
+// permissions as PNGrantBitMask values.
+type AccessGrant struct {
+	TTL            int
+	AuthorizedUUID string
+	Meta           map[string]interface{}
+	Resources      GrantResources
+	Patterns       GrantResources
+}
+
+// ParseAccessPolicy parses and validates a JSON or YAML access policy.
+func ParseAccessPolicy(data []byte) (*AccessPolicy, error) {
+	var policy AccessPolicy
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&policy)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&policy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid access policy: %v", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// policyResource is a resource map of a role with its type.
+type policyResource struct {
+	field        string
+	resourceType PNResourceType
+	pattern      bool
+	entries      map[string][]string
+}
+
+func (r AccessPolicyRole) resources() []policyResource {
+	return []policyResource{
+		{"channels", PNChannels, false, r.Channels},
+		{"channelGroups", PNGroups, false, r.ChannelGroups},
+		{"uuids", PNUUIDs, false, r.UUIDs},
+		{"channelPatterns", PNChannels, true, r.ChannelPatterns},
+		{"channelGroupPatterns", PNGroups, true, r.ChannelGroupPatterns},
+		{"uuidPatterns", PNUUIDs, true, r.UUIDPatterns},
+	}
+}
+
+// Validate checks the TTL, the permissions and the patterns of the roles.
+func (p *AccessPolicy) Validate() error {
+	if len(p.Roles) == 0 {
+		return errors.New("access policy has no roles")
+	}
+	var errs []error
+	for _, name := range sortedKeys(p.Roles) {
+		role := p.Roles[name]
+		if role.TTL < 1 || role.TTL > maxGrantTTL {
+			errs = append(errs, fmt.Errorf("role %q: ttl must be between 1 and %d", name, maxGrantTTL))
+		}
+		empty := true
+		for _, res := range role.resources() {
+			for _, resName := range sortedKeys(res.entries) {
+				empty = false
+				if _, err := permissionsFromNames(res.resourceType, res.entries[resName]); err != nil {
+					errs = append(errs, fmt.Errorf("role %q: %s %q: %v", name, res.field, resName, err))
+				}
+				if res.pattern {
+					// The variables are checked with a placeholder value.
+					if _, err := regexp.Compile(policyVariable.ReplaceAllString(resName, "x")); err != nil {
+						errs = append(errs, fmt.Errorf("role %q: %s %q: invalid pattern: %v", name, res.field, resName, err))
+					}
+				}
+			}
+		}
+		if empty {
+			errs = append(errs, fmt.Errorf("role %q: no resources", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Compile substitutes vars in the templates of role and returns its grant.
+func (p *AccessPolicy) Compile(role string, vars map[string]string) (*AccessGrant, error) {
+	r, ok := p.Roles[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	authorizedUUID, err := expandPolicyTemplate(r.AuthorizedUUID, vars, false)
+	if err != nil {
+		return nil, fmt.Errorf("role %q: authorizedUuid: %v", role, err)
+	}
+	grant := &AccessGrant{
+		TTL:            r.TTL,
+		AuthorizedUUID: authorizedUUID,
+		Meta:           r.Meta,
+		Resources:      GrantResources{Channels: map[string]int64{}, Groups: map[string]int64{}, UUIDs: map[string]int64{}},
+		Patterns:       GrantResources{Channels: map[string]int64{}, Groups: map[string]int64{}, UUIDs: map[string]int64{}},
+	}
+	for _, res := range r.resources() {
+		target := grant.Resources.byType(res.resourceType)
+		if res.pattern {
+			target = grant.Patterns.byType(res.resourceType)
+		}
+		for name, perms := range res.entries {
+			expanded, err := expandPolicyTemplate(name, vars, res.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("role %q: %s %q: %v", role, res.field, name, err)
+			}
+			mask, _ := permissionsFromNames(res.resourceType, perms)
+			// Templates expanding to the same name add their permissions.
+			target[expanded] |= int64(mask)
+		}
+	}
+	return grant, nil
+}
+
+// GrantToken returns a GrantToken request of the grant.
+func (g *AccessGrant) GrantToken(pn *PubNub) *grantTokenBuilder {
+	b := pn.GrantToken().TTL(g.TTL)
+	if g.Meta != nil {
+		b.Meta(g.Meta)
+	}
+	b.opts.AuthorizedUUID = g.AuthorizedUUID
+	b.opts.Channels = make(map[string]ChannelPermissions)
+	b.opts.ChannelsPattern = make(map[string]ChannelPermissions)
+	b.opts.ChannelGroups = make(map[string]GroupPermissions)
+	b.opts.ChannelGroupsPattern = make(map[string]GroupPermissions)
+	b.opts.UUIDs = make(map[string]UUIDPermissions)
+	b.opts.UUIDsPattern = make(map[string]UUIDPermissions)
+	for k, v := range g.Resources.Channels {
+		b.opts.Channels[k] = parseGrantPerms(v, PNChannels).(ChannelPermissions)
+	}
+	for k, v := range g.Patterns.Channels {
+		b.opts.ChannelsPattern[k] = parseGrantPerms(v, PNChannels).(ChannelPermissions)
+	}
+	for k, v := range g.Resources.Groups {
+		b.opts.ChannelGroups[k] = parseGrantPerms(v, PNGroups).(GroupPermissions)
+	}
+	for k, v := range g.Patterns.Groups {
+		b.opts.ChannelGroupsPattern[k] = parseGrantPerms(v, PNGroups).(GroupPermissions)
+	}
+	for k, v := range g.Resources.UUIDs {
+		b.opts.UUIDs[k] = parseGrantPerms(v, PNUUIDs).(UUIDPermissions)
+	}
+	for k, v := range g.Patterns.UUIDs {
+		b.opts.UUIDsPattern[k] = parseGrantPerms(v, PNUUIDs).(UUIDPermissions)
+	}
+	return b
+}
+
+// AccessDifference is a difference between an AccessGrant and a token.
+type AccessDifference struct {
+	// ResourceType is 0 for the differences of the TTL and of the authorized UUID.
+	ResourceType PNResourceType
+	Name         string
+	Pattern      bool
+	// Expected and Actual are the permissions of the grant and of the token, 0 when missing.
+	Expected PNGrantBitMask
+	Actual   PNGrantBitMask
+	Reason   string
+}
+
+func (d AccessDifference) String() string {
+	return d.Reason
+}
+
+// Diff compares the grant with a decoded token, for ex. from GetPermissions,
+// and returns the differences of the TTL, the authorized UUID and the
+// permissions of the resources and patterns.
+func (g *AccessGrant) Diff(token PNGrantTokenDecoded) []AccessDifference {
+	var res []AccessDifference
+	if g.TTL != token.TTL {
+		res = append(res, AccessDifference{Reason: fmt.Sprintf("ttl: expected %d, actual %d", g.TTL, token.TTL)})
+	}
+	if g.AuthorizedUUID != token.AuthorizedUUID {
+		res = append(res, AccessDifference{Reason: fmt.Sprintf("authorized uuid: expected %q, actual %q", g.AuthorizedUUID, token.AuthorizedUUID)})
+	}
+	for _, pattern := range []bool{false, true} {
+		expected, actual := g.Resources, token.Resources
+		if pattern {
+			expected, actual = g.Patterns, token.Patterns
+		}
+		for _, resourceType := range []PNResourceType{PNChannels, PNGroups, PNUUIDs} {
+			res = append(res, diffGrantResources(resourceType, pattern, expected.byType(resourceType), actual.byType(resourceType))...)
+		}
+	}
+	return res
+}
+
+func diffGrantResources(resourceType PNResourceType, pattern bool, expected, actual map[string]int64) []AccessDifference {
+	names := make(map[string]bool)
+	for k := range expected {
+		names[k] = true
+	}
+	for k := range actual {
+		names[k] = true
+	}
+
+	kind := resourceTypeName(resourceType)
+	if pattern {
+		kind += " pattern"
+	}
+	var res []AccessDifference
+	for _, name := range sortedKeys(names) {
+		e, inExpected := expected[name]
+		a, inActual := actual[name]
+		if inExpected && inActual && e == a {
+			continue
+		}
+		d := AccessDifference{ResourceType: resourceType, Name: name, Pattern: pattern, Expected: PNGrantBitMask(e), Actual: PNGrantBitMask(a)}
+		switch {
+		case !inActual:
+			d.Reason = fmt.Sprintf("%s %q: missing from the token", kind, name)
+		case !inExpected:
+			d.Reason = fmt.Sprintf("%s %q: not in the policy", kind, name)
+		default:
+			d.Reason = fmt.Sprintf("%s %q: expected %s, actual %s", kind, name,
+				strings.Join(permissionNames(PNGrantBitMask(e)), ","), strings.Join(permissionNames(PNGrantBitMask(a)), ","))
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// byType returns the resources of resourceType.
+func (r GrantResources) byType(resourceType PNResourceType) map[string]int64 {
+	switch resourceType {
+	case PNChannels:
+		return r.Channels
+	case PNGroups:
+		return r.Groups
+	default:
+		return r.UUIDs
+	}
+}
+
+// resourcePermissions are the permissions applying to each resource type.
+var resourcePermissions = map[PNResourceType]PNGrantBitMask{
+	PNChannels: PNRead | PNWrite | PNManage | PNDelete | PNGet | PNUpdate | PNJoin,
+	PNGroups:   PNRead | PNManage,
+	PNUUIDs:    PNGet | PNUpdate | PNDelete,
+}
+
+// permissionsFromNames returns the bitmask of the permission names, which must
+// apply to resourceType.
+func permissionsFromNames(resourceType PNResourceType, names []string) (PNGrantBitMask, error) {
+	if len(names) == 0 {
+		return 0, errors.New("no permissions")
+	}
+	var mask PNGrantBitMask
+	for _, name := range names {
+		var bit PNGrantBitMask
+		for b := PNRead; b <= PNJoin; b <<= 1 {
+			if permissionName(b) == strings.ToLower(name) {
+				bit = b
+			}
+		}
+		if bit == 0 {
+			return 0, fmt.Errorf("unknown permission %q", name)
+		}
+		if resourcePermissions[resourceType]&bit == 0 {
+			return 0, fmt.Errorf("permission %q doesn't apply to a %s", name, resourceTypeName(resourceType))
+		}
+		mask |= bit
+	}
+	return mask, nil
+}
+
+// permissionNames returns the names of the permissions of mask.
+func permissionNames(mask PNGrantBitMask) []string {
+	names := []string{}
+	for b := PNRead; b <= PNJoin; b <<= 1 {
+		if mask&b != 0 {
+			names = append(names, permissionName(b))
+		}
+	}
+	return names
+}
+
+// expandPolicyTemplate substitutes vars in template, escaped when it is a pattern.
+func expandPolicyTemplate(template string, vars map[string]string, pattern bool) (string, error) {
+	var err error
+	res := policyVariable.ReplaceAllStringFunc(template, func(m string) string {
+		name := policyVariable.FindStringSubmatch(m)[1]
+		v, ok := vars[name]
+		if !ok || v == "" {
+			if err == nil {
+				err = fmt.Errorf("missing variable %q", name)
+			}
+			return m
+		}
+		if pattern {
+			return regexp.QuoteMeta(v)
+		}
+		return v
+	})
+	return res, err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAccessPolicy = `
+roles:
+  member:
+    ttl: 60
+    authorizedUuid: "{userId}"
+    meta:
+      role: member
+    channels:
+      "inbox-{userId}": [read, write]
+      "lobby": [read]
+    channelPatterns:
+      "^room-{team}-.*$": [read, join]
+    uuids:
+      "{userId}": [get, update]
+`
+
+func TestParseAccessPolicyJSONAndYAML(t *testing.T) {
+	assert := assert.New(t)
+	fromYAML, err := ParseAccessPolicy([]byte(testAccessPolicy))
+	require.NoError(t, err)
+
+	b, err := json.Marshal(fromYAML)
+	require.NoError(t, err)
+	fromJSON, err := ParseAccessPolicy(b)
+	require.NoError(t, err)
+	assert.Equal(fromYAML, fromJSON)
+	assert.Equal([]string{"read", "join"}, fromJSON.Roles["member"].ChannelPatterns["^room-{team}-.*$"])
+}
+
+func TestAccessPolicyValidation(t *testing.T) {
+	_, err := ParseAccessPolicy([]byte(`
+roles:
+  bad:
+    ttl: 0
+    channelGroups:
+      cg: [write]
+    uuidPatterns:
+      "([": [get]
+    channels:
+      ch: [fly]
+  empty:
+    ttl: 10
+`))
+	require.Error(t, err)
+	for _, msg := range []string{
+		`role "bad": ttl must be between 1 and 525600`,
+		`role "bad": channelGroups "cg": permission "write" doesn't apply to a channel group`,
+		`role "bad": uuidPatterns "([": invalid pattern`,
+		`role "bad": channels "ch": unknown permission "fly"`,
+		`role "empty": no resources`,
+	} {
+		assert.ErrorContains(t, err, msg)
+	}
+
+	_, err = ParseAccessPolicy([]byte(`{"roles":{"r":{"ttl":1,"chanels":{}}}}`))
+	assert.ErrorContains(t, err, "invalid access policy")
+}
+
+func TestAccessPolicyCompile(t *testing.T) {
+	assert := assert.New(t)
+	policy, err := ParseAccessPolicy([]byte(testAccessPolicy))
+	require.NoError(t, err)
+
+	_, err = policy.Compile("member", map[string]string{"userId": "alice"})
+	assert.ErrorContains(err, `missing variable "team"`)
+	_, err = policy.Compile("admin", nil)
+	assert.ErrorContains(err, `unknown role "admin"`)
+
+	grant, err := policy.Compile("member", map[string]string{"userId": "alice", "team": "a.b"})
+	require.NoError(t, err)
+	assert.Equal(60, grant.TTL)
+	assert.Equal("alice", grant.AuthorizedUUID)
+	assert.Equal(map[string]int64{"inbox-alice": int64(PNRead | PNWrite), "lobby": int64(PNRead)}, grant.Resources.Channels)
+	assert.Equal(map[string]int64{`^room-a\.b-.*$`: int64(PNRead | PNJoin)}, grant.Patterns.Channels)
+	assert.Equal(map[string]int64{"alice": int64(PNGet | PNUpdate)}, grant.Resources.UUIDs)
+
+	var body map[string]interface{}
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		require.NoError(t, json.Unmarshal(b, &body))
+		fmt.Fprint(w, `{"status":200,"data":{"message":"Success","token":"tok"}}`)
+	})
+	pn.Config.SecretKey = "sec"
+	_, _, err = grant.GrantToken(pn).Execute()
+	require.NoError(t, err)
+	assert.Equal(float64(60), body["ttl"])
+	perms := body["permissions"].(map[string]interface{})
+	assert.Equal("alice", perms["uuid"])
+	assert.Equal(map[string]interface{}{"role": "member"}, perms["meta"])
+	assert.Equal(map[string]interface{}{"inbox-alice": float64(3), "lobby": float64(1)}, perms["resources"].(map[string]interface{})["channels"])
+	assert.Equal(map[string]interface{}{`^room-a\.b-.*$`: float64(129)}, perms["patterns"].(map[string]interface{})["channels"])
+}
+
+func TestAccessGrantDiff(t *testing.T) {
+	assert := assert.New(t)
+	policy, err := ParseAccessPolicy([]byte(testAccessPolicy))
+	require.NoError(t, err)
+	grant, err := policy.Compile("member", map[string]string{"userId": "alice", "team": "a"})
+	require.NoError(t, err)
+
+	token := PNGrantTokenDecoded{
+		TTL:            60,
+		AuthorizedUUID: "alice",
+		Resources: GrantResources{
+			Channels: map[string]int64{"inbox-alice": int64(PNRead | PNWrite), "lobby": int64(PNRead | PNWrite), "extra": int64(PNRead)},
+			UUIDs:    map[string]int64{"alice": int64(PNGet | PNUpdate)},
+		},
+		Patterns: GrantResources{Channels: map[string]int64{`^room-a-.*$`: int64(PNRead | PNJoin)}},
+	}
+	var reasons []string
+	for _, d := range grant.Diff(token) {
+		reasons = append(reasons, d.String())
+	}
+	assert.Equal([]string{
+		`channel "extra": not in the policy`,
+		`channel "lobby": expected read, actual read,write`,
+	}, reasons)
+
+	token.TTL = 30
+	token.Patterns.Channels = nil
+	diff := grant.Diff(token)
+	require.Len(t, diff, 4)
+	assert.Equal("ttl: expected 60, actual 30", diff[0].Reason)
+	assert.Equal(`channel pattern "^room-a-.*$": missing from the token`, diff[3].Reason)
+	assert.True(diff[3].Pattern)
+	assert.Equal(PNRead|PNJoin, diff[3].Expected)
+}
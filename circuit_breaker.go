@@ -0,0 +1,285 @@
+package pubnub
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pubnub/go/v9/pnerr"
+)
+
+// CircuitState is the state of the circuit breaker of an endpoint group and origin.
+type CircuitState int
+
+const (
+	// CircuitClosed lets the requests through and counts their failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects the requests with a CircuitOpenError.
+	CircuitOpen
+	// CircuitHalfOpen lets probe requests through to decide the recovery.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breakers of the non-subscribe
+// requests, see Config.CircuitBreaker. A circuit counts the connection errors,
+// the timeouts and the 5xx responses of an endpoint group on an origin.
+type CircuitBreakerConfig struct {
+	// ConsecutiveFailures opens the circuit after this number of consecutive failures, 5 when 0.
+	ConsecutiveFailures int
+	// FailureRate opens the circuit when this ratio of the last Window requests
+	// failed, for ex. 0.5. Disabled when 0.
+	FailureRate float64
+	// Window is the number of requests of FailureRate, 20 when 0.
+	Window int
+	// OpenTimeout is the time the circuit stays open before the probes, 30s when 0.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of successful probes which close the circuit, 1 when 0.
+	HalfOpenProbes int
+}
+
+func (c *CircuitBreakerConfig) consecutiveFailures() int {
+	if c.ConsecutiveFailures <= 0 {
+		return 5
+	}
+	return c.ConsecutiveFailures
+}
+
+func (c *CircuitBreakerConfig) window() int {
+	if c.Window <= 0 {
+		return 20
+	}
+	return c.Window
+}
+
+func (c *CircuitBreakerConfig) openTimeout() time.Duration {
+	if c.OpenTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return c.OpenTimeout
+}
+
+func (c *CircuitBreakerConfig) halfOpenProbes() int {
+	if c.HalfOpenProbes <= 0 {
+		return 1
+	}
+	return c.HalfOpenProbes
+}
+
+// CircuitOpenError is the error of the requests rejected by an open circuit breaker.
+type CircuitOpenError struct {
+	// Group is the endpoint group of the circuit, for ex. "pub" or "pres".
+	Group  string
+	Origin string
+	// RetryAfter is the wait before the circuit lets a probe through, 0 while
+	// the probes are in flight.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker of %s on %s is open, retry after %s", e.Group, e.Origin, e.RetryAfter)
+}
+
+// CircuitBreakerTransition is the AdditionalData of the PNStatus announced
+// when a circuit breaker changes state.
+type CircuitBreakerTransition struct {
+	Group  string
+	Origin string
+	From   CircuitState
+	To     CircuitState
+}
+
+type circuitKey struct {
+	group  string
+	origin string
+}
+
+// circuit is the circuit breaker of a circuitKey.
+type circuit struct {
+	key                 circuitKey
+	state               CircuitState
+	consecutiveFailures int
+	// results are the last results of the closed state, true for the failures.
+	results  []bool
+	next     int
+	openedAt time.Time
+	probes   int
+	// successes are the successful probes of the half-open state.
+	successes int
+}
+
+// circuitBreaker holds the circuits of a client.
+type circuitBreaker struct {
+	sync.Mutex
+	circuits map[circuitKey]*circuit
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{circuits: make(map[circuitKey]*circuit)}
+}
+
+// allow returns the circuit of the request of opts, or the status and the
+// error of the request when the circuit rejects it. The circuit is nil when
+// the request isn't guarded.
+func (cb *circuitBreaker) allow(opts endpoint) (*circuit, StatusResponse, error) {
+	cfg := opts.config().CircuitBreaker
+	op := opts.operationType()
+	if cfg == nil || op == PNSubscribeOperation || op == PNSendFileToS3Operation {
+		return nil, StatusResponse{}, nil
+	}
+	key := circuitKey{group: telemetryEndpointNameForOperation(op), origin: opts.origin()}
+
+	cb.Lock()
+	c, ok := cb.circuits[key]
+	if !ok {
+		c = &circuit{key: key, results: make([]bool, 0, cfg.window())}
+		cb.circuits[key] = c
+	}
+	var err *CircuitOpenError
+	from := c.state
+	switch c.state {
+	case CircuitOpen:
+		if wait := cfg.openTimeout() - time.Since(c.openedAt); wait > 0 {
+			err = &CircuitOpenError{Group: key.group, Origin: key.origin, RetryAfter: wait}
+			break
+		}
+		c.state, c.probes, c.successes = CircuitHalfOpen, 1, 0
+	case CircuitHalfOpen:
+		if c.probes >= cfg.halfOpenProbes() {
+			err = &CircuitOpenError{Group: key.group, Origin: key.origin}
+			break
+		}
+		c.probes++
+	}
+	to := c.state
+	cb.Unlock()
+
+	if from != to {
+		cb.announce(opts, key, from, to, nil)
+	}
+	if err != nil {
+		opts.getPubNub().loggerManager.LogError(err, "CircuitOpen", op, true)
+		return nil, createStatus(PNCircuitOpenedCategory, "", ResponseInfo{Operation: op, Origin: key.origin}, err), err
+	}
+	return c, StatusResponse{}, nil
+}
+
+// done records the result of a request allowed by c.
+func (cb *circuitBreaker) done(c *circuit, opts endpoint, status StatusResponse, err error) {
+	if c == nil {
+		return
+	}
+	cfg := opts.config().CircuitBreaker
+	// The requests cancelled by their context say nothing about the endpoint.
+	cancelled := opts.context() != nil && opts.context().Err() != nil
+	failure := !cancelled && isEndpointFailure(status, err)
+
+	cb.Lock()
+	from := c.state
+	switch c.state {
+	case CircuitClosed:
+		if cancelled {
+			break
+		}
+		if failure {
+			c.consecutiveFailures++
+		} else {
+			c.consecutiveFailures = 0
+		}
+		if len(c.results) < cfg.window() {
+			c.results = append(c.results, failure)
+		} else {
+			c.results[c.next] = failure
+			c.next = (c.next + 1) % len(c.results)
+		}
+		if c.consecutiveFailures >= cfg.consecutiveFailures() || c.failureRateExceeded(cfg) {
+			c.open()
+		}
+	case CircuitHalfOpen:
+		c.probes--
+		if failure {
+			c.open()
+		} else if !cancelled {
+			c.successes++
+			if c.successes >= cfg.halfOpenProbes() {
+				c.state = CircuitClosed
+				c.consecutiveFailures, c.results, c.next = 0, c.results[:0], 0
+			}
+		}
+	}
+	to := c.state
+	cb.Unlock()
+
+	if from != to {
+		cb.announce(opts, c.key, from, to, err)
+	}
+}
+
+func (c *circuit) open() {
+	c.state = CircuitOpen
+	c.openedAt = time.Now()
+	c.probes = 0
+}
+
+func (c *circuit) failureRateExceeded(cfg *CircuitBreakerConfig) bool {
+	if cfg.FailureRate <= 0 || len(c.results) < cfg.window() {
+		return false
+	}
+	failures := 0
+	for _, failure := range c.results {
+		if failure {
+			failures++
+		}
+	}
+	return float64(failures)/float64(len(c.results)) >= cfg.FailureRate
+}
+
+// announce logs a transition of the circuit of key and announces it to the listeners.
+func (cb *circuitBreaker) announce(opts endpoint, key circuitKey, from, to CircuitState, err error) {
+	pn := opts.getPubNub()
+	category := PNCircuitClosedCategory
+	level := PNLogLevelInfo
+	switch to {
+	case CircuitOpen:
+		category = PNCircuitOpenedCategory
+		level = PNLogLevelWarn
+	case CircuitHalfOpen:
+		category = PNCircuitHalfOpenCategory
+	}
+	pn.loggerManager.LogSimple(level, fmt.Sprintf("Circuit breaker of %s on %s: %s -> %s", key.group, key.origin, from, to), false)
+
+	status := &PNStatus{
+		Category:       category,
+		Operation:      opts.operationType(),
+		Origin:         key.origin,
+		AdditionalData: CircuitBreakerTransition{Group: key.group, Origin: key.origin, From: from, To: to},
+	}
+	if to == CircuitOpen && err != nil {
+		status.Error = true
+		status.ErrorData = err
+	}
+	pn.subscriptionManager.listenerManager.announceStatus(status)
+}
+
+// isEndpointFailure checks whether the result of a request counts as a failure
+// of its endpoint or origin: a connection error, a timeout or a 5xx response.
+func isEndpointFailure(status StatusResponse, err error) bool {
+	if err == nil {
+		return false
+	}
+	var connErr *pnerr.ConnectionError
+	return errors.As(err, &connErr) || status.Category == PNTimeoutCategory || status.StatusCode >= 500
+}
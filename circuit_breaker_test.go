@@ -0,0 +1,132 @@
+package pubnub
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCircuitBreakerTestPubNub(t *testing.T, cfg *CircuitBreakerConfig) (*PubNub, *int32, func() []testRequest, *Listener) {
+	code := int32(http.StatusInternalServerError)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&code)))
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn.Config.CircuitBreaker = cfg
+	listener := NewListener()
+	pn.AddListener(listener)
+	return pn, &code, requests, listener
+}
+
+// circuitTransitions returns the transitions announced to listener, in any order.
+func circuitTransitions(t *testing.T, listener *Listener, n int) []CircuitBreakerTransition {
+	var res []CircuitBreakerTransition
+	for len(res) < n {
+		select {
+		case status := <-listener.Status:
+			if transition, ok := status.AdditionalData.(CircuitBreakerTransition); ok {
+				res = append(res, transition)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("got %d transitions, expected %d", len(res), n)
+		}
+	}
+	return res
+}
+
+func TestCircuitBreakerConsecutiveFailures(t *testing.T) {
+	assert := assert.New(t)
+	pn, code, requests, listener := newCircuitBreakerTestPubNub(t, &CircuitBreakerConfig{ConsecutiveFailures: 2, OpenTimeout: 50 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		_, status, err := pn.Time().Execute()
+		assert.NotNil(err)
+		assert.Equal(500, status.StatusCode)
+	}
+	_, status, err := pn.Time().Execute()
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(err, &openErr))
+	assert.Equal("time", openErr.Group)
+	assert.Equal(pn.Config.Origin, openErr.Origin)
+	assert.True(openErr.RetryAfter > 0)
+	assert.Equal(PNCircuitOpenedCategory, status.Category)
+	assert.Len(requests(), 2)
+	assert.Equal([]CircuitBreakerTransition{{Group: "time", Origin: pn.Config.Origin, From: CircuitClosed, To: CircuitOpen}}, circuitTransitions(t, listener, 1))
+
+	// The other endpoint groups have their own circuit.
+	_, _, err = pn.Publish().Channel("ch").Message("hi").Execute()
+	assert.False(errors.As(err, &openErr))
+	assert.Len(requests(), 3)
+
+	// A successful probe closes the circuit.
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(code, http.StatusOK)
+	res, _, err := pn.Time().Execute()
+	require.NoError(t, err)
+	assert.Equal(int64(15000000000000000), res.Timetoken)
+	assert.ElementsMatch([]CircuitBreakerTransition{
+		{Group: "time", Origin: pn.Config.Origin, From: CircuitOpen, To: CircuitHalfOpen},
+		{Group: "time", Origin: pn.Config.Origin, From: CircuitHalfOpen, To: CircuitClosed},
+	}, circuitTransitions(t, listener, 2))
+}
+
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	assert := assert.New(t)
+	pn, _, requests, listener := newCircuitBreakerTestPubNub(t, &CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: 30 * time.Millisecond})
+
+	_, _, err := pn.Time().Execute()
+	assert.NotNil(err)
+	time.Sleep(40 * time.Millisecond)
+	_, _, err = pn.Time().Execute()
+	var openErr *CircuitOpenError
+	assert.False(errors.As(err, &openErr))
+	_, _, err = pn.Time().Execute()
+	assert.True(errors.As(err, &openErr))
+	assert.Len(requests(), 2)
+
+	transitions := circuitTransitions(t, listener, 3)
+	assert.Len(transitions, 3)
+	opened := 0
+	for _, transition := range transitions {
+		if transition.To == CircuitOpen {
+			opened++
+		}
+	}
+	assert.Equal(2, opened)
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	assert := assert.New(t)
+	pn, code, requests, _ := newCircuitBreakerTestPubNub(t, &CircuitBreakerConfig{ConsecutiveFailures: 10, FailureRate: 0.5, Window: 4})
+
+	for i := 0; i < 4; i++ {
+		if i%2 == 0 {
+			atomic.StoreInt32(code, http.StatusServiceUnavailable)
+		} else {
+			atomic.StoreInt32(code, http.StatusOK)
+		}
+		pn.Time().Execute()
+	}
+	_, _, err := pn.Time().Execute()
+	var openErr *CircuitOpenError
+	assert.True(errors.As(err, &openErr))
+	assert.Len(requests(), 4)
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	assert := assert.New(t)
+	pn, code, requests, _ := newCircuitBreakerTestPubNub(t, &CircuitBreakerConfig{ConsecutiveFailures: 1})
+	atomic.StoreInt32(code, http.StatusBadRequest)
+
+	for i := 0; i < 3; i++ {
+		_, status, err := pn.Time().Execute()
+		assert.NotNil(err)
+		assert.Equal(PNBadRequestCategory, status.Category)
+	}
+	assert.Len(requests(), 3)
+}
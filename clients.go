@@ -1,8 +1,15 @@
 package pubnub
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -12,15 +19,16 @@ import (
 // used when callers use NewHTTP2Client without PubNub's Config.
 const defaultHTTP2ClientMaxIdleConnsPerHost = 30
 
+// DialContextFunc dials the connections of the managed clients, see Config.DialContext.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
 // NewHTTP1Client creates a new HTTP 1 client with a new transport initialized with connect and read timeout
 func NewHTTP1Client(connectTimeout, responseReadTimeout, maxIdleConnsPerHost int) *http.Client {
-	transport := &http.Transport{
-		Dial: (&net.Dialer{
-			// Covers establishing a new TCP connection
-			Timeout: time.Duration(connectTimeout) * time.Second,
-		}).Dial,
-		MaxIdleConnsPerHost: maxIdleConnsPerHost,
-	}
+	return newHTTP1Client(nil, connectTimeout, responseReadTimeout, maxIdleConnsPerHost)
+}
+
+func newHTTP1Client(cfg *Config, connectTimeout, responseReadTimeout, maxIdleConnsPerHost int) *http.Client {
+	transport := newTransport(cfg, connectTimeout, maxIdleConnsPerHost)
 
 	client := &http.Client{
 		Transport: transport,
@@ -36,22 +44,17 @@ func NewHTTP1Client(connectTimeout, responseReadTimeout, maxIdleConnsPerHost int
 // to match NewConfigWithUserId unless the client is built through PubNub's GetClient/GetSubscribeClient,
 // which use Config.MaxIdleConnsPerHost.
 func NewHTTP2Client(connectTimeout int, responseReadTimeout int) *http.Client {
-	return newHTTP2Client(connectTimeout, responseReadTimeout, defaultHTTP2ClientMaxIdleConnsPerHost)
+	return newHTTP2Client(nil, connectTimeout, responseReadTimeout, defaultHTTP2ClientMaxIdleConnsPerHost)
 }
 
-func newHTTP2Client(connectTimeout, responseReadTimeout, maxIdleConnsPerHost int) *http.Client {
-	transport := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: time.Duration(connectTimeout) * time.Second,
-		}).Dial,
-		MaxIdleConnsPerHost: maxIdleConnsPerHost,
-		// Match net/http.DefaultTransport so TLS connections negotiate HTTP/2 via ALPN
-		// when the peer supports it.
-		ForceAttemptHTTP2: true,
-	}
+func newHTTP2Client(cfg *Config, connectTimeout, responseReadTimeout, maxIdleConnsPerHost int) *http.Client {
+	transport := newTransport(cfg, connectTimeout, maxIdleConnsPerHost)
+	// Match net/http.DefaultTransport so TLS connections negotiate HTTP/2 via ALPN
+	// when the peer supports it.
+	transport.ForceAttemptHTTP2 = true
 	if err := http2.ConfigureTransport(transport); err != nil {
 		// Should not occur on a freshly built Transport; degrade to HTTP/1-only.
-		return NewHTTP1Client(connectTimeout, responseReadTimeout, maxIdleConnsPerHost)
+		return newHTTP1Client(cfg, connectTimeout, responseReadTimeout, maxIdleConnsPerHost)
 	}
 
 	return &http.Client{
@@ -59,3 +62,75 @@ func newHTTP2Client(connectTimeout, responseReadTimeout, maxIdleConnsPerHost int
 		Timeout:   time.Duration(responseReadTimeout) * time.Second,
 	}
 }
+
+// newTransport returns the transport of the clients, with the proxy, TLS and
+// dialer settings of cfg when it isn't nil.
+func newTransport(cfg *Config, connectTimeout, maxIdleConnsPerHost int) *http.Transport {
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			// Covers establishing a new TCP connection
+			Timeout: time.Duration(connectTimeout) * time.Second,
+		}).DialContext,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+	}
+	if cfg == nil {
+		return transport
+	}
+
+	if cfg.DialContext != nil {
+		transport.DialContext = dialWithTimeout(cfg.DialContext, time.Duration(connectTimeout)*time.Second)
+	}
+	if cfg.ProxyURL != "" {
+		// An invalid proxy URL fails the requests instead of bypassing the proxy.
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		transport.Proxy = func(*http.Request) (*url.URL, error) {
+			return proxyURL, err
+		}
+	}
+	if cfg.RootCAs != nil || len(cfg.ClientCertificates) > 0 || len(cfg.SPKIPins) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:      cfg.RootCAs,
+			Certificates: cfg.ClientCertificates,
+		}
+		if len(cfg.SPKIPins) > 0 {
+			transport.TLSClientConfig.VerifyConnection = verifySPKIPins(cfg.SPKIPins)
+		}
+	}
+
+	return transport
+}
+
+// dialWithTimeout bounds the connections of dial with the connect timeout,
+// as the default net.Dialer.
+func dialWithTimeout(dial DialContextFunc, timeout time.Duration) DialContextFunc {
+	if timeout <= 0 {
+		return dial
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return dial(ctx, network, address)
+	}
+}
+
+// SPKIPin returns the pin of cert for Config.SPKIPins, the base64 SHA-256 hash
+// of its SubjectPublicKeyInfo.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifySPKIPins returns the check of the TLS connections whose verified chain
+// must contain a certificate of one of pins.
+func verifySPKIPins(pins []string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				if indexOf(pins, SPKIPin(cert)) >= 0 {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no SPKI pin matches the certificate chain of %s", cs.ServerName)
+	}
+}
@@ -1,12 +1,24 @@
 package pubnub
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func trustTestServerCert(existing *tls.Config, cert *x509.Certificate) *tls.Config {
@@ -109,3 +121,172 @@ func TestNewHTTP2Client_httpsUsesHTTP11WhenServerDoesNotAdvertiseHTTP2(t *testin
 		t.Fatalf("fallback Proto = %q, want HTTP/1.1", got)
 	}
 }
+
+func newTLSTestPubNub(t *testing.T, srv *httptest.Server, useHTTP2 bool) *PubNub {
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := NewConfigWithUserId(UserId("me"))
+	config.SubscribeKey = "sub"
+	config.Origin = u.Host
+	config.Secure = true
+	config.UseHTTP2 = useHTTP2
+	config.RootCAs = x509.NewCertPool()
+	config.RootCAs.AddCert(srv.Certificate())
+	return NewPubNub(config)
+}
+
+func newTimeTLSServer(http2 bool) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	}))
+	srv.EnableHTTP2 = http2
+	return srv
+}
+
+func TestManagedClientsRootCAsAndSPKIPins(t *testing.T) {
+	srv := newTimeTLSServer(true)
+	srv.StartTLS()
+	defer srv.Close()
+
+	pn := newTLSTestPubNub(t, srv, true)
+	pn.Config.SPKIPins = []string{"AAAA", SPKIPin(srv.Certificate())}
+	if _, _, err := pn.Time().Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := pn.lastNegotiatedProto; got != "HTTP/2.0" {
+		t.Fatalf("Proto = %q, want HTTP/2.0", got)
+	}
+
+	// The clients rebuilt after a reconnect keep the settings.
+	pn.Config.SPKIPins = []string{"AAAA"}
+	pn.invalidateManagedHTTPClientsAfterSubscribeReconnect()
+	_, _, err := pn.Time().Execute()
+	if err == nil || !strings.Contains(err.Error(), "no SPKI pin matches") {
+		t.Fatalf("err = %v, want a pin mismatch", err)
+	}
+}
+
+func TestManagedClientsClientCertificates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	srv := newTimeTLSServer(false)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	pn := newTLSTestPubNub(t, srv, false)
+	if _, _, err := pn.Time().Execute(); err == nil {
+		t.Fatal("request without client certificate succeeded")
+	}
+
+	pn = newTLSTestPubNub(t, srv, false)
+	pn.Config.ClientCertificates = []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}
+	if _, _, err := pn.Time().Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagedClientsProxyURL(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := parseProxyAuthorization(r.Header.Get("Proxy-Authorization"))
+		if !ok || user != "user" || pass != "secret" || r.URL.Host != "pubnub.invalid" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		atomic.AddInt32(&proxied, 1)
+		w.Write([]byte(`[15000000000000000]`))
+	}))
+	defer proxy.Close()
+
+	config := NewConfigWithUserId(UserId("me"))
+	config.Origin = "pubnub.invalid"
+	config.Secure = false
+	config.UseHTTP2 = false
+	config.ProxyURL = strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+	pn := NewPubNub(config)
+
+	if _, _, err := pn.Time().Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&proxied); got != 1 {
+		t.Fatalf("proxied requests = %d, want 1", got)
+	}
+	if strings.Contains(config.GetLogString(), "secret") {
+		t.Fatal("the log string contains the proxy password")
+	}
+}
+
+func parseProxyAuthorization(header string) (string, string, bool) {
+	r := &http.Request{Header: http.Header{"Authorization": {header}}}
+	return r.BasicAuth()
+}
+
+func TestManagedClientsDialContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	}))
+	defer srv.Close()
+
+	var dialed int32
+	config := NewConfigWithUserId(UserId("me"))
+	config.Origin = "pubnub.invalid"
+	config.Secure = false
+	config.UseHTTP2 = true
+	config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dialed, 1)
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	pn := NewPubNub(config)
+
+	if _, _, err := pn.Time().Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&dialed); got != 1 {
+		t.Fatalf("dials = %d, want 1", got)
+	}
+}
+
+func TestManagedClientsDialContextConnectTimeout(t *testing.T) {
+	config := NewConfigWithUserId(UserId("me"))
+	var deadline time.Time
+	var hasDeadline bool
+	config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		deadline, hasDeadline = ctx.Deadline()
+		return nil, errors.New("no network")
+	}
+
+	start := time.Now()
+	transport := newTransport(config, 3, 0)
+	if _, err := transport.DialContext(context.Background(), "tcp", "ps.pndsn.com:443"); err == nil {
+		t.Fatal("expected the error of the dialer")
+	}
+	if !hasDeadline {
+		t.Fatal("the dial context has no deadline")
+	}
+	if d := deadline.Sub(start); d < 3*time.Second || d > 4*time.Second {
+		t.Fatalf("dial deadline in %v, want the 3s connect timeout", d)
+	}
+}
@@ -0,0 +1,171 @@
+// Command pncrypto inspects encrypted PubNub messages and files offline and
+// generates test vectors.
+//
+//	pncrypto -key enigma -key other -payload '"UE5FRAFBQ1JIEA..."'
+//	pncrypto -key enigma -file photo.jpg
+//	pncrypto -key enigma -vectors '{"text":"hello"}'
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pubnub/go/v9/crypto"
+)
+
+type keysFlag []string
+
+func (k *keysFlag) String() string {
+	return strings.Join(*k, ",")
+}
+
+func (k *keysFlag) Set(value string) error {
+	*k = append(*k, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command with args and returns its exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pncrypto", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var keys keysFlag
+	flags.Var(&keys, "key", "candidate cipher key, can be repeated")
+	payload := flags.String("payload", "", "encrypted message, as received or as a JSON string")
+	file := flags.String("file", "", "encrypted file")
+	vectors := flags.String("vectors", "", "plaintext to encrypt as test vectors")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	modes := 0
+	for _, v := range []string{*payload, *file, *vectors} {
+		if v != "" {
+			modes++
+		}
+	}
+	if len(keys) == 0 || modes != 1 {
+		fmt.Fprintln(stderr, "usage: pncrypto -key KEY [-key KEY...] (-payload PAYLOAD | -file FILE | -vectors PLAINTEXT) [-json]")
+		return 2
+	}
+
+	if *vectors != "" {
+		res, err := crypto.GenerateTestVectors([]byte(*vectors), keys)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err := printVectors(stdout, res, *asJSON); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	data := []byte(*payload)
+	if *file != "" {
+		var err error
+		if data, err = os.ReadFile(*file); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	report := crypto.Inspect(data, keys)
+	if err := printReport(stdout, report, *asJSON); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(report.Decrypted()) == 0 {
+		return 1
+	}
+	return 0
+}
+
+func printVectors(w io.Writer, vectors []crypto.TestVector, asJSON bool) error {
+	if asJSON {
+		return printJSON(w, vectors)
+	}
+	for _, v := range vectors {
+		fmt.Fprintf(w, "%-6s key=%s randomIv=%t\n  %s\n", v.Cryptor, v.CipherKey, v.RandomIv, v.Encrypted)
+	}
+	return nil
+}
+
+// jsonAttempt is a crypto.DecryptAttempt with its error as a string.
+type jsonAttempt struct {
+	crypto.DecryptAttempt
+	Plaintext string `json:",omitempty"`
+	Error     string `json:",omitempty"`
+}
+
+// jsonData is a crypto.InspectedData with its error as a string.
+type jsonData struct {
+	crypto.InspectedData
+	HeaderError string `json:",omitempty"`
+}
+
+func printReport(w io.Writer, report *crypto.InspectReport, asJSON bool) error {
+	if asJSON {
+		var res struct {
+			Data     []jsonData
+			Attempts []jsonAttempt
+		}
+		for _, d := range report.Data {
+			j := jsonData{InspectedData: d}
+			if d.HeaderError != nil {
+				j.HeaderError = d.HeaderError.Error()
+			}
+			res.Data = append(res.Data, j)
+		}
+		for _, a := range report.Attempts {
+			j := jsonAttempt{DecryptAttempt: a, Plaintext: string(a.Plaintext)}
+			if a.Error != nil {
+				j.Error = a.Error.Error()
+			}
+			res.Attempts = append(res.Attempts, j)
+		}
+		return printJSON(w, res)
+	}
+	for _, d := range report.Data {
+		switch {
+		case d.HeaderError != nil:
+			fmt.Fprintf(w, "%s: invalid header: %v\n", d.Encoding, d.HeaderError)
+		case d.Header.Legacy:
+			fmt.Fprintf(w, "%s: no header (legacy), data length %d\n", d.Encoding, d.Header.DataLength)
+		default:
+			fmt.Fprintf(w, "%s: cryptor %s, version %d, metadata length %d, data length %d\n",
+				d.Encoding, d.Header.CryptorId, d.Header.Version, d.Header.MetadataLength, d.Header.DataLength)
+		}
+	}
+	for _, a := range report.Attempts {
+		mode := a.Cryptor
+		if a.Cryptor == "legacy" {
+			mode = fmt.Sprintf("legacy randomIv=%t", a.RandomIv)
+		}
+		if a.Error != nil {
+			fmt.Fprintf(w, "FAIL %s %s key=%s: %v\n", a.Encoding, mode, a.CipherKey, a.Error)
+			continue
+		}
+		plaintext := string(a.Plaintext)
+		if !utf8.Valid(a.Plaintext) {
+			plaintext = fmt.Sprintf("%d binary bytes", len(a.Plaintext))
+		}
+		fmt.Fprintf(w, "OK   %s %s key=%s json=%t: %s\n", a.Encoding, mode, a.CipherKey, a.JSON, plaintext)
+	}
+	return nil
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, stderr := runCommand("-payload", "x")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: pncrypto")
+
+	code, _, _ = runCommand("-key", "k", "-payload", "x", "-vectors", "y")
+	assert.Equal(t, 2, code)
+}
+
+func TestRunVectorsAndPayload(t *testing.T) {
+	assert := assert.New(t)
+	code, stdout, stderr := runCommand("-key", "enigma", "-vectors", `{"text":"hello"}`, "-json")
+	require.Equal(t, 0, code, stderr)
+	var vectors []crypto.TestVector
+	require.NoError(t, json.Unmarshal([]byte(stdout), &vectors))
+	require.NotEmpty(t, vectors)
+
+	// Each vector is decrypted back by the inspection of its payload.
+	for _, v := range vectors {
+		code, stdout, _ = runCommand("-key", "other", "-key", "enigma", "-payload", v.Encrypted)
+		assert.Equal(0, code, v.Cryptor)
+		assert.Contains(stdout, "key=enigma json=true: {\"text\":\"hello\"}", v.Cryptor)
+	}
+
+	// A wrong key can pass the padding check of a CBC decryption, not give JSON.
+	_, stdout, _ = runCommand("-key", "other", "-payload", vectors[0].Encrypted)
+	assert.Contains(stdout, "key=other")
+	assert.NotContains(stdout, "json=true")
+}
+
+func TestRunFile(t *testing.T) {
+	module, err := crypto.NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, err)
+	encrypted, err := module.Encrypt([]byte("file content"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "file.enc")
+	require.NoError(t, os.WriteFile(path, encrypted, 0600))
+
+	code, stdout, _ := runCommand("-key", "enigma", "-file", path)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "file content")
+
+	code, _, stderr := runCommand("-key", "enigma", "-file", filepath.Join(t.TempDir(), "missing"))
+	assert.Equal(t, 1, code)
+	assert.NotEmpty(t, stderr)
+}
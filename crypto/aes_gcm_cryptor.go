@@ -0,0 +1,108 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// aesGcmSegmentSize is the size of the plaintext segments sealed separately,
+// so that streams are encrypted and authenticated without buffering them.
+const aesGcmSegmentSize = 64 * 1024
+
+const aesGcmNonceSize = 12
+
+var aesGcmId = "AGCM"
+
+// aesGcmCryptor encrypts with AES-256-GCM. The data is split in segments of
+// aesGcmSegmentSize bytes, each sealed with the random nonce of the metadata
+// XORed with the segment counter. The last segment is flagged in the
+// additional data, so a truncated stream fails authentication.
+type aesGcmCryptor struct {
+	aead        cipher.AEAD
+	segmentSize int
+}
+
+func NewAesGcmCryptor(cipherKey string) (ExtendedCryptor, error) {
+	block, e := aesCipher(cipherKey)
+	if e != nil {
+		return nil, e
+	}
+	return newAesGcmCryptor(block)
+}
+
+func newAesGcmCryptor(block cipher.Block) (ExtendedCryptor, error) {
+	aead, e := cipher.NewGCMWithNonceSize(block, aesGcmNonceSize)
+	if e != nil {
+		return nil, e
+	}
+
+	return &aesGcmCryptor{
+		aead:        aead,
+		segmentSize: aesGcmSegmentSize,
+	}, nil
+}
+
+func (c *aesGcmCryptor) Id() string {
+	return aesGcmId
+}
+
+func (c *aesGcmCryptor) Encrypt(message []byte) (*EncryptedData, error) {
+	nonce := generateIV(aesGcmNonceSize)
+	encryptedBytes, e := io.ReadAll(newAeadEncryptingReader(bytes.NewReader(message), c.aead, nonce, c.segmentSize))
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedData{
+		Metadata: nonce,
+		Data:     encryptedBytes,
+	}, nil
+}
+
+func (c *aesGcmCryptor) Decrypt(encryptedData *EncryptedData) ([]byte, error) {
+	if len(encryptedData.Metadata) != aesGcmNonceSize {
+		return nil, errors.New("decryption error")
+	}
+
+	return io.ReadAll(newAeadDecryptingReader(bytes.NewReader(encryptedData.Data), c.aead, encryptedData.Metadata, c.segmentSize))
+}
+
+func (c *aesGcmCryptor) EncryptStream(reader io.Reader) (*EncryptedStreamData, error) {
+	nonce := generateIV(aesGcmNonceSize)
+
+	return &EncryptedStreamData{
+		Metadata: nonce,
+		Reader:   newAeadEncryptingReader(reader, c.aead, nonce, c.segmentSize),
+	}, nil
+}
+
+func (c *aesGcmCryptor) DecryptStream(encryptedData *EncryptedStreamData) (io.Reader, error) {
+	if len(encryptedData.Metadata) != aesGcmNonceSize {
+		return nil, errors.New("decryption error")
+	}
+	return newAeadDecryptingReader(encryptedData.Reader, c.aead, encryptedData.Metadata, c.segmentSize), nil
+}
+
+// segmentNonce returns the nonce of the segment counter: the last 8 bytes of
+// the base nonce XORed with the big endian counter.
+func segmentNonce(nonce []byte, counter uint64) []byte {
+	res := make([]byte, len(nonce))
+	copy(res, nonce)
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], counter)
+	for i := range c {
+		res[len(res)-8+i] ^= c[i]
+	}
+	return res
+}
+
+// segmentAdditionalData flags the last segment of the data.
+func segmentAdditionalData(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
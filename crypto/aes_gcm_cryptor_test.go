@@ -0,0 +1,160 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAesGcmCryptor(t *testing.T, segmentSize int) *aesGcmCryptor {
+	cryptor, e := NewAesGcmCryptor("enigma")
+	if e != nil {
+		t.Fatal(e)
+	}
+	c := cryptor.(*aesGcmCryptor)
+	c.segmentSize = segmentSize
+	return c
+}
+
+func Test_AesGCM_EncryptDecrypt(t *testing.T) {
+	cryptor := newTestAesGcmCryptor(t, aesGcmSegmentSize)
+	canDecrypt := func(in []byte) bool {
+		encrypted, e := cryptor.Encrypt(in)
+		if e != nil {
+			return false
+		}
+		decrypted, e := cryptor.Decrypt(encrypted)
+		return e == nil && bytes.Equal(in, decrypted)
+	}
+	if err := quick.Check(canDecrypt, defaultPropertyTestConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_AesGCM_StreamInterop(t *testing.T) {
+	cryptor := newTestAesGcmCryptor(t, 16)
+	canDecryptEncryptStreamResult := func(in []byte) bool {
+		output, e := cryptor.EncryptStream(bytes.NewReader(in))
+		if e != nil {
+			return false
+		}
+		encrData, e := io.ReadAll(output.Reader)
+		if e != nil {
+			return false
+		}
+		decrypted, e := cryptor.Decrypt(&EncryptedData{Data: encrData, Metadata: output.Metadata})
+		return e == nil && bytes.Equal(in, decrypted)
+	}
+	canDecryptStreamEncryptResult := func(in []byte) bool {
+		output, e := cryptor.Encrypt(in)
+		if e != nil {
+			return false
+		}
+		r, e := cryptor.DecryptStream(&EncryptedStreamData{Reader: bytes.NewReader(output.Data), Metadata: output.Metadata})
+		if e != nil {
+			return false
+		}
+		decrypted, e := io.ReadAll(r)
+		return e == nil && bytes.Equal(in, decrypted)
+	}
+
+	if err := quick.Check(canDecryptEncryptStreamResult, defaultPropertyTestConfig); err != nil {
+		t.Error(err)
+	}
+	if err := quick.Check(canDecryptStreamEncryptResult, defaultPropertyTestConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_AesGCM_Segments(t *testing.T) {
+	assert := assert.New(t)
+	cryptor := newTestAesGcmCryptor(t, 16)
+	overhead := cryptor.aead.Overhead()
+
+	for _, size := range []int{0, 15, 16, 17, 48} {
+		in := bytes.Repeat([]byte{'a'}, size)
+		encrypted, e := cryptor.Encrypt(in)
+		assert.Nil(e)
+		assert.Equal(aesGcmNonceSize, len(encrypted.Metadata))
+		segments := (size + 15) / 16
+		if segments == 0 {
+			segments = 1
+		}
+		assert.Equal(size+segments*overhead, len(encrypted.Data))
+
+		decrypted, e := cryptor.Decrypt(encrypted)
+		assert.Nil(e)
+		assert.Equal(in, decrypted)
+	}
+}
+
+func Test_AesGCM_RejectsTamperedData(t *testing.T) {
+	assert := assert.New(t)
+	cryptor := newTestAesGcmCryptor(t, 16)
+	overhead := cryptor.aead.Overhead()
+	segment := 16 + overhead
+
+	encrypted, e := cryptor.Encrypt(bytes.Repeat([]byte{'a'}, 40))
+	assert.Nil(e)
+	assert.Equal(3*segment-8, len(encrypted.Data))
+
+	flipped := append([]byte(nil), encrypted.Data...)
+	flipped[3] ^= 1
+	_, e = cryptor.Decrypt(&EncryptedData{Data: flipped, Metadata: encrypted.Metadata})
+	assert.NotNil(e)
+
+	// Dropping the last segments is detected.
+	_, e = cryptor.Decrypt(&EncryptedData{Data: encrypted.Data[:2*segment], Metadata: encrypted.Metadata})
+	assert.NotNil(e)
+
+	// Reordering segments is detected.
+	swapped := append(append(append([]byte(nil), encrypted.Data[segment:2*segment]...), encrypted.Data[:segment]...), encrypted.Data[2*segment:]...)
+	_, e = cryptor.Decrypt(&EncryptedData{Data: swapped, Metadata: encrypted.Metadata})
+	assert.NotNil(e)
+
+	_, e = cryptor.Decrypt(&EncryptedData{Data: encrypted.Data, Metadata: encrypted.Metadata[:4]})
+	assert.NotNil(e)
+
+	// The stream returns the authenticated segments and then the error.
+	r, e := cryptor.DecryptStream(&EncryptedStreamData{Reader: bytes.NewReader(flipped[:2*segment]), Metadata: encrypted.Metadata})
+	assert.Nil(e)
+	_, e = io.ReadAll(r)
+	assert.NotNil(e)
+}
+
+func Test_AesGCM_Module(t *testing.T) {
+	assert := assert.New(t)
+	gcmModule, e := NewAesGcmCryptoModule("enigma", true)
+	assert.Nil(e)
+	cbcModule, _ := NewAesCbcCryptoModule("enigma", true)
+	legacyModule, _ := NewLegacyCryptoModule("enigma", true)
+
+	encrypted, e := gcmModule.Encrypt([]byte("hello"))
+	assert.Nil(e)
+	id, e := peekHeaderCryptorId(encrypted)
+	assert.Nil(e)
+	assert.Equal(aesGcmId, *id)
+
+	_, e = cbcModule.Decrypt(encrypted)
+	assert.NotNil(e)
+
+	for _, m := range []CryptoModule{gcmModule, cbcModule, legacyModule} {
+		encrypted, e := m.Encrypt([]byte("hello"))
+		assert.Nil(e)
+		decrypted, e := gcmModule.Decrypt(encrypted)
+		assert.Nil(e)
+		assert.Equal("hello", string(decrypted))
+	}
+
+	in := bytes.Repeat([]byte("file content "), 10000)
+	r, e := gcmModule.EncryptStream(bytes.NewReader(in))
+	assert.Nil(e)
+	r, e = gcmModule.DecryptStream(r)
+	assert.Nil(e)
+	decrypted, e := io.ReadAll(r)
+	assert.Nil(e)
+	assert.Equal(in, decrypted)
+}
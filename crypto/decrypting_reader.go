@@ -132,3 +132,75 @@ func (decryptingReader *blockModeDecryptingReader) Read(p []byte) (n int, err er
 	n, e := decryptingReader.decryptUntilPFull(p[alreadyWrote:])
 	return alreadyWrote + n, e
 }
+
+func newAeadDecryptingReader(r io.Reader, aead cipher.AEAD, nonce []byte, segmentSize int) io.Reader {
+	return &aeadDecryptingReader{
+		r:           bufio.NewReader(r),
+		aead:        aead,
+		nonce:       nonce,
+		segmentSize: segmentSize,
+		buffer:      bytes.NewBuffer(nil),
+	}
+}
+
+// aeadDecryptingReader opens the segments sealed by aeadEncryptingReader. The
+// data of a segment is returned only once the segment is authenticated.
+type aeadDecryptingReader struct {
+	r           *bufio.Reader
+	aead        cipher.AEAD
+	nonce       []byte
+	segmentSize int
+	counter     uint64
+	buffer      *bytes.Buffer
+	err         error
+}
+
+func (decryptingReader *aeadDecryptingReader) openNextSegment() error {
+	segment := make([]byte, decryptingReader.segmentSize+decryptingReader.aead.Overhead())
+	n, readErr := io.ReadFull(decryptingReader.r, segment)
+	if readErr != nil && readErr != io.EOF && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+		return readErr
+	}
+	if n < decryptingReader.aead.Overhead() {
+		return errors.New("decryption error")
+	}
+
+	last := readErr != nil
+	if !last {
+		if _, e := decryptingReader.r.Peek(1); e == io.EOF {
+			last = true
+		} else if e != nil {
+			return e
+		}
+	}
+
+	nonce := segmentNonce(decryptingReader.nonce, decryptingReader.counter)
+	decrypted, e := decryptingReader.aead.Open(segment[:0], nonce, segment[:n], segmentAdditionalData(last))
+	if e != nil {
+		return errors.New("decryption error")
+	}
+	decryptingReader.buffer.Write(decrypted)
+	decryptingReader.counter++
+	if last {
+		decryptingReader.err = io.EOF
+	}
+	return nil
+}
+
+func (decryptingReader *aeadDecryptingReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, errors.New("cannot read into empty buffer")
+	}
+
+	for decryptingReader.buffer.Len() == 0 {
+		if decryptingReader.err != nil {
+			return 0, decryptingReader.err
+		}
+		if e := decryptingReader.openNextSegment(); e != nil {
+			decryptingReader.err = e
+			return 0, e
+		}
+	}
+
+	return decryptingReader.buffer.Read(p)
+}
@@ -84,3 +84,64 @@ func (encryptingReader *blockModeEncryptingReader) Read(p []byte) (n int, err er
 
 	return alreadyWrote + n, e
 }
+
+func newAeadEncryptingReader(r io.Reader, aead cipher.AEAD, nonce []byte, segmentSize int) io.Reader {
+	return &aeadEncryptingReader{
+		r:           bufio.NewReader(r),
+		aead:        aead,
+		nonce:       nonce,
+		segmentSize: segmentSize,
+		buffer:      bytes.NewBuffer(nil),
+	}
+}
+
+// aeadEncryptingReader seals the data in segments of segmentSize bytes, see aesGcmCryptor.
+type aeadEncryptingReader struct {
+	r           *bufio.Reader
+	aead        cipher.AEAD
+	nonce       []byte
+	segmentSize int
+	counter     uint64
+	buffer      *bytes.Buffer
+	done        bool
+}
+
+func (encryptingReader *aeadEncryptingReader) sealNextSegment() error {
+	segment := make([]byte, encryptingReader.segmentSize)
+	n, readErr := io.ReadFull(encryptingReader.r, segment)
+	if readErr != nil && readErr != io.EOF && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+		return readErr
+	}
+
+	last := readErr != nil
+	if !last {
+		if _, e := encryptingReader.r.Peek(1); e == io.EOF {
+			last = true
+		} else if e != nil {
+			return e
+		}
+	}
+
+	nonce := segmentNonce(encryptingReader.nonce, encryptingReader.counter)
+	encryptingReader.buffer.Write(encryptingReader.aead.Seal(nil, nonce, segment[:n], segmentAdditionalData(last)))
+	encryptingReader.counter++
+	encryptingReader.done = last
+	return nil
+}
+
+func (encryptingReader *aeadEncryptingReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, errors.New("cannot read into empty buffer")
+	}
+
+	for encryptingReader.buffer.Len() == 0 {
+		if encryptingReader.done {
+			return 0, io.EOF
+		}
+		if e := encryptingReader.sealNextSegment(); e != nil {
+			return 0, e
+		}
+	}
+
+	return encryptingReader.buffer.Read(p)
+}
@@ -0,0 +1,125 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var envelopeId = "AGEV"
+
+// envelopeCryptor encrypts with AES-256-GCM under a data key of a KeyProvider.
+// The metadata is the wrapped data key, prefixed with its big endian 2 bytes
+// length, followed by the metadata of the AES-GCM cryptor.
+type envelopeCryptor struct {
+	provider KeyProvider
+}
+
+func NewEnvelopeCryptor(provider KeyProvider) ExtendedCryptor {
+	return &envelopeCryptor{provider: provider}
+}
+
+func (c *envelopeCryptor) Id() string {
+	return envelopeId
+}
+
+func dataKeyCryptor(key []byte) (ExtendedCryptor, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("data key size must be %d bytes", dataKeySize)
+	}
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, e
+	}
+	return newAesGcmCryptor(block)
+}
+
+func (c *envelopeCryptor) encryptor() ([]byte, ExtendedCryptor, error) {
+	key, wrappedKey, e := c.provider.DataKey()
+	if e != nil {
+		return nil, nil, e
+	}
+	if len(wrappedKey) == 0 || len(wrappedKey) > math.MaxUint16 {
+		return nil, nil, fmt.Errorf("wrapped key size must be between 1 and %d bytes", math.MaxUint16)
+	}
+	cryptor, e := dataKeyCryptor(key)
+	return wrappedKey, cryptor, e
+}
+
+// decryptor returns the cryptor of the wrapped data key in metadata and the metadata of the cryptor.
+func (c *envelopeCryptor) decryptor(metadata []byte) (ExtendedCryptor, []byte, error) {
+	if len(metadata) < 2 {
+		return nil, nil, errors.New("decryption error: invalid wrapped key")
+	}
+	size := int(binary.BigEndian.Uint16(metadata))
+	if len(metadata) < 2+size {
+		return nil, nil, errors.New("decryption error: invalid wrapped key")
+	}
+	key, e := c.provider.UnwrapKey(metadata[2 : 2+size])
+	if e != nil {
+		return nil, nil, e
+	}
+	cryptor, e := dataKeyCryptor(key)
+	if e != nil {
+		return nil, nil, e
+	}
+	return cryptor, metadata[2+size:], nil
+}
+
+func wrappedKeyMetadata(wrappedKey []byte, metadata []byte) []byte {
+	res := make([]byte, 2, 2+len(wrappedKey)+len(metadata))
+	binary.BigEndian.PutUint16(res, uint16(len(wrappedKey)))
+	res = append(res, wrappedKey...)
+	return append(res, metadata...)
+}
+
+func (c *envelopeCryptor) Encrypt(message []byte) (*EncryptedData, error) {
+	wrappedKey, cryptor, e := c.encryptor()
+	if e != nil {
+		return nil, e
+	}
+	encrypted, e := cryptor.Encrypt(message)
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedData{
+		Metadata: wrappedKeyMetadata(wrappedKey, encrypted.Metadata),
+		Data:     encrypted.Data,
+	}, nil
+}
+
+func (c *envelopeCryptor) Decrypt(encryptedData *EncryptedData) ([]byte, error) {
+	cryptor, metadata, e := c.decryptor(encryptedData.Metadata)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.Decrypt(&EncryptedData{Metadata: metadata, Data: encryptedData.Data})
+}
+
+func (c *envelopeCryptor) EncryptStream(reader io.Reader) (*EncryptedStreamData, error) {
+	wrappedKey, cryptor, e := c.encryptor()
+	if e != nil {
+		return nil, e
+	}
+	encrypted, e := cryptor.EncryptStream(reader)
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedStreamData{
+		Metadata: wrappedKeyMetadata(wrappedKey, encrypted.Metadata),
+		Reader:   encrypted.Reader,
+	}, nil
+}
+
+func (c *envelopeCryptor) DecryptStream(encryptedData *EncryptedStreamData) (io.Reader, error) {
+	cryptor, metadata, e := c.decryptor(encryptedData.Metadata)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.DecryptStream(&EncryptedStreamData{Metadata: metadata, Reader: encryptedData.Reader})
+}
@@ -0,0 +1,154 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingKeyProvider struct {
+	KeyProvider
+	dataKeys, unwraps int
+}
+
+func (p *countingKeyProvider) DataKey() ([]byte, []byte, error) {
+	p.dataKeys++
+	return p.KeyProvider.DataKey()
+}
+
+func (p *countingKeyProvider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	p.unwraps++
+	return p.KeyProvider.UnwrapKey(wrappedKey)
+}
+
+func TestEnvelopeCryptoModule(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "master.key")
+	provider, e := NewFileKeyProvider(path)
+	require.NoError(t, e)
+	module := NewEnvelopeCryptoModule(provider)
+
+	encrypted, e := module.Encrypt([]byte("hello"))
+	require.NoError(t, e)
+	id, e := peekHeaderCryptorId(encrypted)
+	assert.Nil(e)
+	assert.Equal(envelopeId, *id)
+
+	// Another provider with the same master key file unwraps the data key.
+	provider, e = NewFileKeyProvider(path)
+	require.NoError(t, e)
+	decrypted, e := NewEnvelopeCryptoModule(provider).Decrypt(encrypted)
+	assert.Nil(e)
+	assert.Equal("hello", string(decrypted))
+
+	other, e := NewFileKeyProvider(filepath.Join(t.TempDir(), "other.key"))
+	require.NoError(t, e)
+	_, e = NewEnvelopeCryptoModule(other).Decrypt(encrypted)
+	assert.NotNil(e)
+}
+
+func TestEnvelopeCryptoModuleStream(t *testing.T) {
+	assert := assert.New(t)
+	provider, e := NewFileKeyProvider(filepath.Join(t.TempDir(), "master.key"))
+	require.NoError(t, e)
+	module := NewEnvelopeCryptoModule(provider)
+
+	in := bytes.Repeat([]byte("file content "), 10000)
+	r, e := module.EncryptStream(bytes.NewReader(in))
+	require.NoError(t, e)
+	encrypted, e := io.ReadAll(r)
+	require.NoError(t, e)
+
+	decrypted, e := module.Decrypt(encrypted)
+	assert.Nil(e)
+	assert.Equal(in, decrypted)
+
+	r, e = module.DecryptStream(bytes.NewReader(encrypted))
+	require.NoError(t, e)
+	decrypted, e = io.ReadAll(r)
+	assert.Nil(e)
+	assert.Equal(in, decrypted)
+}
+
+func TestCachingKeyProvider(t *testing.T) {
+	assert := assert.New(t)
+	fileProvider, e := NewFileKeyProvider(filepath.Join(t.TempDir(), "master.key"))
+	require.NoError(t, e)
+	counting := &countingKeyProvider{KeyProvider: fileProvider}
+	provider := NewCachingKeyProvider(counting, time.Minute).(*cachingKeyProvider)
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	module := NewEnvelopeCryptoModule(provider)
+
+	first, e := module.Encrypt([]byte("first"))
+	require.NoError(t, e)
+	second, e := module.Encrypt([]byte("second"))
+	require.NoError(t, e)
+	assert.Equal(1, counting.dataKeys)
+
+	for _, data := range [][]byte{first, second, first} {
+		_, e := module.Decrypt(data)
+		assert.Nil(e)
+	}
+	assert.Equal(1, counting.unwraps)
+
+	now = now.Add(time.Minute)
+	_, e = module.Encrypt([]byte("third"))
+	require.NoError(t, e)
+	_, e = module.Decrypt(first)
+	assert.Nil(e)
+	assert.Equal(2, counting.dataKeys)
+	assert.Equal(2, counting.unwraps)
+	assert.Len(provider.unwrapped, 1)
+}
+
+func TestEnvelopeCryptoModuleCachesByDefault(t *testing.T) {
+	assert := assert.New(t)
+	fileProvider, e := NewFileKeyProvider(filepath.Join(t.TempDir(), "master.key"))
+	require.NoError(t, e)
+	counting := &countingKeyProvider{KeyProvider: fileProvider}
+	module := NewEnvelopeCryptoModule(counting)
+
+	for i := 0; i < 3; i++ {
+		encrypted, e := module.Encrypt([]byte("hello"))
+		require.NoError(t, e)
+		_, e = module.Decrypt(encrypted)
+		assert.Nil(e)
+	}
+	assert.Equal(1, counting.dataKeys)
+	assert.Equal(1, counting.unwraps)
+
+	// Without a TTL the provider is called for every message.
+	counting = &countingKeyProvider{KeyProvider: fileProvider}
+	module = NewEnvelopeCryptoModule(NewCachingKeyProvider(counting, 0))
+	for i := 0; i < 2; i++ {
+		_, e := module.Encrypt([]byte("hello"))
+		require.NoError(t, e)
+	}
+	assert.Equal(2, counting.dataKeys)
+}
+
+type failingKeyProvider struct{}
+
+func (failingKeyProvider) DataKey() ([]byte, []byte, error) {
+	return nil, nil, errors.New("kms unavailable")
+}
+
+func (failingKeyProvider) UnwrapKey([]byte) ([]byte, error) {
+	return nil, errors.New("kms unavailable")
+}
+
+func TestEnvelopeCryptoModuleProviderError(t *testing.T) {
+	module := NewEnvelopeCryptoModule(failingKeyProvider{})
+
+	_, e := module.Encrypt([]byte("hello"))
+	assert.ErrorContains(t, e, "kms unavailable")
+	_, e = module.EncryptStream(bytes.NewReader([]byte("hello")))
+	assert.ErrorContains(t, e, "kms unavailable")
+}
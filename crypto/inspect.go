@@ -0,0 +1,240 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Encodings of the encrypted data of an inspected payload.
+const (
+	EncodingRaw          = "raw"
+	EncodingBase64       = "base64"
+	EncodingDoubleBase64 = "base64x2"
+)
+
+// HeaderInfo describes the crypto header of encrypted data.
+type HeaderInfo struct {
+	// Legacy is true when the data has no header, as encrypted by the legacy cryptor.
+	Legacy         bool
+	CryptorId      string
+	Version        int
+	MetadataLength int
+	DataLength     int
+}
+
+// InspectedData is a decoding of an inspected payload and its header.
+type InspectedData struct {
+	Encoding    string
+	Header      *HeaderInfo
+	HeaderError error
+	data        []byte
+}
+
+// DecryptAttempt is the result of the decryption of an inspected payload with a
+// cryptor and a cipher key.
+type DecryptAttempt struct {
+	Encoding  string
+	Cryptor   string
+	CipherKey string
+	// RandomIv is the IV mode of the legacy cryptor.
+	RandomIv  bool
+	Plaintext []byte
+	// JSON is true when the plaintext is valid JSON, as the messages are. A
+	// wrong key may decrypt to garbage with a valid padding.
+	JSON  bool
+	Error error
+}
+
+// InspectReport is the result of Inspect.
+type InspectReport struct {
+	Data     []InspectedData
+	Attempts []DecryptAttempt
+}
+
+// Decrypted returns the successful attempts.
+func (r *InspectReport) Decrypted() []DecryptAttempt {
+	var res []DecryptAttempt
+	for _, a := range r.Attempts {
+		if a.Error == nil {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// ParseHeaderInfo parses the crypto header of data.
+func ParseHeaderInfo(data []byte) (*HeaderInfo, error) {
+	id, encrypted, e := parseHeader(data)
+	if e != nil {
+		return nil, e
+	}
+	if *id == legacyId {
+		return &HeaderInfo{Legacy: true, DataLength: len(data)}, nil
+	}
+	return &HeaderInfo{
+		CryptorId:      *id,
+		Version:        int(data[versionPosition]),
+		MetadataLength: len(encrypted.Metadata),
+		DataLength:     len(encrypted.Data),
+	}, nil
+}
+
+// decodePayload returns the candidate encodings of payload: as is, base64
+// decoded and base64 decoded twice. A JSON string payload is unquoted first.
+// The spaces around the base64 are ignored, not the ones of the raw data which
+// can end an encrypted file.
+func decodePayload(payload []byte) []InspectedData {
+	var s string
+	if json.Unmarshal(payload, &s) == nil {
+		payload = []byte(s)
+	}
+
+	res := []InspectedData{{Encoding: EncodingRaw, data: payload}}
+	decoded, e := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(payload)))
+	if e != nil || len(decoded) == 0 {
+		return res
+	}
+	res = append(res, InspectedData{Encoding: EncodingBase64, data: decoded})
+	if twice, e := base64.StdEncoding.DecodeString(string(decoded)); e == nil && len(twice) > 0 {
+		res = append(res, InspectedData{Encoding: EncodingDoubleBase64, data: twice})
+	}
+	return res
+}
+
+type inspectCryptor struct {
+	name     string
+	randomIv bool
+	cryptor  ExtendedCryptor
+}
+
+// inspectCryptors returns the cryptors able to decrypt data of header with cipherKey.
+func inspectCryptors(header *HeaderInfo, metadata []byte, cipherKey string) ([]inspectCryptor, error) {
+	if header.Legacy {
+		var res []inspectCryptor
+		for _, randomIv := range []bool{false, true} {
+			c, e := NewLegacyCryptor(cipherKey, randomIv)
+			if e != nil {
+				return nil, e
+			}
+			res = append(res, inspectCryptor{name: "legacy", randomIv: randomIv, cryptor: c})
+		}
+		return res, nil
+	}
+
+	var c ExtendedCryptor
+	var e error
+	switch header.CryptorId {
+	case crivId:
+		c, e = NewAesCbcCryptor(cipherKey)
+	case aesGcmId:
+		c, e = NewAesGcmCryptor(cipherKey)
+	case keyringId:
+		// The key ID of the metadata is assumed to be the one of cipherKey.
+		if len(metadata) < 1 || len(metadata) < 1+int(metadata[0]) {
+			return nil, errors.New("invalid key id")
+		}
+		var keyring *Keyring
+		if keyring, e = NewKeyring(string(metadata[1:1+metadata[0]]), cipherKey); e == nil {
+			c = NewKeyringCryptor(keyring)
+		}
+	default:
+		return nil, fmt.Errorf("cryptor %s can't be inspected with a cipher key", header.CryptorId)
+	}
+	if e != nil {
+		return nil, e
+	}
+	return []inspectCryptor{{name: header.CryptorId, cryptor: c}}, nil
+}
+
+// Inspect decodes payload, a message or a file, parses its crypto header and
+// tries to decrypt it with each cipher key and each cryptor matching the
+// header, to find why the decryption fails.
+func Inspect(payload []byte, cipherKeys []string) *InspectReport {
+	report := &InspectReport{}
+	for _, d := range decodePayload(payload) {
+		d.Header, d.HeaderError = ParseHeaderInfo(d.data)
+		report.Data = append(report.Data, d)
+		if d.HeaderError != nil {
+			continue
+		}
+		_, encrypted, _ := parseHeader(d.data)
+
+		for _, cipherKey := range cipherKeys {
+			cryptors, e := inspectCryptors(d.Header, encrypted.Metadata, cipherKey)
+			if e != nil {
+				report.Attempts = append(report.Attempts, DecryptAttempt{Encoding: d.Encoding, Cryptor: d.Header.CryptorId, CipherKey: cipherKey, Error: e})
+				continue
+			}
+			for _, c := range cryptors {
+				attempt := DecryptAttempt{Encoding: d.Encoding, Cryptor: c.name, CipherKey: cipherKey, RandomIv: c.randomIv}
+				attempt.Plaintext, attempt.Error = decryptInspected(c.cryptor, encrypted)
+				attempt.JSON = attempt.Error == nil && json.Valid(attempt.Plaintext)
+				report.Attempts = append(report.Attempts, attempt)
+			}
+		}
+	}
+	return report
+}
+
+func decryptInspected(c ExtendedCryptor, encrypted *EncryptedData) (r []byte, e error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r, e = nil, errors.New("decryption error")
+		}
+	}()
+	if len(encrypted.Data) == 0 {
+		return nil, errors.New("decryption error: can't decrypt empty data")
+	}
+	return c.Decrypt(encrypted)
+}
+
+// TestVector is a plaintext encrypted in a mode, to compare the SDKs.
+type TestVector struct {
+	Cryptor   string
+	CipherKey string
+	// RandomIv is the IV mode of the legacy cryptor.
+	RandomIv  bool
+	Plaintext string
+	// Encrypted is the base64 encrypted data, with its header.
+	Encrypted string
+}
+
+// GenerateTestVectors encrypts plaintext with each cipher key with the legacy
+// cryptor, with a fixed and a random IV, and with the AES-CBC and AES-GCM
+// cryptors. Only the fixed IV vectors are deterministic.
+func GenerateTestVectors(plaintext []byte, cipherKeys []string) ([]TestVector, error) {
+	var res []TestVector
+	for _, cipherKey := range cipherKeys {
+		modes := []struct {
+			cryptor  string
+			randomIv bool
+			module   func() (CryptoModule, error)
+		}{
+			{"legacy", false, func() (CryptoModule, error) { return NewLegacyCryptoModule(cipherKey, false) }},
+			{"legacy", true, func() (CryptoModule, error) { return NewLegacyCryptoModule(cipherKey, true) }},
+			{crivId, false, func() (CryptoModule, error) { return NewAesCbcCryptoModule(cipherKey, true) }},
+			{aesGcmId, false, func() (CryptoModule, error) { return NewAesGcmCryptoModule(cipherKey, true) }},
+		}
+		for _, mode := range modes {
+			module, e := mode.module()
+			if e != nil {
+				return nil, e
+			}
+			encrypted, e := module.Encrypt(plaintext)
+			if e != nil {
+				return nil, e
+			}
+			res = append(res, TestVector{
+				Cryptor:   mode.cryptor,
+				CipherKey: cipherKey,
+				RandomIv:  mode.randomIv,
+				Plaintext: string(plaintext),
+				Encrypted: base64.StdEncoding.EncodeToString(encrypted),
+			})
+		}
+	}
+	return res, nil
+}
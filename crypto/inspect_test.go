@@ -0,0 +1,113 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaderInfo(t *testing.T) {
+	assert := assert.New(t)
+	module, e := NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, e)
+	encrypted, e := module.Encrypt([]byte("hello"))
+	require.NoError(t, e)
+
+	info, e := ParseHeaderInfo(encrypted)
+	assert.Nil(e)
+	assert.Equal(&HeaderInfo{CryptorId: crivId, Version: 1, MetadataLength: 16, DataLength: 16}, info)
+
+	info, e = ParseHeaderInfo([]byte("no header"))
+	assert.Nil(e)
+	assert.True(info.Legacy)
+
+	_, e = ParseHeaderInfo(encrypted[:7])
+	assert.NotNil(e)
+}
+
+func TestInspectFindsKeyAndMode(t *testing.T) {
+	assert := assert.New(t)
+	module, e := NewLegacyCryptoModule("right", true)
+	require.NoError(t, e)
+	encrypted, e := module.Encrypt([]byte(`"hello"`))
+	require.NoError(t, e)
+	payload, _ := json.Marshal(base64.StdEncoding.EncodeToString(encrypted))
+
+	report := Inspect(payload, []string{"wrong", "right"})
+	require.Len(t, report.Data, 2)
+	assert.Equal(EncodingBase64, report.Data[1].Encoding)
+	assert.True(report.Data[1].Header.Legacy)
+
+	var found []DecryptAttempt
+	for _, a := range report.Decrypted() {
+		if a.JSON {
+			found = append(found, a)
+		}
+	}
+	require.Len(t, found, 1)
+	assert.Equal(EncodingBase64, found[0].Encoding)
+	assert.Equal("right", found[0].CipherKey)
+	assert.True(found[0].RandomIv)
+	assert.Equal(`"hello"`, string(found[0].Plaintext))
+}
+
+func TestInspectDoubleBase64Header(t *testing.T) {
+	assert := assert.New(t)
+	keyring, e := NewKeyring("k1", "right")
+	require.NoError(t, e)
+	encrypted, e := NewKeyringCryptoModule(keyring, true).Encrypt([]byte(`{"a":1}`))
+	require.NoError(t, e)
+	payload := base64.StdEncoding.EncodeToString([]byte(base64.StdEncoding.EncodeToString(encrypted)))
+
+	report := Inspect([]byte(payload), []string{"wrong", "right"})
+	require.Len(t, report.Data, 3)
+	assert.Equal(keyringId, report.Data[2].Header.CryptorId)
+
+	// The legacy decryptions with the wrong key can pass the padding check.
+	var decrypted []DecryptAttempt
+	for _, a := range report.Decrypted() {
+		if a.JSON {
+			decrypted = append(decrypted, a)
+		}
+	}
+	require.Len(t, decrypted, 1)
+	assert.Equal(EncodingDoubleBase64, decrypted[0].Encoding)
+	assert.Equal(keyringId, decrypted[0].Cryptor)
+	assert.Equal("right", decrypted[0].CipherKey)
+	assert.True(decrypted[0].JSON)
+}
+
+func TestGenerateTestVectors(t *testing.T) {
+	assert := assert.New(t)
+	vectors, e := GenerateTestVectors([]byte("hello"), []string{"k1", "k2"})
+	require.NoError(t, e)
+	require.Len(t, vectors, 8)
+
+	for _, v := range vectors {
+		report := Inspect([]byte(v.Encrypted), []string{v.CipherKey})
+		var ok bool
+		for _, a := range report.Decrypted() {
+			ok = ok || (a.Cryptor == v.Cryptor && a.RandomIv == v.RandomIv && string(a.Plaintext) == "hello")
+		}
+		assert.True(ok, "%+v", v)
+	}
+
+	again, e := GenerateTestVectors([]byte("hello"), []string{"k1"})
+	require.NoError(t, e)
+	assert.Equal(vectors[0], again[0])
+	assert.NotEqual(vectors[1].Encrypted, again[1].Encrypted)
+}
+
+func TestDecodePayloadKeepsRawSpaces(t *testing.T) {
+	data := []byte("PNED\x01ACRH\x10 encrypted data ends with \n")
+	decoded := decodePayload(data)
+	require.Len(t, decoded, 1)
+	assert.Equal(t, data, decoded[0].data)
+
+	decoded = decodePayload([]byte(" " + base64.StdEncoding.EncodeToString(data) + "\n"))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, data, decoded[1].data)
+}
@@ -0,0 +1,142 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// dataKeySize is the size of the AES-256 data keys.
+const dataKeySize = 32
+
+// DefaultDataKeyTTL is the time NewEnvelopeCryptoModule caches the data keys.
+const DefaultDataKeyTTL = 5 * time.Minute
+
+// KeyProvider provides the data keys of the envelope cryptor, usually backed by
+// a KMS. The data is encrypted with a data key and only the wrapped data key,
+// encrypted with a key the provider never exposes, is stored with the data.
+type KeyProvider interface {
+	// DataKey returns a new 32 bytes data key and its wrapped form.
+	DataKey() (key []byte, wrappedKey []byte, e error)
+	// UnwrapKey returns the data key of a wrapped data key.
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+type cachedKey struct {
+	key        []byte
+	wrappedKey []byte
+	expiresAt  time.Time
+}
+
+// cachingKeyProvider reuses a data key for encryption and the unwrapped data
+// keys for decryption until their TTL expires, so the provider isn't called
+// for every message.
+type cachingKeyProvider struct {
+	sync.Mutex
+
+	provider  KeyProvider
+	ttl       time.Duration
+	dataKey   *cachedKey
+	unwrapped map[string]*cachedKey
+	now       func() time.Time
+}
+
+// NewCachingKeyProvider caches the data keys of a provider for ttl, they
+// aren't cached when ttl is 0.
+func NewCachingKeyProvider(provider KeyProvider, ttl time.Duration) KeyProvider {
+	return &cachingKeyProvider{
+		provider:  provider,
+		ttl:       ttl,
+		unwrapped: make(map[string]*cachedKey),
+		now:       time.Now,
+	}
+}
+
+func (p *cachingKeyProvider) DataKey() ([]byte, []byte, error) {
+	p.Lock()
+	defer p.Unlock()
+	now := p.now()
+	if p.dataKey != nil && now.Before(p.dataKey.expiresAt) {
+		return p.dataKey.key, p.dataKey.wrappedKey, nil
+	}
+
+	key, wrappedKey, e := p.provider.DataKey()
+	if e != nil {
+		return nil, nil, e
+	}
+	p.dataKey = &cachedKey{key: key, wrappedKey: wrappedKey, expiresAt: now.Add(p.ttl)}
+	return key, wrappedKey, nil
+}
+
+func (p *cachingKeyProvider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	p.Lock()
+	defer p.Unlock()
+	now := p.now()
+	if cached, ok := p.unwrapped[string(wrappedKey)]; ok && now.Before(cached.expiresAt) {
+		return cached.key, nil
+	}
+
+	key, e := p.provider.UnwrapKey(wrappedKey)
+	if e != nil {
+		return nil, e
+	}
+	for k, cached := range p.unwrapped {
+		if !now.Before(cached.expiresAt) {
+			delete(p.unwrapped, k)
+		}
+	}
+	p.unwrapped[string(wrappedKey)] = &cachedKey{key: key, expiresAt: now.Add(p.ttl)}
+	return key, nil
+}
+
+// fileKeyProvider wraps the data keys with AES-256-GCM under a master key read
+// from a local file. It is meant for tests and development, not production.
+type fileKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewFileKeyProvider creates a provider with the master key of a file. A
+// random master key is written to the file, readable only by the current
+// user, when it doesn't exist.
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	masterKey, e := os.ReadFile(path)
+	if errors.Is(e, os.ErrNotExist) {
+		masterKey = generateIV(dataKeySize)
+		e = os.WriteFile(path, masterKey, 0600)
+	}
+	if e != nil {
+		return nil, e
+	}
+	if len(masterKey) == 0 {
+		return nil, errors.New("empty master key file")
+	}
+
+	hash := sha256.Sum256(masterKey)
+	block, e := aes.NewCipher(hash[:])
+	if e != nil {
+		return nil, e
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, e
+	}
+	return &fileKeyProvider{aead: aead}, nil
+}
+
+func (p *fileKeyProvider) DataKey() ([]byte, []byte, error) {
+	key := generateIV(dataKeySize)
+	nonce := generateIV(p.aead.NonceSize())
+	return key, p.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *fileKeyProvider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	nonceSize := p.aead.NonceSize()
+	if len(wrappedKey) < nonceSize {
+		return nil, errors.New("invalid wrapped key")
+	}
+	return p.aead.Open(nil, wrappedKey[:nonceSize], wrappedKey[nonceSize:], nil)
+}
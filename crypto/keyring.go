@@ -0,0 +1,106 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// maxKeyIdLength is the max length of a key ID, stored with a 1 byte length in the metadata.
+const maxKeyIdLength = 255
+
+// Keyring stores the cipher keys of a keyring cryptor by key ID. Data is
+// encrypted with the primary key and decrypted with the key matching the key
+// ID of the data, so the primary key can be rotated without losing access to
+// the data encrypted with the previous keys.
+type Keyring struct {
+	sync.RWMutex
+
+	keys    map[string]string
+	primary string
+}
+
+// NewKeyring creates a keyring with a primary key.
+func NewKeyring(primaryId, primaryKey string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]string)}
+	if e := k.AddKey(primaryId, primaryKey); e != nil {
+		return nil, e
+	}
+	k.primary = primaryId
+	return k, nil
+}
+
+// AddKey adds a key. A key ID can't be reused for a different cipher key.
+func (k *Keyring) AddKey(id, cipherKey string) error {
+	if id == "" || len(id) > maxKeyIdLength {
+		return fmt.Errorf("key id length must be between 1 and %d", maxKeyIdLength)
+	}
+	if cipherKey == "" {
+		return errors.New("cipher key can't be empty")
+	}
+
+	k.Lock()
+	defer k.Unlock()
+	if existing, ok := k.keys[id]; ok && existing != cipherKey {
+		return fmt.Errorf("key id %s already used for a different key", id)
+	}
+	k.keys[id] = cipherKey
+	return nil
+}
+
+// SetPrimary sets the key used for encryption.
+func (k *Keyring) SetPrimary(id string) error {
+	k.Lock()
+	defer k.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("unknown key id %s", id)
+	}
+	k.primary = id
+	return nil
+}
+
+// Primary returns the ID of the key used for encryption.
+func (k *Keyring) Primary() string {
+	k.RLock()
+	defer k.RUnlock()
+	return k.primary
+}
+
+// RemoveKey removes a key. The data encrypted with it can't be decrypted anymore.
+func (k *Keyring) RemoveKey(id string) error {
+	k.Lock()
+	defer k.Unlock()
+	if id == k.primary {
+		return errors.New("can't remove the primary key")
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Ids returns the key IDs, the primary key first and then the others sorted.
+func (k *Keyring) Ids() []string {
+	k.RLock()
+	defer k.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.primary {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return append([]string{k.primary}, ids...)
+}
+
+func (k *Keyring) key(id string) (string, bool) {
+	k.RLock()
+	defer k.RUnlock()
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+func (k *Keyring) primaryKey() (string, string) {
+	k.RLock()
+	defer k.RUnlock()
+	return k.primary, k.keys[k.primary]
+}
@@ -0,0 +1,181 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+var keyringId = "AGKR"
+
+// keyringCryptor encrypts with AES-256-GCM under the primary key of a keyring.
+// The metadata is the key ID, prefixed with its length, followed by the
+// metadata of the AES-GCM cryptor.
+type keyringCryptor struct {
+	sync.Mutex
+
+	keyring  *Keyring
+	cryptors map[keyringKey]ExtendedCryptor
+}
+
+// keyringKey is a key of a keyring. A key ID removed from the keyring can be
+// added again with another key, so the cryptors are cached by ID and key.
+type keyringKey struct {
+	id        string
+	cipherKey string
+}
+
+func NewKeyringCryptor(keyring *Keyring) ExtendedCryptor {
+	return &keyringCryptor{
+		keyring:  keyring,
+		cryptors: make(map[keyringKey]ExtendedCryptor),
+	}
+}
+
+func (c *keyringCryptor) Id() string {
+	return keyringId
+}
+
+// cryptor returns the AES-GCM cryptor of a key.
+func (c *keyringCryptor) cryptor(id, cipherKey string) (ExtendedCryptor, error) {
+	c.Lock()
+	defer c.Unlock()
+	key := keyringKey{id: id, cipherKey: cipherKey}
+	if cryptor, ok := c.cryptors[key]; ok {
+		return cryptor, nil
+	}
+	cryptor, e := NewAesGcmCryptor(cipherKey)
+	if e != nil {
+		return nil, e
+	}
+	c.cryptors[key] = cryptor
+	return cryptor, nil
+}
+
+func (c *keyringCryptor) primaryCryptor() (string, ExtendedCryptor, error) {
+	id, cipherKey := c.keyring.primaryKey()
+	cryptor, e := c.cryptor(id, cipherKey)
+	return id, cryptor, e
+}
+
+// decryptor returns the cryptor of the key ID in metadata and the metadata of the cryptor.
+func (c *keyringCryptor) decryptor(metadata []byte) (ExtendedCryptor, []byte, error) {
+	if len(metadata) < 1 || len(metadata) < 1+int(metadata[0]) {
+		return nil, nil, errors.New("decryption error: invalid key id")
+	}
+	id := string(metadata[1 : 1+metadata[0]])
+	cipherKey, ok := c.keyring.key(id)
+	if !ok {
+		return nil, nil, fmt.Errorf("decryption error: unknown key id %s", id)
+	}
+	cryptor, e := c.cryptor(id, cipherKey)
+	if e != nil {
+		return nil, nil, e
+	}
+	return cryptor, metadata[1+metadata[0]:], nil
+}
+
+func keyIdMetadata(id string, metadata []byte) []byte {
+	res := make([]byte, 0, 1+len(id)+len(metadata))
+	res = append(res, byte(len(id)))
+	res = append(res, id...)
+	return append(res, metadata...)
+}
+
+func (c *keyringCryptor) Encrypt(message []byte) (*EncryptedData, error) {
+	id, cryptor, e := c.primaryCryptor()
+	if e != nil {
+		return nil, e
+	}
+	encrypted, e := cryptor.Encrypt(message)
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedData{
+		Metadata: keyIdMetadata(id, encrypted.Metadata),
+		Data:     encrypted.Data,
+	}, nil
+}
+
+func (c *keyringCryptor) Decrypt(encryptedData *EncryptedData) ([]byte, error) {
+	cryptor, metadata, e := c.decryptor(encryptedData.Metadata)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.Decrypt(&EncryptedData{Metadata: metadata, Data: encryptedData.Data})
+}
+
+func (c *keyringCryptor) EncryptStream(reader io.Reader) (*EncryptedStreamData, error) {
+	id, cryptor, e := c.primaryCryptor()
+	if e != nil {
+		return nil, e
+	}
+	encrypted, e := cryptor.EncryptStream(reader)
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedStreamData{
+		Metadata: keyIdMetadata(id, encrypted.Metadata),
+		Reader:   encrypted.Reader,
+	}, nil
+}
+
+func (c *keyringCryptor) DecryptStream(encryptedData *EncryptedStreamData) (io.Reader, error) {
+	cryptor, metadata, e := c.decryptor(encryptedData.Metadata)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.DecryptStream(&EncryptedStreamData{Metadata: metadata, Reader: encryptedData.Reader})
+}
+
+// keyringFallbackCryptor decrypts the data of a cryptor without key ID by
+// trying every key of the keyring, the primary key first.
+type keyringFallbackCryptor struct {
+	id         string
+	keyring    *Keyring
+	newCryptor func(cipherKey string) (ExtendedCryptor, error)
+	// authenticated is set when a wrong key fails the decryption.
+	authenticated bool
+}
+
+func (c *keyringFallbackCryptor) Id() string {
+	return c.id
+}
+
+func (c *keyringFallbackCryptor) Encrypt(message []byte) (*EncryptedData, error) {
+	_, cipherKey := c.keyring.primaryKey()
+	cryptor, e := c.newCryptor(cipherKey)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.Encrypt(message)
+}
+
+// Decrypt returns the data decrypted by the first key which succeeds. A wrong
+// key of an unauthenticated cipher yields a valid padding from time to time,
+// so only a text, valid UTF-8, is decrypted by trial then: the binary data
+// needs the key ID of the keyring cryptor.
+func (c *keyringFallbackCryptor) Decrypt(encryptedData *EncryptedData) ([]byte, error) {
+	for _, id := range c.keyring.Ids() {
+		cipherKey, ok := c.keyring.key(id)
+		if !ok {
+			continue
+		}
+		cryptor, e := c.newCryptor(cipherKey)
+		if e != nil {
+			return nil, e
+		}
+		decrypted, e := cryptor.Decrypt(encryptedData)
+		if e != nil {
+			continue
+		}
+		if c.authenticated || utf8.Valid(decrypted) {
+			return decrypted, nil
+		}
+	}
+	return nil, errors.New("decryption error")
+}
@@ -0,0 +1,126 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	assert := assert.New(t)
+
+	_, e := NewKeyring("", "key")
+	assert.NotNil(e)
+
+	keyring, e := NewKeyring("k1", "key1")
+	require.NoError(t, e)
+	assert.Nil(keyring.AddKey("k2", "key2"))
+	assert.Nil(keyring.AddKey("k2", "key2"))
+	assert.NotNil(keyring.AddKey("k2", "other"))
+	assert.NotNil(keyring.SetPrimary("k3"))
+
+	assert.Nil(keyring.SetPrimary("k2"))
+	assert.Equal("k2", keyring.Primary())
+	assert.Equal([]string{"k2", "k1"}, keyring.Ids())
+	assert.NotNil(keyring.RemoveKey("k2"))
+	assert.Nil(keyring.RemoveKey("k1"))
+	assert.Equal([]string{"k2"}, keyring.Ids())
+}
+
+func TestKeyringCryptoModuleRotation(t *testing.T) {
+	assert := assert.New(t)
+	keyring, e := NewKeyring("k1", "key1")
+	require.NoError(t, e)
+	module := NewKeyringCryptoModule(keyring, true)
+
+	before, e := module.Encrypt([]byte("before rotation"))
+	require.NoError(t, e)
+	id, e := peekHeaderCryptorId(before)
+	assert.Nil(e)
+	assert.Equal(keyringId, *id)
+
+	require.NoError(t, keyring.AddKey("k2", "key2"))
+	require.NoError(t, keyring.SetPrimary("k2"))
+	after, e := module.Encrypt([]byte("after rotation"))
+	require.NoError(t, e)
+
+	_, encrypted, e := parseHeader(after)
+	assert.Nil(e)
+	assert.Equal("k2", string(encrypted.Metadata[1:1+encrypted.Metadata[0]]))
+
+	for expected, data := range map[string][]byte{"before rotation": before, "after rotation": after} {
+		decrypted, e := module.Decrypt(data)
+		assert.Nil(e)
+		assert.Equal(expected, string(decrypted))
+	}
+
+	// The data encrypted with a removed key can't be decrypted anymore.
+	require.NoError(t, keyring.RemoveKey("k1"))
+	_, e = module.Decrypt(before)
+	assert.NotNil(e)
+
+	// A key ID added again with another key doesn't use the removed key.
+	require.NoError(t, keyring.AddKey("k1", "key3"))
+	_, e = module.Decrypt(before)
+	assert.NotNil(e)
+}
+
+func TestKeyringCryptoModuleDecryptsDataWithoutKeyId(t *testing.T) {
+	assert := assert.New(t)
+	keyring, e := NewKeyring("new", "key2")
+	require.NoError(t, e)
+	require.NoError(t, keyring.AddKey("old", "key1"))
+	module := NewKeyringCryptoModule(keyring, true)
+
+	gcmModule, _ := NewAesGcmCryptoModule("key1", true)
+	cbcModule, _ := NewAesCbcCryptoModule("key1", true)
+	legacyModule, _ := NewLegacyCryptoModule("key1", true)
+
+	for _, m := range []CryptoModule{gcmModule, cbcModule, legacyModule} {
+		encrypted, e := m.Encrypt([]byte("hello"))
+		require.NoError(t, e)
+		decrypted, e := module.Decrypt(encrypted)
+		assert.Nil(e)
+		assert.Equal("hello", string(decrypted))
+	}
+
+	// A wrong key can't be told from binary data without a key ID, except with AES-GCM.
+	binary := []byte{0xff, 0xfe, 0x00, 0x80}
+	for _, m := range []CryptoModule{cbcModule, legacyModule} {
+		encrypted, e := m.Encrypt(binary)
+		require.NoError(t, e)
+		_, e = module.Decrypt(encrypted)
+		assert.NotNil(e)
+	}
+	encrypted, e := gcmModule.Encrypt(binary)
+	require.NoError(t, e)
+	decrypted, e := module.Decrypt(encrypted)
+	assert.Nil(e)
+	assert.Equal(binary, decrypted)
+}
+
+func TestKeyringCryptoModuleStream(t *testing.T) {
+	assert := assert.New(t)
+	keyring, e := NewKeyring("k1", "key1")
+	require.NoError(t, e)
+	module := NewKeyringCryptoModule(keyring, true)
+
+	in := bytes.Repeat([]byte("file content "), 10000)
+	r, e := module.EncryptStream(bytes.NewReader(in))
+	require.NoError(t, e)
+	encrypted, e := io.ReadAll(r)
+	require.NoError(t, e)
+
+	decrypted, e := module.Decrypt(encrypted)
+	assert.Nil(e)
+	assert.Equal(in, decrypted)
+
+	r, e = module.DecryptStream(bytes.NewReader(encrypted))
+	require.NoError(t, e)
+	decrypted, e = io.ReadAll(r)
+	assert.Nil(e)
+	assert.Equal(in, decrypted)
+}
@@ -3,6 +3,7 @@ package crypto
 import (
 	"bufio"
 	"bytes"
+	"crypto/ecdh"
 	"errors"
 	"fmt"
 	"io"
@@ -49,6 +50,67 @@ func NewAesCbcCryptoModule(cipherKey string, randomIv bool) (CryptoModule, error
 	return NewCryptoModule(aesCbc, []Cryptor{legacy}), nil
 }
 
+// NewAesGcmCryptoModule encrypts with AES-256-GCM and decrypts the data
+// encrypted with AES-GCM, AES-CBC and the legacy cryptor.
+func NewAesGcmCryptoModule(cipherKey string, randomIv bool) (CryptoModule, error) {
+	aesGcm, e := NewAesGcmCryptor(cipherKey)
+	if e != nil {
+		return nil, e
+	}
+
+	aesCbc, e := NewAesCbcCryptor(cipherKey)
+	if e != nil {
+		return nil, e
+	}
+
+	legacy, e := NewLegacyCryptor(cipherKey, randomIv)
+	if e != nil {
+		return nil, e
+	}
+
+	return NewCryptoModule(aesGcm, []Cryptor{aesCbc, legacy}), nil
+}
+
+// NewKeyringCryptoModule encrypts with AES-256-GCM under the primary key of the
+// keyring and writes the key ID in the header. The data encrypted with
+// AES-GCM, AES-CBC and the legacy cryptor, which have no key ID, is decrypted
+// by trying every key of the keyring, the primary key first. AES-CBC and the
+// legacy cryptor don't detect a wrong key, so their data is only decrypted by
+// trial when it is a text.
+func NewKeyringCryptoModule(keyring *Keyring, randomIv bool) CryptoModule {
+	return NewCryptoModule(NewKeyringCryptor(keyring), []Cryptor{
+		&keyringFallbackCryptor{id: aesGcmId, keyring: keyring, newCryptor: NewAesGcmCryptor, authenticated: true},
+		&keyringFallbackCryptor{id: crivId, keyring: keyring, newCryptor: NewAesCbcCryptor},
+		&keyringFallbackCryptor{id: legacyId, keyring: keyring, newCryptor: func(cipherKey string) (ExtendedCryptor, error) {
+			return NewLegacyCryptor(cipherKey, randomIv)
+		}},
+	})
+}
+
+// NewEnvelopeCryptoModule encrypts with AES-256-GCM under data keys of the
+// provider and writes the wrapped data key in the header, so no cipher key is
+// stored in the config. The data keys are cached for DefaultDataKeyTTL, pass
+// a provider made with NewCachingKeyProvider for another TTL, 0 to call the
+// provider for every message.
+func NewEnvelopeCryptoModule(provider KeyProvider) CryptoModule {
+	if _, ok := provider.(*cachingKeyProvider); !ok {
+		provider = NewCachingKeyProvider(provider, DefaultDataKeyTTL)
+	}
+	return NewCryptoModule(NewEnvelopeCryptor(provider), nil)
+}
+
+// NewSealedBoxCryptoModule encrypts for the recipients public keys, and the
+// public key of privateKey, and decrypts the data sent to privateKey. Add
+// NewSealedBoxCryptor to the decryptors of another module to decrypt sealed
+// boxes with it.
+func NewSealedBoxCryptoModule(privateKey *ecdh.PrivateKey, recipients ...*ecdh.PublicKey) (CryptoModule, error) {
+	sealedBox, e := NewSealedBoxCryptor(privateKey, recipients...)
+	if e != nil {
+		return nil, e
+	}
+	return NewCryptoModule(sealedBox, nil), nil
+}
+
 func NewCryptoModule(defaultCryptor Cryptor, decryptors []Cryptor) CryptoModule {
 
 	decryptorsMap := make(map[string]ExtendedCryptor, len(decryptors)+1)
@@ -0,0 +1,214 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var sealedBoxId = "SBOX"
+
+const sealedBoxKeySize = 32
+const sealedBoxKeyIdSize = 8
+const sealedBoxWrappedKeySize = dataKeySize + 16
+const sealedBoxRecipientSize = sealedBoxKeyIdSize + sealedBoxWrappedKeySize
+const maxSealedBoxRecipients = 255
+const sealedBoxInfo = "pubnub sealed box"
+
+// sealedBoxCryptor encrypts with AES-256-GCM under a random content key, which
+// is wrapped for each recipient with a key derived from an X25519 key agreement
+// between an ephemeral key and the recipient public key. The metadata is the
+// ephemeral public key, the number of recipients, the ID and wrapped content
+// key of each recipient and the metadata of the AES-GCM cryptor.
+type sealedBoxCryptor struct {
+	privateKey *ecdh.PrivateKey
+	recipients []*ecdh.PublicKey
+}
+
+// GenerateSealedBoxKey generates the X25519 private key of a sealed box cryptor.
+func GenerateSealedBoxKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// NewSealedBoxCryptor creates a cryptor which encrypts for the recipients and
+// decrypts the data sent to privateKey. The public key of privateKey is always
+// a recipient, so the sender can decrypt the data it sent.
+func NewSealedBoxCryptor(privateKey *ecdh.PrivateKey, recipients ...*ecdh.PublicKey) (ExtendedCryptor, error) {
+	if privateKey == nil || privateKey.Curve() != ecdh.X25519() {
+		return nil, errors.New("sealed box private key must be a X25519 key")
+	}
+
+	keys := []*ecdh.PublicKey{privateKey.PublicKey()}
+	for _, recipient := range recipients {
+		if recipient == nil || recipient.Curve() != ecdh.X25519() {
+			return nil, errors.New("sealed box recipient key must be a X25519 key")
+		}
+		duplicate := false
+		for _, k := range keys {
+			duplicate = duplicate || k.Equal(recipient)
+		}
+		if !duplicate {
+			keys = append(keys, recipient)
+		}
+	}
+	if len(keys) > maxSealedBoxRecipients {
+		return nil, fmt.Errorf("sealed box can't have more than %d recipients", maxSealedBoxRecipients)
+	}
+
+	return &sealedBoxCryptor{privateKey: privateKey, recipients: keys}, nil
+}
+
+// IsSealedBox returns whether data was encrypted by a sealed box cryptor.
+func IsSealedBox(data []byte) bool {
+	id, e := peekHeaderCryptorId(data)
+	return e == nil && id != nil && *id == sealedBoxId
+}
+
+func (c *sealedBoxCryptor) Id() string {
+	return sealedBoxId
+}
+
+// sealedBoxKeyId identifies the recipient of a wrapped key without revealing its public key.
+func sealedBoxKeyId(key *ecdh.PublicKey) []byte {
+	hash := sha256.Sum256(key.Bytes())
+	return hash[:sealedBoxKeyIdSize]
+}
+
+// keyWrapAead returns the AEAD wrapping the content key for a recipient. The
+// key is derived from a fresh ephemeral key, so a fixed nonce is never reused
+// with the same key.
+func keyWrapAead(shared, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	salt := append(append(make([]byte, 0, 2*sealedBoxKeySize), ephemeral...), recipient...)
+	kek, e := hkdf.Key(sha256.New, shared, salt, sealedBoxInfo, dataKeySize)
+	if e != nil {
+		return nil, e
+	}
+	block, e := aes.NewCipher(kek)
+	if e != nil {
+		return nil, e
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the cryptor of a new content key and the metadata wrapping it for the recipients.
+func (c *sealedBoxCryptor) seal() ([]byte, ExtendedCryptor, error) {
+	ephemeral, e := GenerateSealedBoxKey()
+	if e != nil {
+		return nil, nil, e
+	}
+	contentKey := generateIV(dataKeySize)
+
+	metadata := make([]byte, 0, sealedBoxKeySize+1+len(c.recipients)*sealedBoxRecipientSize+aesGcmNonceSize)
+	metadata = append(metadata, ephemeral.PublicKey().Bytes()...)
+	metadata = append(metadata, byte(len(c.recipients)))
+	for _, recipient := range c.recipients {
+		shared, e := ephemeral.ECDH(recipient)
+		if e != nil {
+			return nil, nil, e
+		}
+		aead, e := keyWrapAead(shared, ephemeral.PublicKey().Bytes(), recipient.Bytes())
+		if e != nil {
+			return nil, nil, e
+		}
+		metadata = append(metadata, sealedBoxKeyId(recipient)...)
+		metadata = aead.Seal(metadata, make([]byte, aead.NonceSize()), contentKey, nil)
+	}
+
+	cryptor, e := dataKeyCryptor(contentKey)
+	return metadata, cryptor, e
+}
+
+// open returns the cryptor of the content key wrapped for the private key and the metadata of the cryptor.
+func (c *sealedBoxCryptor) open(metadata []byte) (ExtendedCryptor, []byte, error) {
+	if len(metadata) < sealedBoxKeySize+1 {
+		return nil, nil, errors.New("decryption error: invalid sealed box")
+	}
+	n := int(metadata[sealedBoxKeySize])
+	recipientsEnd := sealedBoxKeySize + 1 + n*sealedBoxRecipientSize
+	if len(metadata) < recipientsEnd {
+		return nil, nil, errors.New("decryption error: invalid sealed box")
+	}
+
+	ephemeral, e := ecdh.X25519().NewPublicKey(metadata[:sealedBoxKeySize])
+	if e != nil {
+		return nil, nil, e
+	}
+	publicKey := c.privateKey.PublicKey()
+	keyId := sealedBoxKeyId(publicKey)
+	for i := 0; i < n; i++ {
+		entry := metadata[sealedBoxKeySize+1+i*sealedBoxRecipientSize:]
+		if !bytes.Equal(entry[:sealedBoxKeyIdSize], keyId) {
+			continue
+		}
+		shared, e := c.privateKey.ECDH(ephemeral)
+		if e != nil {
+			return nil, nil, e
+		}
+		aead, e := keyWrapAead(shared, ephemeral.Bytes(), publicKey.Bytes())
+		if e != nil {
+			return nil, nil, e
+		}
+		contentKey, e := aead.Open(nil, make([]byte, aead.NonceSize()), entry[sealedBoxKeyIdSize:sealedBoxRecipientSize], nil)
+		if e != nil {
+			return nil, nil, e
+		}
+		cryptor, e := dataKeyCryptor(contentKey)
+		return cryptor, metadata[recipientsEnd:], e
+	}
+	return nil, nil, errors.New("decryption error: not a recipient of the sealed box")
+}
+
+func (c *sealedBoxCryptor) Encrypt(message []byte) (*EncryptedData, error) {
+	metadata, cryptor, e := c.seal()
+	if e != nil {
+		return nil, e
+	}
+	encrypted, e := cryptor.Encrypt(message)
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedData{
+		Metadata: append(metadata, encrypted.Metadata...),
+		Data:     encrypted.Data,
+	}, nil
+}
+
+func (c *sealedBoxCryptor) Decrypt(encryptedData *EncryptedData) ([]byte, error) {
+	cryptor, metadata, e := c.open(encryptedData.Metadata)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.Decrypt(&EncryptedData{Metadata: metadata, Data: encryptedData.Data})
+}
+
+func (c *sealedBoxCryptor) EncryptStream(reader io.Reader) (*EncryptedStreamData, error) {
+	metadata, cryptor, e := c.seal()
+	if e != nil {
+		return nil, e
+	}
+	encrypted, e := cryptor.EncryptStream(reader)
+	if e != nil {
+		return nil, e
+	}
+
+	return &EncryptedStreamData{
+		Metadata: append(metadata, encrypted.Metadata...),
+		Reader:   encrypted.Reader,
+	}, nil
+}
+
+func (c *sealedBoxCryptor) DecryptStream(encryptedData *EncryptedStreamData) (io.Reader, error) {
+	cryptor, metadata, e := c.open(encryptedData.Metadata)
+	if e != nil {
+		return nil, e
+	}
+	return cryptor.DecryptStream(&EncryptedStreamData{Metadata: metadata, Reader: encryptedData.Reader})
+}
@@ -0,0 +1,108 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateSealedBoxKeys(t *testing.T, n int) []*ecdh.PrivateKey {
+	keys := make([]*ecdh.PrivateKey, n)
+	for i := range keys {
+		k, e := GenerateSealedBoxKey()
+		require.NoError(t, e)
+		keys[i] = k
+	}
+	return keys
+}
+
+func TestSealedBoxCryptoModule(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateSealedBoxKeys(t, 4)
+	sender, alice, bob, eve := keys[0], keys[1], keys[2], keys[3]
+
+	module, e := NewSealedBoxCryptoModule(sender, alice.PublicKey(), bob.PublicKey(), alice.PublicKey())
+	require.NoError(t, e)
+	encrypted, e := module.Encrypt([]byte("hello"))
+	require.NoError(t, e)
+	assert.True(IsSealedBox(encrypted))
+
+	_, header, e := parseHeader(encrypted)
+	require.NoError(t, e)
+	assert.Equal(byte(3), header.Metadata[sealedBoxKeySize])
+
+	for _, k := range []*ecdh.PrivateKey{sender, alice, bob} {
+		m, e := NewSealedBoxCryptoModule(k)
+		require.NoError(t, e)
+		decrypted, e := m.Decrypt(encrypted)
+		assert.Nil(e)
+		assert.Equal("hello", string(decrypted))
+	}
+
+	m, e := NewSealedBoxCryptoModule(eve)
+	require.NoError(t, e)
+	_, e = m.Decrypt(encrypted)
+	assert.NotNil(e)
+}
+
+func TestSealedBoxCryptorInOtherModule(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateSealedBoxKeys(t, 2)
+	sender, alice := keys[0], keys[1]
+
+	sealedBox, e := NewSealedBoxCryptoModule(sender, alice.PublicKey())
+	require.NoError(t, e)
+	encrypted, e := sealedBox.Encrypt([]byte("direct"))
+	require.NoError(t, e)
+
+	aliceCryptor, e := NewSealedBoxCryptor(alice)
+	require.NoError(t, e)
+	aesCbc, e := NewAesCbcCryptor("enigma")
+	require.NoError(t, e)
+	module := NewCryptoModule(aesCbc, []Cryptor{aliceCryptor})
+
+	decrypted, e := module.Decrypt(encrypted)
+	assert.Nil(e)
+	assert.Equal("direct", string(decrypted))
+
+	shared, e := module.Encrypt([]byte("shared"))
+	require.NoError(t, e)
+	assert.False(IsSealedBox(shared))
+}
+
+func TestSealedBoxCryptoModuleStream(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateSealedBoxKeys(t, 2)
+	module, e := NewSealedBoxCryptoModule(keys[0], keys[1].PublicKey())
+	require.NoError(t, e)
+
+	in := bytes.Repeat([]byte("file content "), 10000)
+	r, e := module.EncryptStream(bytes.NewReader(in))
+	require.NoError(t, e)
+	encrypted, e := io.ReadAll(r)
+	require.NoError(t, e)
+
+	recipient, e := NewSealedBoxCryptoModule(keys[1])
+	require.NoError(t, e)
+	r, e = recipient.DecryptStream(bytes.NewReader(encrypted))
+	require.NoError(t, e)
+	decrypted, e := io.ReadAll(r)
+	assert.Nil(e)
+	assert.Equal(in, decrypted)
+}
+
+func TestSealedBoxCryptorInvalidKeys(t *testing.T) {
+	_, e := NewSealedBoxCryptor(nil)
+	assert.NotNil(t, e)
+
+	keys := generateSealedBoxKeys(t, 1)
+	p256, e := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, e)
+	_, e = NewSealedBoxCryptor(keys[0], p256.PublicKey())
+	assert.NotNil(t, e)
+}
@@ -0,0 +1,95 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResolverTestModules(t *testing.T) (global, secret crypto.CryptoModule) {
+	global, err := crypto.NewAesCbcCryptoModule("global-key", true)
+	require.NoError(t, err)
+	secret, err = crypto.NewAesGcmCryptoModule("secret-key", true)
+	require.NoError(t, err)
+	return global, secret
+}
+
+func TestCryptoModuleResolverPublish(t *testing.T) {
+	assert := assert.New(t)
+	global, secret := newResolverTestModules(t)
+
+	bodies := make(map[string]string)
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		parts := strings.Split(req.URL.Path, "/")
+		bodies[parts[len(parts)-2]] = string(body)
+		fmt.Fprint(w, `[1,"Sent","15000000000000000"]`)
+	})
+	pn.Config.CryptoModule = global
+	pn.Config.CryptoModuleResolver = func(channel string) crypto.CryptoModule {
+		if strings.HasPrefix(channel, "secret.") {
+			return secret
+		}
+		return nil
+	}
+
+	for _, ch := range []string{"secret.a", "public"} {
+		_, _, err := pn.Publish().Channel(ch).Message("hello").UsePost(true).Execute()
+		require.NoError(t, err)
+	}
+
+	decrypt := func(module crypto.CryptoModule, body string) (string, error) {
+		var encrypted string
+		require.NoError(t, json.Unmarshal([]byte(body), &encrypted))
+		decrypted, err := decryptString(module, encrypted, pn.loggerManager)
+		if err != nil {
+			return "", err
+		}
+		return decrypted.(string), nil
+	}
+
+	decrypted, err := decrypt(secret, bodies["secret.a"])
+	assert.Nil(err)
+	assert.Equal(`"hello"`, decrypted)
+	_, err = decrypt(global, bodies["secret.a"])
+	assert.NotNil(err)
+
+	decrypted, err = decrypt(global, bodies["public"])
+	assert.Nil(err)
+	assert.Equal(`"hello"`, decrypted)
+}
+
+func TestCryptoModuleResolverParseCipherInterface(t *testing.T) {
+	assert := assert.New(t)
+	global, secret := newResolverTestModules(t)
+
+	pn := NewPubNub(NewDemoConfig())
+	pn.Config.CryptoModule = global
+	pn.Config.CryptoModuleResolver = func(channel string) crypto.CryptoModule {
+		if channel == "secret" {
+			return secret
+		}
+		return nil
+	}
+
+	for ch, module := range map[string]crypto.CryptoModule{"secret": secret, "public": global, "": global} {
+		encrypted, err := encryptString(module, `"hello"`, pn.loggerManager)
+		require.NoError(t, err)
+
+		result, err := parseCipherInterface(encrypted, pn, ch)
+		assert.Nil(err, ch)
+		assert.Equal("hello", result, ch)
+	}
+
+	encrypted, err := encryptString(global, `"hello"`, pn.loggerManager)
+	require.NoError(t, err)
+	_, err = parseCipherInterface(encrypted, pn, "secret")
+	assert.NotNil(err)
+}
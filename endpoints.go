@@ -18,6 +18,8 @@ import (
 type endpointOpts struct {
 	pubnub *PubNub
 	ctx    Context
+	// requestOrigin is the origin of the current attempt, the active origin when empty.
+	requestOrigin string
 }
 
 type endpoint interface {
@@ -36,6 +38,8 @@ type endpoint interface {
 	telemetryManager() *TelemetryManager
 	tokenManager() *TokenManager
 	getPubNub() *PubNub
+	origin() string
+	setOrigin(origin string)
 }
 
 func (o *endpointOpts) config() *Config {
@@ -58,6 +62,17 @@ func (o *endpointOpts) getPubNub() *PubNub {
 	return o.pubnub
 }
 
+func (o *endpointOpts) origin() string {
+	if o.requestOrigin != "" {
+		return o.requestOrigin
+	}
+	return o.pubnub.originSelector.current(o.pubnub)
+}
+
+func (o *endpointOpts) setOrigin(origin string) {
+	o.requestOrigin = origin
+}
+
 func (o *endpointOpts) buildBody() ([]byte, error) {
 	return []byte{}, nil
 }
@@ -150,46 +165,18 @@ func buildURL(o endpoint) (*url.URL, error) {
 	var stringifiedQuery string
 	var signature string
 
-	path, err := o.buildPath()
+	path, query, err := buildPathAndQuery(o)
 	if err != nil {
 		return &url.URL{}, err
 	}
 
-	query, err := o.buildQuery()
-	if err != nil {
-		return &url.URL{}, err
-	}
-
-	// Validate the client UUID for every endpoint that sends it (those built
-	// via defaultQuery). Returning an error here lets callers handle an invalid
-	// UUID gracefully instead of panicking inside SDK-owned goroutines.
-	if query.Has("uuid") {
-		if err := utils.ValidateUUID(query.Get("uuid")); err != nil {
-			return &url.URL{}, err
-		}
-	}
-
-	if v := o.tokenManager().GetToken(); v != "" && query.Get("auth") == "" {
-		query.Set("auth", v)
-	} else if v := o.config().AuthKey; v != "" && query.Get("auth") == "" {
-		query.Set("auth", v)
-	}
-
-	if o.config().SecretKey != "" {
+	if signer := o.config().requestSigner(); signer != nil {
 		timestamp := time.Now().Unix()
 		query.Set("timestamp", strconv.Itoa(int(timestamp)))
 
-		if (!o.config().UsePAMV3) || ((o.operationType() == PNPublishOperation) && (o.httpMethod() == "POST")) {
-			signedInput := o.config().SubscribeKey + "\n" + o.config().PublishKey + "\n"
-
-			signedInput += fmt.Sprintf("%s\n", path)
-
-			signedInput += utils.PreparePamParams(query)
-			o.getPubNub().loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("PAM: signedInput=%s", signedInput), false)
-
-			signature = utils.GetHmacSha256(o.config().SecretKey, signedInput)
-		} else {
-			signature = createSignatureV2(o, path, query)
+		signature, err = signRequest(o, signer, path, query)
+		if err != nil {
+			return &url.URL{}, err
 		}
 	}
 
@@ -248,10 +235,10 @@ func buildURL(o endpoint) (*url.URL, error) {
 
 	scheme := fmt.Sprintf("http%s", secure)
 
-	host := o.config().Origin
+	host := o.origin()
 
 	if o.httpMethod() != "POSTFORM" {
-		path = fmt.Sprintf("//%s%s", o.config().Origin, path)
+		path = fmt.Sprintf("//%s%s", host, path)
 	} else {
 		p := strings.Split(path, "://")
 		scheme = p[0]
@@ -272,26 +259,35 @@ func buildURL(o endpoint) (*url.URL, error) {
 	return retURL, nil
 }
 
-func createSignatureV2(o endpoint, path string, query *url.Values) string {
-	bodyString := ""
-	b, err := o.buildBody()
-	if err == nil {
-		bodyString = string(b)
-	} else {
-		o.getPubNub().loggerManager.LogSimple(PNLogLevelWarn, fmt.Sprintf("PAM: buildBody error: %v", err.Error()), false)
+// buildPathAndQuery returns the path and the query of the request of o,
+// before they are signed and encoded.
+func buildPathAndQuery(o endpoint) (string, *url.Values, error) {
+	path, err := o.buildPath()
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, err := o.buildQuery()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Validate the client UUID for every endpoint that sends it (those built
+	// via defaultQuery). Returning an error here lets callers handle an invalid
+	// UUID gracefully instead of panicking inside SDK-owned goroutines.
+	if query.Has("uuid") {
+		if err := utils.ValidateUUID(query.Get("uuid")); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if v := o.tokenManager().GetToken(); v != "" && query.Get("auth") == "" {
+		query.Set("auth", v)
+	} else if v := o.config().AuthKey; v != "" && query.Get("auth") == "" {
+		query.Set("auth", v)
 	}
 
-	sig := createSignatureV2FromStrings(
-		o.httpMethod(),
-		o.config().PublishKey,
-		o.config().SecretKey,
-		fmt.Sprintf("%s", path),
-		utils.PreparePamParams(query),
-		bodyString,
-	)
-
-	o.getPubNub().loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("PAM: signaturev2=%s", sig), false)
-	return sig
+	return path, query, nil
 }
 
 func createSignatureV2FromStrings(httpMethod, pubKey, secKey, path, query, body string) string {
@@ -198,6 +198,28 @@ func (p ReconnectionPolicy) String() string {
 	}
 }
 
+// UnverifiedMessagePolicy is used as an enum to catgorize what happens to the
+// received messages without a valid signature when Config.PublicKeyRegistry is set.
+type UnverifiedMessagePolicy int
+
+const (
+	// PNFlagUnverifiedMessages delivers the unsigned and invalid messages with Verified false.
+	PNFlagUnverifiedMessages UnverifiedMessagePolicy = iota
+	// PNDropUnverifiedMessages drops the unsigned and invalid messages.
+	PNDropUnverifiedMessages
+)
+
+func (p UnverifiedMessagePolicy) String() string {
+	switch p {
+	case PNFlagUnverifiedMessages:
+		return "Flag"
+	case PNDropUnverifiedMessages:
+		return "Drop"
+	default:
+		return "Unknown"
+	}
+}
+
 const (
 	// PNMessageTypeSignal is to identify Signal the Subscribe response
 	PNMessageTypeSignal PNMessageType = 1 + iota
@@ -244,6 +266,16 @@ const (
 	PNDisconnectedUnexpectedlyCategory
 	// PNPreconditionFailedCategory as the StatusCategory means the precondition for the request failed (e.g., ETag mismatch with If-Match header).
 	PNPreconditionFailedCategory
+	// PNRateLimitedCategory as the StatusCategory means the request was rejected by Config.RateLimits before it was sent.
+	PNRateLimitedCategory
+	// PNCircuitOpenedCategory as the StatusCategory means the circuit breaker of an endpoint group opened after failures, or rejected a request while open.
+	PNCircuitOpenedCategory
+	// PNCircuitHalfOpenCategory as the StatusCategory means the circuit breaker of an endpoint group lets probe requests through.
+	PNCircuitHalfOpenCategory
+	// PNCircuitClosedCategory as the StatusCategory means the circuit breaker of an endpoint group closed after successful probes.
+	PNCircuitClosedCategory
+	// PNOriginFailoverCategory as the StatusCategory means the client moved to the next origin of Config.Origins after a failure.
+	PNOriginFailoverCategory
 )
 
 const (
@@ -541,6 +573,21 @@ func (c StatusCategory) String() string {
 	case PNPreconditionFailedCategory:
 		return "Precondition Failed"
 
+	case PNRateLimitedCategory:
+		return "Rate Limited"
+
+	case PNCircuitOpenedCategory:
+		return "Circuit Opened"
+
+	case PNCircuitHalfOpenCategory:
+		return "Circuit Half Open"
+
+	case PNCircuitClosedCategory:
+		return "Circuit Closed"
+
+	case PNOriginFailoverCategory:
+		return "Origin Failover"
+
 	default:
 		return "No Stub Matched"
 
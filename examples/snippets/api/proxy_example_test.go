@@ -91,4 +91,23 @@ func proxyNonSubscribe() {
 	pn.SetClient(c)
 }
 
+// snippet.proxy_config
+// proxyConfig demonstrates configuring a proxy for all requests
+func proxyConfig() {
+	// The clients managed by the SDK use the proxy for subscribe, non-subscribe
+	// and file upload requests, and keep the HTTP/2 fallback of UseHTTP2.
+	config := pubnub.NewConfigWithUserId(pubnub.UserId("myUniqueUserId"))
+	config.ProxyURL = fmt.Sprintf("socks5://%s:%s@%s:%d", "proxyUser", "proxyPassword", "proxyServer", 1080)
+
+	pn := pubnub.NewPubNub(config)
+
+	// The request goes through the proxy.
+	res, _, err := pn.Time().Execute()
+	if err != nil {
+		fmt.Printf("ERROR: proxyConfig: Time request through the proxy failed: %s\n", err.Error())
+		return
+	}
+	fmt.Println("Timetoken:", res.Timetoken)
+}
+
 // snippet.end
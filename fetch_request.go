@@ -66,7 +66,9 @@ func (b *fetchBuilder) Count(count int) *fetchBuilder {
 	return b
 }
 
-// IncludeMeta fetches the meta data associated with the message
+// IncludeMeta fetches the meta data associated with the message.
+// It is required to reassemble the messages published with Chunked and to verify
+// the message signatures with Config.PublicKeyRegistry.
 func (b *fetchBuilder) IncludeMeta(withMeta bool) *fetchBuilder {
 	b.opts.WithMeta = withMeta
 	return b
@@ -232,6 +234,18 @@ func (o *fetchOpts) buildQuery() (*url.Values, error) {
 	return q, nil
 }
 
+func (o *fetchOpts) channelCount() int {
+	return len(o.Channels)
+}
+
+func (o *fetchOpts) channelList() []string {
+	return o.Channels
+}
+
+func (o *fetchOpts) channelGroupList() []string {
+	return nil
+}
+
 func (o *fetchOpts) operationType() OperationType {
 	return PNFetchMessagesOperation
 }
@@ -293,60 +307,61 @@ func (o *fetchOpts) fetchMessages(channels map[string]interface{}) map[string][]
 	for channel, histResponseSliceMap := range channels {
 		if histResponseMap, ok2 := histResponseSliceMap.([]interface{}); ok2 {
 			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: channel=%s, message count=%d", channel, len(histResponseMap)), false)
-			items := make([]FetchResponseItem, len(histResponseMap))
-			count := 0
+			items := make([]FetchResponseItem, 0, len(histResponseMap))
+
+			// chunks of chunked messages, by message ID
+			reassembler := newChunkReassembler(nil)
+			chunks := make(map[string][]map[string]interface{})
+			var chunkIDs []string
 
 			for _, val := range histResponseMap {
 				if histResponse, ok3 := val.(map[string]interface{}); ok3 {
-					msg, err := parseCipherInterface(histResponse["message"], o.pubnub)
+					chunk, userMeta, isChunk := parseMessageChunk(histResponse["meta"])
+					if !isChunk {
+						signerID, deliver := o.verifyMessage(channel, histResponse)
+						if !deliver {
+							continue
+						}
+						msg, err := parseCipherInterface(histResponse["message"], o.pubnub, channel)
+						items = append(items, o.newVerifiedFetchResponseItem(histResponse, msg, err, signerID))
+						o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: channel=%s, processed %d/%d items", channel, len(items), len(histResponseMap)), false)
+						continue
+					}
 
-					histItem := FetchResponseItem{
-						Message:   msg,
-						Timetoken: histResponse["timetoken"].(string),
-						Meta:      histResponse["meta"],
-						Error:     err,
+					if _, ok := chunks[chunk.ID]; !ok {
+						chunkIDs = append(chunkIDs, chunk.ID)
 					}
-					if d, ok := histResponse["message_type"]; ok {
-						switch v := d.(type) {
-						case float64:
-							histItem.MessageType = int(v)
-						case string:
-							t, err := strconv.ParseInt(v, 10, 64)
-							if err == nil {
-								histItem.MessageType = int(t)
-							} else {
-								o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Fetch: message_type conversion error", false)
-							}
-						default:
-							o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: message_type type=%v", d), false)
-							if v != nil {
-								o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: message_type kind=%v", reflect.TypeOf(v).Kind()), false)
-							} else {
-								o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Fetch: message_type nil", false)
-							}
-						}
+					chunks[chunk.ID] = append(chunks[chunk.ID], histResponse)
+					payload, _, complete, err := reassembler.add(channel, chunk, histResponse["message"], 0, 0)
+					if err != nil {
+						items = append(items, o.chunkFetchResponseItems(chunks[chunk.ID], err)...)
+						delete(chunks, chunk.ID)
+						continue
 					}
-					if d, ok := histResponse["uuid"]; ok {
-						histItem.UUID = d.(string)
+					if !complete {
+						continue
 					}
-					histItem.MessageActions = o.parseMessageActions(histResponse["actions"])
-					if filesPayload, okFile := msg.(map[string]interface{}); okFile {
-						f, m := ParseFileInfo(filesPayload)
+					delete(chunks, chunk.ID)
 
-						if f.Name != "" && f.ID != "" {
-							histItem.File = f
-							histItem.Message = m
-						}
+					histResponse["message"] = payload
+					histResponse["meta"] = userMeta
+					signerID, deliver := o.verifyMessage(channel, histResponse)
+					if !deliver {
+						continue
 					}
-
-					items[count] = histItem
-					o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: channel=%s, processed %d/%d items", channel, count, len(items)), false)
-					count++
+					msg, err := parseCipherInterface(payload, o.pubnub, channel)
+					items = append(items, o.newVerifiedFetchResponseItem(histResponse, msg, err, signerID))
 				} else {
 					o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: histResponse not a map: %v", histResponse), false)
 					continue
 				}
 			}
+			for _, id := range chunkIDs {
+				if pending, ok := chunks[id]; ok {
+					o.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Fetch: chunked message %s incomplete, %d chunks received", id, len(pending)), false)
+					items = append(items, o.chunkFetchResponseItems(pending, errIncompleteChunkedMessage)...)
+				}
+			}
 			messages[channel] = items
 			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: channel=%s, total messages=%d", channel, len(messages[channel])), false)
 		} else {
@@ -357,6 +372,83 @@ func (o *fetchOpts) fetchMessages(channels map[string]interface{}) map[string][]
 	return messages
 }
 
+// chunkFetchResponseItems returns the raw chunks of a chunked message which
+// couldn't be reassembled, with err set.
+func (o *fetchOpts) chunkFetchResponseItems(chunks []map[string]interface{}, err error) []FetchResponseItem {
+	items := make([]FetchResponseItem, len(chunks))
+	for i, histResponse := range chunks {
+		items[i] = o.newFetchResponseItem(histResponse, histResponse["message"], err)
+	}
+	return items
+}
+
+// verifyMessage verifies the signature of a message with the signature in its
+// meta, which is replaced with the meta without the signature. Only the
+// messages, not the files, are verified and the meta is only returned with
+// IncludeMeta. It returns the signer ID when the signature is valid and whether
+// the message is kept according to Config.UnverifiedMessagePolicy.
+func (o *fetchOpts) verifyMessage(channel string, histResponse map[string]interface{}) (string, bool) {
+	if messageType, ok := histResponse["message_type"].(float64); ok && PNMessageType(messageType) == PNMessageTypeFile {
+		return "", true
+	}
+	timetoken, _ := histResponse["timetoken"].(string)
+	tt, _ := strconv.ParseInt(timetoken, 10, 64)
+	userMeta, signerID, deliver := o.pubnub.verifyMessage(channel, histResponse["message"], histResponse["meta"], tt)
+	if _, ok := histResponse["meta"]; ok {
+		histResponse["meta"] = userMeta
+	}
+	return signerID, deliver
+}
+
+func (o *fetchOpts) newVerifiedFetchResponseItem(histResponse map[string]interface{}, msg interface{}, err error, signerID string) FetchResponseItem {
+	histItem := o.newFetchResponseItem(histResponse, msg, err)
+	histItem.Verified = signerID != ""
+	histItem.SignerID = signerID
+	return histItem
+}
+
+func (o *fetchOpts) newFetchResponseItem(histResponse map[string]interface{}, msg interface{}, err error) FetchResponseItem {
+	histItem := FetchResponseItem{
+		Message:   msg,
+		Timetoken: histResponse["timetoken"].(string),
+		Meta:      histResponse["meta"],
+		Error:     err,
+	}
+	if d, ok := histResponse["message_type"]; ok {
+		switch v := d.(type) {
+		case float64:
+			histItem.MessageType = int(v)
+		case string:
+			t, err := strconv.ParseInt(v, 10, 64)
+			if err == nil {
+				histItem.MessageType = int(t)
+			} else {
+				o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Fetch: message_type conversion error", false)
+			}
+		default:
+			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: message_type type=%v", d), false)
+			if v != nil {
+				o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Fetch: message_type kind=%v", reflect.TypeOf(v).Kind()), false)
+			} else {
+				o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Fetch: message_type nil", false)
+			}
+		}
+	}
+	if d, ok := histResponse["uuid"]; ok {
+		histItem.UUID = d.(string)
+	}
+	histItem.MessageActions = o.parseMessageActions(histResponse["actions"])
+	if filesPayload, okFile := msg.(map[string]interface{}); okFile {
+		f, m := ParseFileInfo(filesPayload)
+
+		if f.Name != "" && f.ID != "" {
+			histItem.File = f
+			histItem.Message = m
+		}
+	}
+	return histItem
+}
+
 func newFetchResponse(jsonBytes []byte, o *fetchOpts,
 	status StatusResponse) (*FetchResponse, StatusResponse, error) {
 
@@ -404,6 +496,10 @@ type FetchResponseItem struct {
 	UUID           string                                    `json:"uuid"`
 	MessageType    int                                       `json:"message_type"`
 	Error          error
+	// Verified is true when the message signature is valid, see Config.PublicKeyRegistry.
+	Verified bool `json:"-"`
+	// SignerID is the ID of the key which signed the message when Verified is true.
+	SignerID string `json:"-"`
 }
 
 // PNHistoryMessageActionsTypeMap is the struct used in the Fetch request that includes Message Actions
@@ -90,7 +90,7 @@ func (b *downloadFileBuilder) Execute() (*PNDownloadFileResponse, StatusResponse
 		Operation:        PNGetFileURLOperation,
 		StatusCode:       200,
 		TLSEnabled:       b.opts.config().Secure,
-		Origin:           b.opts.config().Origin,
+		Origin:           b.opts.origin(),
 		UUID:             b.opts.config().UUID,
 	}
 	b.opts.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Downloading file: URI=%s", u.RequestURI()), false)
@@ -105,13 +105,13 @@ func (b *downloadFileBuilder) Execute() (*PNDownloadFileResponse, StatusResponse
 	}
 
 	var respDL *PNDownloadFileResponse
-	if b.opts.CipherKey == "" && b.opts.pubnub.getCryptoModule() == nil {
+	if b.opts.CipherKey == "" && b.opts.pubnub.getChannelCryptoModule(b.opts.Channel) == nil {
 		respDL = &PNDownloadFileResponse{
 			File: resp.Body,
 		}
 	} else {
 		var e error
-		cryptoModule := b.opts.pubnub.getCryptoModule()
+		cryptoModule := b.opts.pubnub.getChannelCryptoModule(b.opts.Channel)
 		if b.opts.CipherKey != "" {
 			cryptoModule, e = crypto.NewLegacyCryptoModule(b.opts.CipherKey, true)
 			if e != nil {
@@ -128,6 +128,7 @@ func (b *downloadFileBuilder) Execute() (*PNDownloadFileResponse, StatusResponse
 		r, e := cryptoModule.DecryptStream(resp.Body)
 		if e != nil {
 			b.opts.pubnub.loggerManager.LogSimple(PNLogLevelError, "Crypto: decryption of file failed", false)
+			b.opts.pubnub.Config.metrics().RecordDecryptFailure()
 			b.opts.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Crypto: decryption of file failed due to %v", e), false)
 			return nil, stat, e
 		}
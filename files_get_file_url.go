@@ -85,7 +85,7 @@ func (b *getFileURLBuilder) Execute() (*PNGetFileURLResponse, StatusResponse, er
 		Operation:        PNGetFileURLOperation,
 		StatusCode:       200,
 		TLSEnabled:       b.opts.config().Secure,
-		Origin:           b.opts.config().Origin,
+		Origin:           b.opts.origin(),
 		UUID:             b.opts.config().UUID,
 	}
 	return resp, stat, nil
@@ -0,0 +1,56 @@
+package pubnub
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pubnub/go/v9/pnerr"
+)
+
+// ReencryptFile downloads a file, decrypted with the crypto module, and sends it
+// again so that it is encrypted with the current key of the crypto module, for
+// ex. the primary key of a crypto.Keyring after a key rotation. A new file
+// message is published on the channel. The original file is deleted when
+// deleteOriginal is true.
+//
+// The decrypted file is buffered in a temporary file, readable only by the
+// current user, which is removed before ReencryptFile returns.
+func (pn *PubNub) ReencryptFile(channel, id, name string, deleteOriginal bool) (*PNSendFileResponse, error) {
+	if pn.getChannelCryptoModule(channel) == nil {
+		return nil, pnerr.NewValidationError(PNSendFileOperation.String(), "Missing CryptoModule")
+	}
+
+	downloaded, _, err := pn.DownloadFile().Channel(channel).ID(id).Name(name).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "pubnub-reencrypt-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, downloaded.File); err != nil {
+		pn.loggerManager.LogError(err, "ReencryptFileDownloadFailed", PNDownloadFileOperation, true)
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	sent, _, err := pn.SendFile().Channel(channel).Name(name).File(tmp).Execute()
+	if err != nil {
+		return nil, err
+	}
+	pn.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("File %s re-encrypted as %s: channel=%s", id, sent.Data.ID, channel), false)
+
+	if deleteOriginal {
+		if _, _, err := pn.DeleteFile().Channel(channel).ID(id).Name(name).Execute(); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,73 @@
+package pubnub
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReencryptFile(t *testing.T) {
+	assert := assert.New(t)
+	keyring, err := crypto.NewKeyring("old", "key1")
+	require.NoError(t, err)
+	module := crypto.NewKeyringCryptoModule(keyring, true)
+
+	original, err := module.Encrypt([]byte("file content"))
+	require.NoError(t, err)
+
+	var origin string
+	var uploaded []byte
+	var deleted, published bool
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodDelete:
+			deleted = true
+			fmt.Fprint(w, `{"status":200}`)
+		case strings.HasSuffix(req.URL.Path, "/files/old-id/f.txt"):
+			w.Write(original)
+		case strings.HasSuffix(req.URL.Path, "/generate-upload-url"):
+			fmt.Fprintf(w, `{"status":200,"data":{"id":"new-id","name":"f.txt"},"file_upload_request":{"url":"http://%s/upload","method":"POST","form_fields":[]}}`, origin)
+		case req.URL.Path == "/upload":
+			file, _, err := req.FormFile("file")
+			require.NoError(t, err)
+			uploaded, _ = io.ReadAll(file)
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasPrefix(req.URL.Path, "/v1/files/publish-file/"):
+			published = true
+			fmt.Fprint(w, `[1,"Sent","15000000000000000"]`)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	})
+	origin = pn.Config.Origin
+	pn.Config.CryptoModule = module
+
+	require.NoError(t, keyring.AddKey("new", "key2"))
+	require.NoError(t, keyring.SetPrimary("new"))
+
+	resp, err := pn.ReencryptFile("ch", "old-id", "f.txt", true)
+	require.NoError(t, err)
+	assert.Equal("new-id", resp.Data.ID)
+	assert.True(published)
+	assert.True(deleted)
+
+	// The uploaded file is encrypted with the new primary key only.
+	require.NoError(t, keyring.RemoveKey("old"))
+	decrypted, err := module.Decrypt(uploaded)
+	assert.Nil(err)
+	assert.Equal("file content", string(decrypted))
+}
+
+func TestReencryptFileWithoutCryptoModule(t *testing.T) {
+	pn := NewPubNub(NewDemoConfig())
+
+	_, err := pn.ReencryptFile("ch", "id", "name", false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Missing CryptoModule")
+}
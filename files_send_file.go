@@ -295,6 +295,7 @@ func newPNSendFileResponse(jsonBytes []byte, o *sendFileOpts,
 	} else {
 		s = newSendFileToS3Builder(o.pubnub)
 	}
+	s.opts.channel = o.Channel
 	_, s3ResponseStatus, errS3Response := s.File(o.File).CipherKey(o.CipherKey).FileUploadRequestData(respForS3.FileUploadRequest).Execute()
 	if s3ResponseStatus.StatusCode != 204 {
 		o.pubnub.loggerManager.LogSimple(PNLogLevelWarn, fmt.Sprintf("Send file: S3 upload unexpected status=%d", s3ResponseStatus.StatusCode), false)
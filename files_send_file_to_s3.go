@@ -84,6 +84,9 @@ type sendFileToS3Opts struct {
 	QueryParam            map[string]string
 	CipherKey             string
 	Transport             http.RoundTripper
+
+	// channel of the file, used to resolve its crypto module
+	channel string
 }
 
 func (o *sendFileToS3Opts) validate() error {
@@ -138,7 +141,7 @@ func (o *sendFileToS3Opts) buildBodyMultipartFileUpload() (bytes.Buffer, *multip
 		return bytes.Buffer{}, writer, s, errFilePart
 	}
 
-	if o.CipherKey == "" && o.pubnub.getCryptoModule() == nil {
+	if o.CipherKey == "" && o.pubnub.getChannelCryptoModule(o.channel) == nil {
 		_, errIOCopy := io.Copy(filePart, o.File)
 
 		if errIOCopy != nil {
@@ -147,7 +150,7 @@ func (o *sendFileToS3Opts) buildBodyMultipartFileUpload() (bytes.Buffer, *multip
 		}
 	} else {
 		var e error
-		cryptoModule := o.pubnub.getCryptoModule()
+		cryptoModule := o.pubnub.getChannelCryptoModule(o.channel)
 
 		if o.CipherKey != "" {
 			cryptoModule, e = crypto.NewLegacyCryptoModule(o.CipherKey, true)
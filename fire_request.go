@@ -184,9 +184,9 @@ func (o *fireOpts) buildPath() (string, error) {
 	var message []byte
 	var err error
 
-	if o.pubnub.getCryptoModule() != nil {
+	if o.pubnub.getChannelCryptoModule(o.Channel) != nil {
 		var msg string
-		if msg, err = serializeEncryptAndSerialize(o.pubnub.getCryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); err != nil {
+		if msg, err = serializeEncryptAndSerialize(o.pubnub.getChannelCryptoModule(o.Channel), o.Message, o.Serialize, o.pubnub.loggerManager); err != nil {
 			o.pubnub.loggerManager.LogError(err, "FireMessageSerializationFailed", PNFireOperation, true)
 			return "", err
 		}
@@ -252,8 +252,8 @@ func (o *fireOpts) buildBody() ([]byte, error) {
 			}
 		}
 
-		if o.pubnub.getCryptoModule() != nil {
-			enc, err := encryptString(o.pubnub.getCryptoModule(), string(msg), o.pubnub.loggerManager)
+		if o.pubnub.getChannelCryptoModule(o.Channel) != nil {
+			enc, err := encryptString(o.pubnub.getChannelCryptoModule(o.Channel), string(msg), o.pubnub.loggerManager)
 			if err != nil {
 				return []byte{}, err
 			}
@@ -275,6 +275,18 @@ func (o *fireOpts) httpMethod() string {
 	return "GET"
 }
 
+func (o *fireOpts) channelCount() int {
+	return 1
+}
+
+func (o *fireOpts) channelName() string {
+	return o.Channel
+}
+
+func (o *fireOpts) message() interface{} {
+	return o.Message
+}
+
 func (o *fireOpts) operationType() OperationType {
 	return PNFireOperation
 }
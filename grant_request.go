@@ -222,7 +222,7 @@ func (o *grantOpts) validate() error {
 		return newValidationError(o, StrMissingSubKey)
 	}
 
-	if o.config().SecretKey == "" {
+	if o.config().requestSigner() == nil {
 		return newValidationError(o, StrMissingSecretKey)
 	}
 
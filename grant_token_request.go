@@ -393,7 +393,7 @@ func (o *grantTokenOpts) validate() error {
 		return newValidationError(o, StrMissingSubKey)
 	}
 
-	if o.config().SecretKey == "" {
+	if o.config().requestSigner() == nil {
 		return newValidationError(o, StrMissingSecretKey)
 	}
 
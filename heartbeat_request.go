@@ -154,6 +154,18 @@ func (o *heartbeatOpts) buildQuery() (*url.Values, error) {
 	return q, nil
 }
 
+func (o *heartbeatOpts) channelCount() int {
+	return len(o.Channels) + len(o.ChannelGroups)
+}
+
+func (o *heartbeatOpts) channelList() []string {
+	return o.Channels
+}
+
+func (o *heartbeatOpts) channelGroupList() []string {
+	return o.ChannelGroups
+}
+
 func (o *heartbeatOpts) operationType() OperationType {
 	return PNHeartBeatOperation
 }
@@ -90,6 +90,13 @@ func (b *hereNowBuilder) QueryParam(queryParam map[string]string) *hereNowBuilde
 	return b
 }
 
+// NoCache bypasses Config.RequestCache: the request is neither coalesced nor served from the cache.
+func (b *hereNowBuilder) NoCache() *hereNowBuilder {
+	b.opts.NoCache = true
+
+	return b
+}
+
 // Transport sets the Transport for the HereNow request.
 func (b *hereNowBuilder) Transport(tr http.RoundTripper) *hereNowBuilder {
 	b.opts.Transport = tr
@@ -151,6 +158,7 @@ type hereNowOpts struct {
 	Offset          int
 	SetOffset       bool
 	QueryParam      map[string]string
+	NoCache         bool
 
 	Transport http.RoundTripper
 }
@@ -232,6 +240,36 @@ func (o *hereNowOpts) connectTimeout() int {
 	return o.pubnub.Config.ConnectTimeout
 }
 
+func (o *hereNowOpts) channelCount() int {
+	return len(o.Channels) + len(o.ChannelGroups)
+}
+
+func (o *hereNowOpts) channelList() []string {
+	return o.Channels
+}
+
+func (o *hereNowOpts) channelGroupList() []string {
+	return o.ChannelGroups
+}
+
+func (o *hereNowOpts) cacheTags() []string {
+	if len(o.Channels) == 0 && len(o.ChannelGroups) == 0 {
+		return []string{cacheTagPresence}
+	}
+	tags := make([]string, 0, len(o.Channels)+len(o.ChannelGroups))
+	for _, ch := range o.Channels {
+		tags = append(tags, presenceCacheTag(ch))
+	}
+	for _, cg := range o.ChannelGroups {
+		tags = append(tags, presenceGroupCacheTag(cg))
+	}
+	return tags
+}
+
+func (o *hereNowOpts) bypassCache() bool {
+	return o.NoCache
+}
+
 func (o *hereNowOpts) operationType() OperationType {
 	return PNHereNowOperation
 }
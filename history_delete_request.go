@@ -108,7 +108,7 @@ func (o *historyDeleteOpts) validate() error {
 		return newValidationError(o, StrMissingSubKey)
 	}
 
-	if o.config().SecretKey == "" {
+	if o.config().requestSigner() == nil {
 		return newValidationError(o, StrMissingSecretKey)
 	}
 
@@ -220,6 +220,18 @@ func (o *historyOpts) connectTimeout() int {
 	return o.pubnub.Config.ConnectTimeout
 }
 
+func (o *historyOpts) channelCount() int {
+	return 1
+}
+
+func (o *historyOpts) channelList() []string {
+	return []string{o.Channel}
+}
+
+func (o *historyOpts) channelGroupList() []string {
+	return nil
+}
+
 func (o *historyOpts) operationType() OperationType {
 	return PNHistoryOperation
 }
@@ -264,9 +276,9 @@ func getHistoryItemsWithoutTimetoken(historyResponseRaw []byte, o *historyOpts,
 		if vMap, ok := v.(map[string]interface{}); ok {
 			// Try to extract message, timetoken, and meta from the map
 			if message, hasMessage := vMap["message"]; hasMessage {
-				items[i].Message, items[i].Error = parseCipherInterface(message, o.pubnub)
+				items[i].Message, items[i].Error = parseCipherInterface(message, o.pubnub, o.Channel)
 			} else {
-				items[i].Message, items[i].Error = parseCipherInterface(v, o.pubnub)
+				items[i].Message, items[i].Error = parseCipherInterface(v, o.pubnub, o.Channel)
 			}
 
 			// Preserve timetoken if present
@@ -284,7 +296,7 @@ func getHistoryItemsWithoutTimetoken(historyResponseRaw []byte, o *historyOpts,
 			}
 		} else {
 			// Plain value without timetoken/meta
-			items[i].Message, items[i].Error = parseCipherInterface(v, o.pubnub)
+			items[i].Message, items[i].Error = parseCipherInterface(v, o.pubnub, o.Channel)
 		}
 	}
 	return items, nil
@@ -298,7 +310,7 @@ func getHistoryItemsWithTimetoken(historyResponseItems []HistoryResponseItem, o
 	for i, v := range historyResponseItems {
 		if v.Message != nil {
 			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("History: processing message %d with timetoken", i), false)
-			items[i].Message, items[i].Error = parseCipherInterface(v.Message, o.pubnub)
+			items[i].Message, items[i].Error = parseCipherInterface(v.Message, o.pubnub, o.Channel)
 
 			items[i].Timetoken = v.Timetoken
 
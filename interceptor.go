@@ -0,0 +1,129 @@
+package pubnub
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// InterceptedRequest is a request of an operation passed to the interceptors.
+type InterceptedRequest struct {
+	Operation OperationType
+	// Options are the options of the endpoint of the operation, for ex. the
+	// publish options with the channel and the message. Channels,
+	// ChannelGroups and Message return the common ones.
+	Options interface{}
+	// Request is the built request, with its signature. Interceptors may change
+	// its headers and its query before calling next, a request whose query
+	// changed is signed again after the interceptors.
+	Request *http.Request
+	// Attempt is 0, or 1 when the request is retried with a refreshed token.
+	Attempt int
+}
+
+// channelListEndpoint is implemented by the endpoints of several channels and
+// channel groups, for InterceptedRequest.Channels and ChannelGroups.
+type channelListEndpoint interface {
+	channelList() []string
+	channelGroupList() []string
+}
+
+// messageEndpoint is implemented by the endpoints sending a message, for
+// InterceptedRequest.Message.
+type messageEndpoint interface {
+	message() interface{}
+}
+
+// Channels returns the channels of the request, nil when the operation has none.
+func (r *InterceptedRequest) Channels() []string {
+	switch o := r.Options.(type) {
+	case channelListEndpoint:
+		return o.channelList()
+	case channelEndpoint:
+		return []string{o.channelName()}
+	}
+	return nil
+}
+
+// ChannelGroups returns the channel groups of the request, nil when the operation has none.
+func (r *InterceptedRequest) ChannelGroups() []string {
+	if o, ok := r.Options.(channelListEndpoint); ok {
+		return o.channelGroupList()
+	}
+	return nil
+}
+
+// Message returns the message of the publish, fire, signal and publish file
+// message requests as passed to their builder, before its serialization and
+// encryption. It returns nil for the other operations.
+func (r *InterceptedRequest) Message() interface{} {
+	if o, ok := r.Options.(messageEndpoint); ok {
+		return o.message()
+	}
+	return nil
+}
+
+// InterceptorNext continues the chain of interceptors and sends the request
+// after the last one. It returns the body of the response and its parsed status.
+type InterceptorNext func(req *InterceptedRequest) ([]byte, StatusResponse, error)
+
+// Interceptor intercepts the requests of all the operations, including the
+// subscribe and the file upload ones, see Config.Interceptors.
+type Interceptor interface {
+	// Intercept calls next to send req, or returns a synthetic response
+	// without calling it, for ex. from a cache or in the tests.
+	Intercept(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error)
+}
+
+// InterceptorFunc adapts a function to an Interceptor.
+type InterceptorFunc func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error)
+
+// Intercept calls f.
+func (f InterceptorFunc) Intercept(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+	return f(req, next)
+}
+
+// interceptRequest runs the request through Config.Interceptors, and send after them.
+func interceptRequest(opts endpoint, req *InterceptedRequest, send InterceptorNext) ([]byte, StatusResponse, error) {
+	interceptors := opts.config().Interceptors
+	next := send
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(req *InterceptedRequest) ([]byte, StatusResponse, error) {
+			return interceptor.Intercept(req, inner)
+		}
+	}
+	return next(req)
+}
+
+// resignInterceptedRequest signs req again when the interceptors changed
+// signedQuery, the query of the URL signed by buildURL.
+func resignInterceptedRequest(opts endpoint, req *http.Request, signedQuery string) error {
+	signer := opts.config().requestSigner()
+	if signer == nil || req.URL.RawQuery == signedQuery || opts.operationType() == PNSendFileToS3Operation {
+		return nil
+	}
+	query, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		return err
+	}
+	query.Del("signature")
+	path, err := opts.buildPath()
+	if err != nil {
+		return err
+	}
+	signature, err := signRequest(opts, signer, path, &query)
+	if err != nil {
+		return err
+	}
+
+	params := strings.Split(req.URL.RawQuery, "&")
+	rawQuery := make([]string, 0, len(params)+1)
+	for _, param := range params {
+		if param != "" && !strings.HasPrefix(param, "signature=") {
+			rawQuery = append(rawQuery, param)
+		}
+	}
+	req.URL.RawQuery = strings.Join(append(rawQuery, "signature="+signature), "&")
+	return nil
+}
@@ -0,0 +1,176 @@
+package pubnub
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptorsChain(t *testing.T) {
+	assert := assert.New(t)
+	var header, query string
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Audit")
+		query = r.URL.Query().Get("tenant")
+		w.Write([]byte(`[15000000000000000]`))
+	})
+
+	var calls []string
+	var status StatusResponse
+	pn.Config.Interceptors = []Interceptor{
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			calls = append(calls, "outer:"+req.Operation.String())
+			req.Request.Header.Set("X-Audit", "outer")
+			val, s, err := next(req)
+			status = s
+			return val, s, err
+		}),
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			calls = append(calls, "inner:"+req.Operation.String())
+			_, ok := req.Options.(*timeOpts)
+			assert.True(ok)
+			q := req.Request.URL.Query()
+			q.Set("tenant", "acme")
+			req.Request.URL.RawQuery = q.Encode()
+			return next(req)
+		}),
+	}
+
+	res, _, err := pn.Time().Execute()
+	require.NoError(t, err)
+	assert.Equal(int64(15000000000000000), res.Timetoken)
+	assert.Equal([]string{"outer:Time", "inner:Time"}, calls)
+	assert.Equal("outer", header)
+	assert.Equal("acme", query)
+	assert.Equal(200, status.StatusCode)
+	assert.Equal(PNTimeOperation, status.Operation)
+}
+
+func TestInterceptorsShortCircuit(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn.Config.Interceptors = []Interceptor{
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			return []byte(`[16000000000000000]`), StatusResponse{Operation: req.Operation, StatusCode: 200}, nil
+		}),
+	}
+
+	res, status, err := pn.Time().Execute()
+	require.NoError(t, err)
+	assert.Equal(int64(16000000000000000), res.Timetoken)
+	assert.Equal(200, status.StatusCode)
+	assert.Empty(requests())
+
+	denied := errors.New("denied by policy")
+	pn.Config.Interceptors = []Interceptor{
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			return nil, StatusResponse{Operation: req.Operation, Category: PNBadRequestCategory, Error: denied}, denied
+		}),
+	}
+	_, status, err = pn.Time().Execute()
+	assert.Equal(denied, err)
+	assert.Equal(PNBadRequestCategory, status.Category)
+	assert.Empty(requests())
+}
+
+func TestInterceptorsSubscribe(t *testing.T) {
+	assert := assert.New(t)
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	})
+	var intercepted *InterceptedRequest
+	pn.Config.Interceptors = []Interceptor{
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			intercepted = req
+			return []byte(`{"t":{"t":"15000000000000000","r":1},"m":[]}`), StatusResponse{StatusCode: 200}, nil
+		}),
+	}
+
+	opts := newSubscribeOpts(pn, pn.ctx)
+	opts.Channels = []string{"ch"}
+	val, _, err := executeRequest(opts)
+	require.NoError(t, err)
+	assert.Contains(string(val), "15000000000000000")
+	require.NotNil(t, intercepted)
+	assert.Equal(PNSubscribeOperation, intercepted.Operation)
+	assert.Contains(intercepted.Request.URL.String(), "/v2/subscribe/sub/ch/0")
+}
+
+func TestInterceptorsQueryChangeSignedAgain(t *testing.T) {
+	assert := assert.New(t)
+	var rawQuery string
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		w.Write([]byte(`[1,"Sent","15000000000000000"]`))
+	})
+	pn.Config.SecretKey = "secret"
+
+	var signature string
+	pn.Config.Interceptors = []Interceptor{
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			q := req.Request.URL.Query()
+			signature = q.Get("signature")
+			q.Set("tenant", "acme")
+			req.Request.URL.RawQuery = q.Encode()
+			return next(req)
+		}),
+	}
+
+	_, _, err := pn.Publish().Channel("ch").Message("hi").Execute()
+	require.NoError(t, err)
+	received, err := url.ParseQuery(rawQuery)
+	require.NoError(t, err)
+	assert.Equal("acme", received.Get("tenant"))
+	assert.NotEmpty(signature)
+	assert.NotEqual(signature, received.Get("signature"))
+	assert.Len(received["signature"], 1)
+
+	// The server checks the signature of the query it receives.
+	opts := newPublishOpts(pn, pn.ctx)
+	opts.Channel = "ch"
+	opts.Message = "hi"
+	path, err := opts.buildPath()
+	require.NoError(t, err)
+	expected := received.Get("signature")
+	received.Del("signature")
+	resigned, err := signRequest(opts, pn.Config.requestSigner(), path, &received)
+	require.NoError(t, err)
+	assert.Equal(expected, resigned)
+}
+
+func TestInterceptedRequestAccessors(t *testing.T) {
+	assert := assert.New(t)
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[1,"Sent","15000000000000000"]`))
+	})
+
+	type intercepted struct {
+		channels, groups []string
+		message          interface{}
+	}
+	var seen []intercepted
+	pn.Config.Interceptors = []Interceptor{
+		InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+			seen = append(seen, intercepted{req.Channels(), req.ChannelGroups(), req.Message()})
+			return []byte(`{"status":200,"message":"OK","service":"Presence"}`), StatusResponse{Operation: req.Operation, StatusCode: 200}, nil
+		}),
+	}
+
+	message := map[string]interface{}{"text": "hi"}
+	pn.Publish().Channel("ch").Message(message).Execute()
+	pn.Signal().Channel("typing").Message("on").Execute()
+	pn.HereNow().Channels([]string{"a", "b"}).ChannelGroups([]string{"cg"}).Execute()
+	pn.Time().Execute()
+
+	require.Len(t, seen, 4)
+	assert.Equal(intercepted{[]string{"ch"}, nil, message}, seen[0])
+	assert.Equal(intercepted{[]string{"typing"}, nil, "on"}, seen[1])
+	assert.Equal(intercepted{[]string{"a", "b"}, []string{"cg"}, nil}, seen[2])
+	assert.Equal(intercepted{}, seen[3])
+}
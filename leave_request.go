@@ -107,6 +107,18 @@ func (o *leaveOpts) validate() error {
 	return nil
 }
 
+func (o *leaveOpts) channelCount() int {
+	return len(o.Channels) + len(o.ChannelGroups)
+}
+
+func (o *leaveOpts) channelList() []string {
+	return o.Channels
+}
+
+func (o *leaveOpts) channelGroupList() []string {
+	return o.ChannelGroups
+}
+
 func (o *leaveOpts) operationType() OperationType {
 	return PNUnsubscribeOperation
 }
@@ -239,6 +239,85 @@ func (m *ListenerManager) announceFile(file *PNFilesEvent) {
 	}()
 }
 
+// eventHandlers holds optional callbacks used by SDK components that consume
+// subscribe events through an internal Listener. Nil handlers are skipped.
+type eventHandlers struct {
+	Status              func(*PNStatus)
+	Message             func(*PNMessage)
+	Presence            func(*PNPresence)
+	Signal              func(*PNMessage)
+	UUIDEvent           func(*PNUUIDEvent)
+	ChannelEvent        func(*PNChannelEvent)
+	MembershipEvent     func(*PNMembershipEvent)
+	MessageActionsEvent func(*PNMessageActionsEvent)
+	File                func(*PNFilesEvent)
+}
+
+// addEventHandlers registers an internal Listener and dispatches its events to
+// the given handlers. Every channel of the listener is drained, as announcements
+// block until each registered listener has received the event.
+// The returned function removes the listener and stops the dispatch goroutine.
+func (pn *PubNub) addEventHandlers(h eventHandlers) func() {
+	listener := NewListener()
+	done := make(chan struct{})
+	pn.AddListener(listener)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-pn.ctx.Done():
+				return
+			case s := <-listener.Status:
+				if h.Status != nil {
+					h.Status(s)
+				}
+			case msg := <-listener.Message:
+				if h.Message != nil {
+					h.Message(msg)
+				}
+			case p := <-listener.Presence:
+				if h.Presence != nil {
+					h.Presence(p)
+				}
+			case sig := <-listener.Signal:
+				if h.Signal != nil {
+					h.Signal(sig)
+				}
+			case e := <-listener.UUIDEvent:
+				if h.UUIDEvent != nil {
+					h.UUIDEvent(e)
+				}
+			case e := <-listener.ChannelEvent:
+				if h.ChannelEvent != nil {
+					h.ChannelEvent(e)
+				}
+			case e := <-listener.MembershipEvent:
+				if h.MembershipEvent != nil {
+					h.MembershipEvent(e)
+				}
+			case e := <-listener.MessageActionsEvent:
+				if h.MessageActionsEvent != nil {
+					h.MessageActionsEvent(e)
+				}
+			case f := <-listener.File:
+				if h.File != nil {
+					h.File(f)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			pn.RemoveListener(listener)
+			close(done)
+		})
+	}
+}
+
 // PNStatus is the status struct
 type PNStatus struct {
 	Category              StatusCategory
@@ -253,6 +332,7 @@ type PNStatus struct {
 	ClientRequest         interface{} // Should be same for non-google environment
 	AffectedChannels      []string
 	AffectedChannelGroups []string
+	AdditionalData        interface{} // CircuitBreakerTransition for the circuit breaker categories
 }
 
 // PNMessage is the Message Response for Subscribe
@@ -267,6 +347,10 @@ type PNMessage struct {
 	Timetoken         int64
 	CustomMessageType string
 	Error             error
+	// Verified is true when the message signature is valid, see Config.PublicKeyRegistry.
+	Verified bool
+	// SignerID is the ID of the key which signed the message when Verified is true.
+	SignerID string
 }
 
 // PNPresence is the Message Response for Presence
@@ -43,15 +43,17 @@ func (b BaseLogMessage) GetCallsite() string     { return b.Callsite }
 // SimpleLogMessage is used for general purpose logging.
 type SimpleLogMessage struct {
 	BaseLogMessage
+	Operation OperationType // 0 when the message isn't about an operation
 }
 
 // NetworkRequestLogMessage contains HTTP request details.
 type NetworkRequestLogMessage struct {
 	BaseLogMessage
-	Method  string
-	URL     string
-	Headers map[string]string
-	Body    string
+	Operation OperationType
+	Method    string
+	URL       string
+	Headers   map[string]string
+	Body      string
 }
 
 // NetworkResponseLogMessage contains HTTP response details.
@@ -110,13 +110,28 @@ func (lm *loggerManager) LogSimple(level PNLogLevel, message string, includeCall
 	})
 }
 
+// LogOperation logs a simple text message about an operation at the specified level.
+// includeCallsite: if true, captures the file and line number of the caller
+func (lm *loggerManager) LogOperation(level PNLogLevel, operation OperationType, message string, includeCallsite bool) {
+	lm.log(SimpleLogMessage{
+		BaseLogMessage: BaseLogMessage{
+			Timestamp:  time.Now(),
+			InstanceID: lm.instanceID,
+			LogLevel:   level,
+			Message:    message,
+			Callsite:   lm.captureCallsite(includeCallsite, 2),
+		},
+		Operation: operation,
+	})
+}
+
 // ============================================================================
 // Specialized Logging Methods
 // ============================================================================
 
 // LogNetworkRequest logs an HTTP request.
 // includeCallsite: if true, captures the file and line number of the caller
-func (lm *loggerManager) LogNetworkRequest(level PNLogLevel, method, url string, headers map[string]string, body string, includeCallsite bool) {
+func (lm *loggerManager) LogNetworkRequest(level PNLogLevel, operation OperationType, method, url string, headers map[string]string, body string, includeCallsite bool) {
 	lm.log(NetworkRequestLogMessage{
 		BaseLogMessage: BaseLogMessage{
 			Timestamp:  time.Now(),
@@ -125,10 +140,11 @@ func (lm *loggerManager) LogNetworkRequest(level PNLogLevel, method, url string,
 			Message:    "HTTP Request",
 			Callsite:   lm.captureCallsite(includeCallsite, 2),
 		},
-		Method:  method,
-		URL:     url,
-		Headers: headers,
-		Body:    body,
+		Operation: operation,
+		Method:    method,
+		URL:       url,
+		Headers:   headers,
+		Body:      body,
 	})
 }
 
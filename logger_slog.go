@@ -0,0 +1,262 @@
+package pubnub
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Attributes of the records of SlogLogger.
+const (
+	SlogAttrInstanceID = "pubnub.instance_id"
+	SlogAttrOperation  = "pubnub.operation"
+	SlogAttrCallsite   = "pubnub.callsite"
+	SlogAttrMethod     = "http.request.method"
+	SlogAttrURL        = "url.full"
+	SlogAttrHeaders    = "http.request.headers"
+	SlogAttrStatusCode = "http.response.status_code"
+	SlogAttrProtocol   = "network.protocol"
+	SlogAttrBody       = "body"
+	SlogAttrError      = "error"
+	SlogAttrErrorName  = "error.name"
+	SlogAttrParameters = "parameters"
+)
+
+// SlogLevelTrace is the slog level of the PNLogLevelTrace messages.
+const SlogLevelTrace = slog.LevelDebug - 4
+
+// redacted replaces the secrets and the encrypted payloads in the records of SlogLogger.
+const redacted = "[REDACTED]"
+
+// SlogLoggerOptions are the options of a SlogLogger.
+type SlogLoggerOptions struct {
+	// MinLogLevel is the minimum level of the messages, all levels when 0.
+	MinLogLevel PNLogLevel
+	// OperationLevels override MinLogLevel for the messages of operations.
+	OperationLevels map[OperationType]PNLogLevel
+	// SampleRates keep 1 of N of the trace and debug messages of operations,
+	// for ex. {PNSubscribeOperation: 100} for the subscribe loop.
+	SampleRates map[OperationType]int
+}
+
+// SlogLogger is a PNLogger writing the log messages to a slog.Handler, with
+// the fields of the messages as attributes. The auth, signature, token and
+// pnsdk params and the encrypted payloads are redacted at every level.
+type SlogLogger struct {
+	handler  slog.Handler
+	options  SlogLoggerOptions
+	minLevel PNLogLevel
+	samples  sync.Map // OperationType -> *atomic.Uint64
+}
+
+// Compile-time check to ensure SlogLogger implements PNLogger
+var _ PNLogger = (*SlogLogger)(nil)
+
+// NewSlogLogger creates a logger writing to handler.
+func NewSlogLogger(handler slog.Handler, options SlogLoggerOptions) *SlogLogger {
+	minLevel := options.MinLogLevel
+	for _, level := range options.OperationLevels {
+		if level < minLevel {
+			minLevel = level
+		}
+	}
+	return &SlogLogger{
+		handler:  handler,
+		options:  options,
+		minLevel: minLevel,
+	}
+}
+
+// GetMinLogLevel returns the lowest level of MinLogLevel and OperationLevels.
+func (l *SlogLogger) GetMinLogLevel() PNLogLevel {
+	return l.minLevel
+}
+
+// Log implements PNLogger interface.
+func (l *SlogLogger) Log(logMsg LogMessage) {
+	level := logMsg.GetLogLevel()
+	op := logMessageOperation(logMsg)
+	if !l.enabled(level, op) || !l.sampled(level, op) {
+		return
+	}
+	slogLevel := slogLevel(level)
+	if !l.handler.Enabled(backgroundContext, slogLevel) {
+		return
+	}
+
+	record := slog.NewRecord(logMsg.GetTimestamp(), slogLevel, redactText(logMsg.GetMessage()), 0)
+	record.AddAttrs(slog.String(SlogAttrInstanceID, logMsg.GetInstanceID()))
+	if op != 0 {
+		record.AddAttrs(slog.String(SlogAttrOperation, op.String()))
+	}
+	if callsite := logMsg.GetCallsite(); callsite != "" {
+		record.AddAttrs(slog.String(SlogAttrCallsite, callsite))
+	}
+
+	switch msg := logMsg.(type) {
+	case NetworkRequestLogMessage:
+		record.AddAttrs(slog.String(SlogAttrMethod, msg.Method), slog.String(SlogAttrURL, redactURL(msg.URL)))
+		if len(msg.Headers) > 0 {
+			headers := make([]any, 0, len(msg.Headers))
+			for _, k := range sortedKeys(msg.Headers) {
+				headers = append(headers, slog.String(k, redactText(msg.Headers[k])))
+			}
+			record.AddAttrs(slog.Group(SlogAttrHeaders, headers...))
+		}
+		if msg.Body != "" {
+			record.AddAttrs(slog.String(SlogAttrBody, redactBody(msg.Body)))
+		}
+	case NetworkResponseLogMessage:
+		record.AddAttrs(slog.Int(SlogAttrStatusCode, msg.StatusCode), slog.String(SlogAttrURL, redactURL(msg.URL)))
+		if msg.Protocol != "" {
+			record.AddAttrs(slog.String(SlogAttrProtocol, msg.Protocol))
+		}
+		if msg.Body != "" {
+			record.AddAttrs(slog.String(SlogAttrBody, redactBody(msg.Body)))
+		}
+	case ErrorLogMessage:
+		if msg.Error != nil {
+			record.AddAttrs(slog.String(SlogAttrError, redactText(msg.Error.Error())))
+		}
+		if msg.ErrorName != "" {
+			record.AddAttrs(slog.String(SlogAttrErrorName, msg.ErrorName))
+		}
+	case UserInputLogMessage:
+		params := make([]any, 0, len(msg.Parameters))
+		for _, k := range sortedKeys(msg.Parameters) {
+			if isSecretParam(k) {
+				params = append(params, slog.String(k, redacted))
+			} else {
+				params = append(params, slog.Any(k, msg.Parameters[k]))
+			}
+		}
+		record.AddAttrs(slog.Group(SlogAttrParameters, params...))
+	}
+
+	l.handler.Handle(backgroundContext, record)
+}
+
+// enabled checks the level of a message of op against MinLogLevel or its override.
+func (l *SlogLogger) enabled(level PNLogLevel, op OperationType) bool {
+	min := l.options.MinLogLevel
+	if override, ok := l.options.OperationLevels[op]; ok && op != 0 {
+		min = override
+	}
+	return min != PNLogLevelNone && level >= min
+}
+
+// sampled checks whether a trace or debug message of op is kept by its sample rate.
+func (l *SlogLogger) sampled(level PNLogLevel, op OperationType) bool {
+	if level >= PNLogLevelInfo || op == 0 {
+		return true
+	}
+	rate := l.options.SampleRates[op]
+	if rate <= 1 {
+		return true
+	}
+	counter, _ := l.samples.LoadOrStore(op, new(atomic.Uint64))
+	return (counter.(*atomic.Uint64).Add(1)-1)%uint64(rate) == 0
+}
+
+// logMessageOperation returns the operation of a message, 0 when it has none.
+func logMessageOperation(logMsg LogMessage) OperationType {
+	switch msg := logMsg.(type) {
+	case SimpleLogMessage:
+		return msg.Operation
+	case NetworkRequestLogMessage:
+		return msg.Operation
+	case NetworkResponseLogMessage:
+		return msg.Operation
+	case ErrorLogMessage:
+		return msg.Operation
+	case UserInputLogMessage:
+		return msg.Operation
+	}
+	return 0
+}
+
+func slogLevel(level PNLogLevel) slog.Level {
+	switch level {
+	case PNLogLevelTrace:
+		return SlogLevelTrace
+	case PNLogLevelDebug:
+		return slog.LevelDebug
+	case PNLogLevelInfo:
+		return slog.LevelInfo
+	case PNLogLevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+var (
+	// secretParamRegex matches the secret params of the URLs, also in the
+	// messages and errors which quote them.
+	secretParamRegex = regexp.MustCompile(`\b(auth|signature|token|pnsdk)=[^&\s"']*`)
+	// tokenPathRegex matches the token in the path of the RevokeToken requests.
+	tokenPathRegex = regexp.MustCompile(`(/v3/pam/[^/?\s]+/grant/)[^/?\s"']+`)
+	// tokenFieldRegex matches the token of the GrantToken responses.
+	tokenFieldRegex = regexp.MustCompile(`("token"\s*:\s*")[^"]*"`)
+	// base64Regex matches the candidates to encrypted payloads.
+	base64Regex = regexp.MustCompile(`[A-Za-z0-9+/]{22,}={0,2}`)
+)
+
+// redactText redacts the values of the secret params and the tokens in s.
+func redactText(s string) string {
+	s = secretParamRegex.ReplaceAllString(s, "${1}="+redacted)
+	s = tokenPathRegex.ReplaceAllString(s, "${1}"+redacted)
+	return tokenFieldRegex.ReplaceAllString(s, "${1}"+redacted+`"`)
+}
+
+// redactBody redacts the tokens and the encrypted payloads of a body.
+func redactBody(s string) string {
+	return redactEncrypted(tokenFieldRegex.ReplaceAllString(s, "${1}"+redacted+`"`))
+}
+
+// redactURL redacts the secret params of rawURL, and its path when it has an
+// encrypted message, as the GET publish requests, or a token, as the
+// RevokeToken requests.
+func redactURL(rawURL string) string {
+	path, query, hasQuery := strings.Cut(rawURL, "?")
+	path = tokenPathRegex.ReplaceAllString(path, "${1}"+redacted)
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		if res := redactEncrypted(unescaped); res != unescaped {
+			path = res
+		}
+	}
+	if hasQuery {
+		return path + "?" + redactText(query)
+	}
+	return path
+}
+
+// redactEncrypted redacts the encrypted payloads of s: the base64 runs which
+// decode to a crypto module header or to whole AES blocks.
+func redactEncrypted(s string) string {
+	return base64Regex.ReplaceAllStringFunc(s, func(run string) string {
+		b, err := base64.StdEncoding.DecodeString(run)
+		if err != nil {
+			return run
+		}
+		if strings.HasPrefix(string(b), "PNED") || len(b)%16 == 0 {
+			return redacted
+		}
+		return run
+	})
+}
+
+// isSecretParam checks whether a user input param is a secret.
+func isSecretParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range []string{"auth", "signature", "token", "secret", "cipher"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package pubnub
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSlogLogger(options SlogLoggerOptions) (*loggerManager, func() []map[string]interface{}) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: SlogLevelTrace})
+	mgr := newLoggerManager("test-instance", []PNLogger{NewSlogLogger(handler, options)})
+	return mgr, func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				panic(err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+}
+
+func TestSlogLoggerAttributes(t *testing.T) {
+	assert := assert.New(t)
+	mgr, records := newTestSlogLogger(SlogLoggerOptions{MinLogLevel: PNLogLevelDebug})
+
+	mgr.LogNetworkRequest(PNLogLevelDebug, PNTimeOperation, "GET", "https://ps.pndsn.com/time/0?uuid=me", map[string]string{"User-Agent": "test"}, "", true)
+	mgr.LogNetworkResponse(PNLogLevelInfo, 200, "https://ps.pndsn.com/time/0?uuid=me", "[1500000000000000]", PNTimeOperation, nil, false)
+	mgr.LogError(errors.New("failed"), "TimeError", PNTimeOperation, false)
+	mgr.LogSimple(PNLogLevelTrace, "filtered", false)
+
+	res := records()
+	assert.Len(res, 3)
+
+	assert.Equal("DEBUG", res[0]["level"])
+	assert.Equal("HTTP Request", res[0]["msg"])
+	assert.Equal("test-instance", res[0][SlogAttrInstanceID])
+	assert.Equal("Time", res[0][SlogAttrOperation])
+	assert.Equal("GET", res[0][SlogAttrMethod])
+	assert.Equal("https://ps.pndsn.com/time/0?uuid=me", res[0][SlogAttrURL])
+	assert.Equal(map[string]interface{}{"User-Agent": "test"}, res[0][SlogAttrHeaders])
+	assert.Contains(res[0][SlogAttrCallsite], "logger_slog_test.go:")
+
+	assert.Equal("INFO", res[1]["level"])
+	assert.Equal(float64(200), res[1][SlogAttrStatusCode])
+	assert.Equal("[1500000000000000]", res[1][SlogAttrBody])
+
+	assert.Equal("ERROR", res[2]["level"])
+	assert.Equal("failed", res[2][SlogAttrError])
+	assert.Equal("TimeError", res[2][SlogAttrErrorName])
+}
+
+func TestSlogLoggerRedaction(t *testing.T) {
+	assert := assert.New(t)
+	mgr, records := newTestSlogLogger(SlogLoggerOptions{MinLogLevel: PNLogLevelTrace})
+
+	encrypted := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	mgr.LogNetworkRequest(PNLogLevelDebug, PNPublishOperation, "GET",
+		"https://ps.pndsn.com/publish/pub/sub/0/ch/0/%22"+strings.ReplaceAll(encrypted, "/", "%2F")+"%22?auth=secret&pnsdk=PubNub-Go%2F9&signature=v2.sig&uuid=me",
+		nil, "", false)
+	mgr.LogNetworkResponse(PNLogLevelDebug, 200, "https://ps.pndsn.com/v2/subscribe/sub/ch/0?token=tok",
+		`{"t":{"t":"17000000000000000","r":1},"m":[{"d":"`+encrypted+`"}]}`, PNSubscribeOperation, nil, false)
+	mgr.LogSimple(PNLogLevelTrace, "PAM: signing query=auth=secret&uuid=me", false)
+	mgr.LogUserInput(PNLogLevelDebug, PNAccessManagerGrantToken, map[string]interface{}{"AuthKey": "secret", "TTL": 10}, false)
+
+	res := records()
+	assert.Len(res, 4)
+	for _, r := range res {
+		b, _ := json.Marshal(r)
+		assert.NotContains(string(b), "secret")
+		assert.NotContains(string(b), encrypted)
+	}
+	assert.Equal("https://ps.pndsn.com/publish/pub/sub/0/ch/0/\"[REDACTED]\"?auth=[REDACTED]&pnsdk=[REDACTED]&signature=[REDACTED]&uuid=me", res[0][SlogAttrURL])
+	assert.Equal("https://ps.pndsn.com/v2/subscribe/sub/ch/0?token=[REDACTED]", res[1][SlogAttrURL])
+	assert.Equal(`{"t":{"t":"17000000000000000","r":1},"m":[{"d":"[REDACTED]"}]}`, res[1][SlogAttrBody])
+	assert.Equal("PAM: signing query=auth=[REDACTED]&uuid=me", res[2]["msg"])
+	assert.Equal(map[string]interface{}{"AuthKey": "[REDACTED]", "TTL": float64(10)}, res[3][SlogAttrParameters])
+}
+
+func TestSlogLoggerTokenRedaction(t *testing.T) {
+	assert := assert.New(t)
+	mgr, records := newTestSlogLogger(SlogLoggerOptions{MinLogLevel: PNLogLevelTrace})
+
+	token := "qEF2AkF0GmEI03xDdHRsGDxDcmVzpURjaGFuoWNjaDEY70NncnCgQ3VzcqBDc3BjoENwYXSk"
+	revokeURL := "https://ps.pndsn.com/v3/pam/sub/grant/" + strings.ReplaceAll(token, "/", "%2F") + "?uuid=me"
+	mgr.LogNetworkRequest(PNLogLevelDebug, PNAccessManagerRevokeToken, "DELETE", revokeURL, nil, "", false)
+	mgr.LogNetworkResponse(PNLogLevelDebug, 200, "https://ps.pndsn.com/v3/pam/sub/grant?uuid=me",
+		`{"data":{"message":"Success","token": "`+token+`"},"service":"Access Manager","status":200}`, PNAccessManagerGrantToken, nil, false)
+	mgr.LogError(errors.New("request failed: DELETE "+revokeURL), "RevokeTokenError", PNAccessManagerRevokeToken, false)
+
+	res := records()
+	assert.Len(res, 3)
+	for _, r := range res {
+		b, _ := json.Marshal(r)
+		assert.NotContains(string(b), token)
+	}
+	assert.Equal("https://ps.pndsn.com/v3/pam/sub/grant/[REDACTED]?uuid=me", res[0][SlogAttrURL])
+	assert.Equal("https://ps.pndsn.com/v3/pam/sub/grant?uuid=me", res[1][SlogAttrURL])
+	assert.Equal(`{"data":{"message":"Success","token": "[REDACTED]"},"service":"Access Manager","status":200}`, res[1][SlogAttrBody])
+	assert.Equal("request failed: DELETE https://ps.pndsn.com/v3/pam/sub/grant/[REDACTED]?uuid=me", res[2][SlogAttrError])
+}
+
+func TestSlogLoggerOperationLevels(t *testing.T) {
+	assert := assert.New(t)
+	logger := NewSlogLogger(slog.NewJSONHandler(&bytes.Buffer{}, nil), SlogLoggerOptions{
+		MinLogLevel:     PNLogLevelInfo,
+		OperationLevels: map[OperationType]PNLogLevel{PNPublishOperation: PNLogLevelTrace, PNSubscribeOperation: PNLogLevelError},
+	})
+	assert.Equal(PNLogLevelTrace, logger.GetMinLogLevel())
+
+	mgr, records := newTestSlogLogger(SlogLoggerOptions{
+		MinLogLevel:     PNLogLevelInfo,
+		OperationLevels: map[OperationType]PNLogLevel{PNPublishOperation: PNLogLevelTrace, PNSubscribeOperation: PNLogLevelError},
+	})
+	mgr.LogOperation(PNLogLevelTrace, PNPublishOperation, "publish trace", false)
+	mgr.LogOperation(PNLogLevelWarn, PNSubscribeOperation, "subscribe warn", false)
+	mgr.LogOperation(PNLogLevelError, PNSubscribeOperation, "subscribe error", false)
+	mgr.LogSimple(PNLogLevelDebug, "debug", false)
+	mgr.LogSimple(PNLogLevelInfo, "info", false)
+
+	var msgs []interface{}
+	for _, r := range records() {
+		msgs = append(msgs, r["msg"])
+	}
+	assert.Equal([]interface{}{"publish trace", "subscribe error", "info"}, msgs)
+}
+
+func TestSlogLoggerSampling(t *testing.T) {
+	assert := assert.New(t)
+	mgr, records := newTestSlogLogger(SlogLoggerOptions{
+		MinLogLevel: PNLogLevelTrace,
+		SampleRates: map[OperationType]int{PNSubscribeOperation: 10},
+	})
+
+	for i := 0; i < 25; i++ {
+		mgr.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribe trace", false)
+		mgr.LogOperation(PNLogLevelTrace, PNPublishOperation, "publish trace", false)
+	}
+	mgr.LogOperation(PNLogLevelWarn, PNSubscribeOperation, "subscribe warn", false)
+
+	counts := make(map[interface{}]int)
+	for _, r := range records() {
+		counts[r["msg"]]++
+	}
+	assert.Equal(map[interface{}]int{"subscribe trace": 3, "publish trace": 25, "subscribe warn": 1}, counts)
+}
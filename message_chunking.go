@@ -0,0 +1,269 @@
+package pubnub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// chunkMetaKey is the Meta key which carries the chunk info of a chunked message.
+const chunkMetaKey = "pn_chunk"
+
+// minMessageChunkSize is the min Config.MessageChunkSize of a chunked publish.
+const minMessageChunkSize = 1024
+
+var (
+	errIncompleteChunkedMessage = errors.New("chunked message incomplete")
+	errChunkedMessageIntegrity  = errors.New("chunked message integrity check failed")
+)
+
+// ChunkedPublishError is the error of a chunked publish which failed after
+// some of its chunks were published. The subscribers drop the published chunks
+// after Config.ChunkReassemblyTimeout.
+type ChunkedPublishError struct {
+	// ID is the ID of the chunked message.
+	ID    string
+	Total int
+	// Timetokens are the timetokens of the published chunks, in order.
+	Timetokens []int64
+	Err        error
+}
+
+func (e *ChunkedPublishError) Error() string {
+	return fmt.Sprintf("chunked message %s failed after %d of %d chunks were published: %v", e.ID, len(e.Timetokens), e.Total, e.Err)
+}
+
+func (e *ChunkedPublishError) Unwrap() error {
+	return e.Err
+}
+
+// messageChunk describes one publish of a chunked message.
+type messageChunk struct {
+	ID    string // ID shared by all the chunks of a message
+	Index int    // Position of the chunk, from 0 to Total-1
+	Total int    // Number of chunks of the message
+	Hash  string // Hex SHA-256 of the reassembled payload
+}
+
+func (c messageChunk) toMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"id": c.ID,
+		"i":  c.Index,
+		"n":  c.Total,
+		"h":  c.Hash,
+	}
+}
+
+// parseMessageChunk returns the chunk info carried in meta and the user meta
+// without it. ok is false when meta doesn't belong to a chunked message.
+func parseMessageChunk(meta interface{}) (chunk messageChunk, userMeta interface{}, ok bool) {
+	m, ok := meta.(map[string]interface{})
+	if !ok {
+		return chunk, meta, false
+	}
+	c, ok := m[chunkMetaKey].(map[string]interface{})
+	if !ok {
+		return chunk, meta, false
+	}
+	id, _ := c["id"].(string)
+	index, okIndex := c["i"].(float64)
+	total, okTotal := c["n"].(float64)
+	hash, _ := c["h"].(string)
+	if id == "" || hash == "" || !okIndex || !okTotal || total < 1 || index < 0 || index >= total {
+		return chunk, meta, false
+	}
+
+	if len(m) > 1 {
+		rest := make(map[string]interface{}, len(m)-1)
+		for k, v := range m {
+			if k != chunkMetaKey {
+				rest[k] = v
+			}
+		}
+		userMeta = rest
+	}
+
+	return messageChunk{ID: id, Index: int(index), Total: int(total), Hash: hash}, userMeta, true
+}
+
+// chunkedMeta returns a copy of the user meta with the chunk info added.
+// The user meta must be nil or serialize to a JSON object.
+func chunkedMeta(meta interface{}, chunk messageChunk) (map[string]interface{}, error) {
+	res, err := metaObject(meta, "Meta must be a JSON object to publish a chunked message")
+	if err != nil {
+		return nil, err
+	}
+	res[chunkMetaKey] = chunk.toMeta()
+	return res, nil
+}
+
+// metaObject returns a copy of meta as a JSON object, or an error with msg
+// when meta isn't a JSON object.
+func metaObject(meta interface{}, msg string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	switch v := meta.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, val := range v {
+			res[k] = val
+		}
+	default:
+		b, err := json.Marshal(meta)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &res); err != nil {
+			return nil, errors.New(msg)
+		}
+	}
+	return res, nil
+}
+
+// splitMessageChunks splits payload on rune boundaries so that each chunk,
+// once encoded as a JSON string, is at most size bytes.
+func splitMessageChunks(payload string, size int) []string {
+	var chunks []string
+	start, n := 0, 2
+	for i, r := range payload {
+		l := jsonStringRuneLen(r)
+		if n+l > size && i > start {
+			chunks = append(chunks, payload[start:i])
+			start, n = i, 2
+		}
+		n += l
+	}
+	return append(chunks, payload[start:])
+}
+
+// jsonStringRuneLen returns the max number of bytes json.Marshal uses for r in a string.
+func jsonStringRuneLen(r rune) int {
+	switch {
+	case r == '"' || r == '\\' || r == '\n' || r == '\r' || r == '\t':
+		return 2
+	case r < 0x20 || r == '<' || r == '>' || r == '&' || r == '\u2028' || r == '\u2029' || r == utf8.RuneError:
+		return 6
+	}
+	return utf8.RuneLen(r)
+}
+
+func chunkHash(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// pendingChunkedMessage buffers the chunks received for a message.
+type pendingChunkedMessage struct {
+	chunk     messageChunk
+	parts     []string
+	received  int
+	timetoken int64
+	timer     *time.Timer
+}
+
+// chunkReassembler buffers the chunks of chunked messages until all of them
+// are received. Sets which are not complete within the timeout are dropped.
+type chunkReassembler struct {
+	sync.Mutex
+
+	pending  map[string]*pendingChunkedMessage
+	onExpire func(channel string, chunk messageChunk)
+}
+
+func newChunkReassembler(onExpire func(channel string, chunk messageChunk)) *chunkReassembler {
+	return &chunkReassembler{
+		pending:  make(map[string]*pendingChunkedMessage),
+		onExpire: onExpire,
+	}
+}
+
+// add buffers a chunk. When the set is complete the reassembled payload is
+// verified against the hash, decoded and returned with the highest timetoken
+// of the set. A timeout <= 0 keeps incomplete sets until they are dropped
+// explicitly.
+func (r *chunkReassembler) add(channel string, chunk messageChunk, piece interface{}, timetoken int64, timeout time.Duration) (payload interface{}, lastTimetoken int64, complete bool, err error) {
+	part, ok := piece.(string)
+	if !ok {
+		return nil, 0, false, fmt.Errorf("chunk %d of message %s is not a string", chunk.Index, chunk.ID)
+	}
+
+	key := channel + "/" + chunk.ID
+	r.Lock()
+	p, ok := r.pending[key]
+	if !ok {
+		p = &pendingChunkedMessage{
+			chunk: chunk,
+			parts: make([]string, chunk.Total),
+		}
+		if timeout > 0 {
+			p.timer = time.AfterFunc(timeout, func() {
+				r.expire(channel, key, p)
+			})
+		}
+		r.pending[key] = p
+	}
+	if p.chunk.Total != chunk.Total || p.chunk.Hash != chunk.Hash {
+		r.drop(key)
+		r.Unlock()
+		return nil, 0, false, errChunkedMessageIntegrity
+	}
+	if p.parts[chunk.Index] == "" {
+		p.received++
+	}
+	p.parts[chunk.Index] = part
+	if timetoken > p.timetoken {
+		p.timetoken = timetoken
+	}
+	if p.received < chunk.Total {
+		r.Unlock()
+		return nil, 0, false, nil
+	}
+	r.drop(key)
+	r.Unlock()
+
+	joined := strings.Join(p.parts, "")
+	if chunkHash(joined) != chunk.Hash {
+		return nil, 0, false, errChunkedMessageIntegrity
+	}
+	if err := json.Unmarshal([]byte(joined), &payload); err != nil {
+		return nil, 0, false, errChunkedMessageIntegrity
+	}
+	return payload, p.timetoken, true, nil
+}
+
+// drop removes a pending set. Must be called with the lock held.
+func (r *chunkReassembler) drop(key string) {
+	if p, ok := r.pending[key]; ok {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		delete(r.pending, key)
+	}
+}
+
+func (r *chunkReassembler) expire(channel, key string, p *pendingChunkedMessage) {
+	r.Lock()
+	current := r.pending[key] == p
+	if current {
+		delete(r.pending, key)
+	}
+	r.Unlock()
+
+	if current && r.onExpire != nil {
+		r.onExpire(channel, p.chunk)
+	}
+}
+
+// reset drops all the pending sets.
+func (r *chunkReassembler) reset() {
+	r.Lock()
+	for key := range r.pending {
+		r.drop(key)
+	}
+	r.Unlock()
+}
@@ -0,0 +1,314 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type publishedChunk struct {
+	body      string
+	meta      map[string]interface{}
+	timetoken int64
+}
+
+// newChunkedPublishTestPubNub returns a PubNub publishing to a server which
+// replies with the current time plus the number of the request as timetoken.
+func newChunkedPublishTestPubNub(t *testing.T) (*PubNub, func() []publishedChunk) {
+	start := time.Now().UnixNano() / 100
+	var requests func() []testRequest
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `[1,"Sent","%d"]`, start+int64(len(requests())))
+	})
+
+	return pn, func() []publishedChunk {
+		var chunks []publishedChunk
+		for i, req := range requests() {
+			var meta map[string]interface{}
+			if m := req.URL.Query().Get("meta"); m != "" {
+				require.NoError(t, json.Unmarshal([]byte(m), &meta))
+			}
+			chunks = append(chunks, publishedChunk{body: req.body, meta: meta, timetoken: start + int64(i+1)})
+		}
+		return chunks
+	}
+}
+
+// subscribeChunks delivers the published chunks to the subscribe path and returns the announced messages.
+func subscribeChunks(t *testing.T, pn *PubNub, chunks []publishedChunk) []*PNMessage {
+	listener := NewListener()
+	pn.AddListener(listener)
+	defer pn.RemoveListener(listener)
+
+	var received []*PNMessage
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case m := <-listener.Message:
+				received = append(received, m)
+			case <-listener.Status:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for i, c := range chunks {
+		timetoken := c.timetoken
+		if timetoken == 0 {
+			timetoken = 15000000000000000 + int64(i+1)
+		}
+		var payload interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.body), &payload))
+		var meta interface{}
+		b, _ := json.Marshal(c.meta)
+		require.NoError(t, json.Unmarshal(b, &meta))
+		processSubscribePayload(pn.subscriptionManager, subscribeMessage{
+			Channel:         "ch",
+			Payload:         payload,
+			UserMetadata:    meta,
+			IssuingClientID: "me",
+			PublishMetaData: publishMetadata{PublishTimetoken: strconv.FormatInt(timetoken, 10)},
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	done <- true
+	return received
+}
+
+func TestSplitMessageChunks(t *testing.T) {
+	assert := assert.New(t)
+	payload := `{"text":"héllo <world> \"quoted\" ` + strings.Repeat("日本語", 20) + `"}`
+
+	chunks := splitMessageChunks(payload, 20)
+	assert.True(len(chunks) > 1)
+	assert.Equal(payload, strings.Join(chunks, ""))
+	for _, c := range chunks {
+		b, err := json.Marshal(c)
+		assert.Nil(err)
+		assert.LessOrEqual(len(b), 20)
+	}
+
+	assert.Equal([]string{"abc"}, splitMessageChunks("abc", 100))
+}
+
+func TestChunkedMetaAndParseMessageChunk(t *testing.T) {
+	assert := assert.New(t)
+	chunk := messageChunk{ID: "id", Index: 1, Total: 3, Hash: "h"}
+
+	meta, err := chunkedMeta(map[string]interface{}{"k": "v"}, chunk)
+	assert.Nil(err)
+	b, _ := json.Marshal(meta)
+	var decoded interface{}
+	assert.Nil(json.Unmarshal(b, &decoded))
+
+	parsed, userMeta, ok := parseMessageChunk(decoded)
+	assert.True(ok)
+	assert.Equal(chunk, parsed)
+	assert.Equal(map[string]interface{}{"k": "v"}, userMeta)
+
+	meta, err = chunkedMeta(nil, chunk)
+	assert.Nil(err)
+	b, _ = json.Marshal(meta)
+	assert.Nil(json.Unmarshal(b, &decoded))
+	_, userMeta, ok = parseMessageChunk(decoded)
+	assert.True(ok)
+	assert.Nil(userMeta)
+
+	_, err = chunkedMeta("not an object", chunk)
+	assert.NotNil(err)
+
+	_, _, ok = parseMessageChunk(map[string]interface{}{"k": "v"})
+	assert.False(ok)
+	_, _, ok = parseMessageChunk(map[string]interface{}{chunkMetaKey: map[string]interface{}{"id": "x", "i": 3.0, "n": 3.0, "h": "h"}})
+	assert.False(ok)
+}
+
+func TestPublishChunkedSmallMessageIsNotSplit(t *testing.T) {
+	assert := assert.New(t)
+	pn, chunks := newChunkedPublishTestPubNub(t)
+
+	_, _, err := pn.Publish().Channel("ch").Message("small").Chunked(true).Execute()
+	assert.Nil(err)
+	require.Len(t, chunks(), 1)
+	assert.Nil(chunks()[0].meta)
+}
+
+func TestPublishChunkedRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	pn, chunks := newChunkedPublishTestPubNub(t)
+	pn.Config.MessageChunkSize = 1024
+
+	message := map[string]interface{}{"text": strings.Repeat("chunk ", 600)}
+	resp, _, err := pn.Publish().Channel("ch").Message(message).Meta(map[string]interface{}{"k": "v"}).Chunked(true).Execute()
+	assert.Nil(err)
+
+	published := chunks()
+	require.True(t, len(published) > 1)
+	last := published[len(published)-1].timetoken
+	assert.Equal(last, resp.Timestamp)
+	for _, c := range published {
+		assert.LessOrEqual(len(c.body), 1024)
+		assert.Equal("v", c.meta["k"])
+		assert.NotNil(c.meta[chunkMetaKey])
+	}
+
+	// Chunks received out of order are reassembled into one message.
+	published[0], published[1] = published[1], published[0]
+	received := subscribeChunks(t, pn, published)
+	require.Len(t, received, 1)
+	assert.Equal(message, received[0].Message)
+	assert.Equal(map[string]interface{}{"k": "v"}, received[0].UserMetadata)
+	assert.Equal(last, received[0].Timetoken)
+	assert.Nil(received[0].Error)
+}
+
+func TestPublishChunkedEncrypted(t *testing.T) {
+	assert := assert.New(t)
+	pn, chunks := newChunkedPublishTestPubNub(t)
+	pn.Config.MessageChunkSize = 1024
+	module, err := crypto.NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, err)
+	pn.Config.CryptoModule = module
+
+	message := strings.Repeat("secret ", 400)
+	_, _, err = pn.Publish().Channel("ch").Message(message).Chunked(true).Execute()
+	assert.Nil(err)
+	for _, c := range chunks() {
+		assert.NotContains(c.body, "secret")
+	}
+
+	received := subscribeChunks(t, pn, chunks())
+	require.Len(t, received, 1)
+	assert.Equal(message, received[0].Message)
+	assert.Nil(received[0].UserMetadata)
+}
+
+func TestPublishChunkedInvalidMeta(t *testing.T) {
+	pn, chunks := newChunkedPublishTestPubNub(t)
+	pn.Config.MessageChunkSize = 1024
+
+	_, _, err := pn.Publish().Channel("ch").Message(strings.Repeat("a", 2000)).Meta("meta").Chunked(true).Execute()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Meta must be a JSON object")
+	assert.Empty(t, chunks())
+}
+
+func TestPublishChunkedMinChunkSize(t *testing.T) {
+	pn, chunks := newChunkedPublishTestPubNub(t)
+	pn.Config.MessageChunkSize = 0
+
+	_, _, err := pn.Publish().Channel("ch").Message(strings.Repeat("a", 2000)).Chunked(true).Execute()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "MessageChunkSize must be at least")
+	assert.Empty(t, chunks())
+}
+
+func TestPublishChunkedPartialFailure(t *testing.T) {
+	assert := assert.New(t)
+	var requests func() []testRequest
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		if len(requests()) > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `[1,"Sent","15000000000000001"]`)
+	})
+	pn.Config.MessageChunkSize = 1024
+
+	_, _, err := pn.Publish().Channel("ch").Message(strings.Repeat("a", 3000)).Chunked(true).Execute()
+	var chunkedErr *ChunkedPublishError
+	require.True(t, errors.As(err, &chunkedErr))
+	assert.Equal(3, chunkedErr.Total)
+	assert.Equal([]int64{15000000000000001}, chunkedErr.Timetokens)
+	assert.NotEmpty(chunkedErr.ID)
+	assert.Len(requests(), 2)
+}
+
+func TestChunkReassemblerIntegrity(t *testing.T) {
+	assert := assert.New(t)
+	r := newChunkReassembler(nil)
+	payload := `"hello world"`
+	hash := chunkHash(payload)
+
+	_, _, complete, err := r.add("ch", messageChunk{ID: "a", Index: 0, Total: 2, Hash: hash}, `"hello`, 1, 0)
+	assert.Nil(err)
+	assert.False(complete)
+	_, _, complete, err = r.add("ch", messageChunk{ID: "a", Index: 1, Total: 2, Hash: hash}, ` there"`, 2, 0)
+	assert.Equal(errChunkedMessageIntegrity, err)
+	assert.False(complete)
+
+	_, _, _, err = r.add("ch", messageChunk{ID: "b", Index: 0, Total: 1, Hash: hash}, 10, 1, 0)
+	assert.NotNil(err)
+
+	msg, tt, complete, err := r.add("ch", messageChunk{ID: "c", Index: 0, Total: 1, Hash: hash}, payload, 7, 0)
+	assert.Nil(err)
+	assert.True(complete)
+	assert.Equal("hello world", msg)
+	assert.Equal(int64(7), tt)
+	assert.Empty(r.pending)
+}
+
+func TestChunkReassemblerTimeout(t *testing.T) {
+	assert := assert.New(t)
+	expired := make(chan messageChunk, 1)
+	r := newChunkReassembler(func(channel string, chunk messageChunk) {
+		assert.Equal("ch", channel)
+		expired <- chunk
+	})
+
+	_, _, complete, err := r.add("ch", messageChunk{ID: "a", Index: 0, Total: 2, Hash: "h"}, "x", 1, 20*time.Millisecond)
+	assert.Nil(err)
+	assert.False(complete)
+
+	select {
+	case chunk := <-expired:
+		assert.Equal("a", chunk.ID)
+	case <-time.After(time.Second):
+		assert.Fail("incomplete set not expired")
+	}
+	r.Lock()
+	assert.Empty(r.pending)
+	r.Unlock()
+}
+
+func TestFetchReassemblesChunkedMessages(t *testing.T) {
+	assert := assert.New(t)
+	payload := `{"text":"hello world"}`
+	hash := chunkHash(payload)
+	meta := func(id string, i, n int, h string) string {
+		return fmt.Sprintf(`{"k":"v","pn_chunk":{"id":%q,"i":%d,"n":%d,"h":%q}}`, id, i, n, h)
+	}
+	jsonString := []byte(fmt.Sprintf(`{"status":200,"error":false,"error_message":"","channels":{"ch":[
+		{"message":"before","timetoken":"1"},
+		{"message":%q,"timetoken":"2","meta":%s,"uuid":"u"},
+		{"message":%q,"timetoken":"3","meta":%s,"uuid":"u"},
+		{"message":"orphan","timetoken":"4","meta":%s},
+		{"message":"after","timetoken":"5"}]}}`,
+		payload[:10], meta("a", 0, 2, hash), payload[10:], meta("a", 1, 2, hash), meta("b", 0, 2, hash)))
+
+	resp, _, err := newFetchResponse(jsonString, initFetchOpts(""), fakeResponseState)
+	assert.Nil(err)
+	items := resp.Messages["ch"]
+	require.Len(t, items, 4)
+
+	assert.Equal("before", items[0].Message)
+	assert.Equal(map[string]interface{}{"text": "hello world"}, items[1].Message)
+	assert.Equal("3", items[1].Timetoken)
+	assert.Equal(map[string]interface{}{"k": "v"}, items[1].Meta)
+	assert.Equal("u", items[1].UUID)
+	assert.Nil(items[1].Error)
+	assert.Equal("after", items[2].Message)
+	assert.Equal("orphan", items[3].Message)
+	assert.Equal(errIncompleteChunkedMessage, items[3].Error)
+}
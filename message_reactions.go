@@ -0,0 +1,283 @@
+package pubnub
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const defaultMessageReactionsPageLimit = 100
+
+// MessageReactions aggregates the message actions of a channel by message timetoken.
+// It can be seeded from GetMessageActions or Fetch (with IncludeMessageActions) and
+// kept current with the live PNMessageActionsEvent stream.
+type MessageReactions struct {
+	sync.RWMutex
+
+	pubnub  *PubNub
+	channel string
+	stop    func()
+
+	// message timetoken -> action type -> action value -> uuid -> action timetoken
+	messages map[string]map[string]map[string]map[string]string
+}
+
+// PNMessageReaction is the aggregated state of one action type and value on a message.
+type PNMessageReaction struct {
+	ActionType  string
+	ActionValue string
+	Count       int
+	UUIDs       []string
+	ReactedByMe bool
+}
+
+// PNMessageReactions contains all aggregated reactions of a single message.
+type PNMessageReactions struct {
+	MessageTimetoken string
+	Reactions        []PNMessageReaction
+}
+
+// NewMessageReactions creates a reactions aggregator for the channel.
+func NewMessageReactions(pubnub *PubNub, channel string) *MessageReactions {
+	return &MessageReactions{
+		pubnub:   pubnub,
+		channel:  channel,
+		messages: make(map[string]map[string]map[string]map[string]string),
+	}
+}
+
+// Channel returns the channel the aggregator is bound to.
+func (r *MessageReactions) Channel() string {
+	return r.channel
+}
+
+// Start keeps the aggregator current with the message actions events received on the subscribe loop.
+func (r *MessageReactions) Start() {
+	r.Lock()
+	defer r.Unlock()
+	if r.stop != nil {
+		return
+	}
+	r.stop = r.pubnub.addEventHandlers(eventHandlers{
+		MessageActionsEvent: r.HandleEvent,
+	})
+}
+
+// Stop stops listening for message actions events. The aggregated state is kept.
+func (r *MessageReactions) Stop() {
+	r.Lock()
+	stop := r.stop
+	r.stop = nil
+	r.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// Load seeds the aggregator from GetMessageActions, paging backwards with Start and Limit
+// until the channel has no more actions. A limit <= 0 uses a page size of 100.
+func (r *MessageReactions) Load(limit int) error {
+	if limit <= 0 {
+		limit = defaultMessageReactionsPageLimit
+	}
+	start := ""
+	for {
+		builder := r.pubnub.GetMessageActions().Channel(r.channel).Limit(limit)
+		if start != "" {
+			builder.Start(start)
+		}
+		resp, _, err := builder.Execute()
+		if err != nil {
+			return err
+		}
+		for _, action := range resp.Data {
+			r.add(action.MessageTimetoken, action.ActionType, action.ActionValue, action.UUID, action.ActionTimetoken)
+		}
+		r.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("MessageReactions: loaded %d actions, channel=%s, start=%s", len(resp.Data), r.channel, start), false)
+		if len(resp.Data) == 0 || resp.More.Start == "" || resp.More.Start == start {
+			return nil
+		}
+		start = resp.More.Start
+	}
+}
+
+// LoadFetchResponse seeds the aggregator from a Fetch response requested with IncludeMessageActions(true).
+// Messages of other channels are ignored.
+func (r *MessageReactions) LoadFetchResponse(resp *FetchResponse) {
+	if resp == nil {
+		return
+	}
+	for _, item := range resp.Messages[r.channel] {
+		for actionType, values := range item.MessageActions {
+			for actionValue, actions := range values.ActionsTypeValues {
+				for _, action := range actions {
+					r.add(item.Timetoken, actionType, actionValue, action.UUID, action.ActionTimetoken)
+				}
+			}
+		}
+	}
+}
+
+// HandleEvent applies a live message actions event. Events of other channels are ignored.
+func (r *MessageReactions) HandleEvent(event *PNMessageActionsEvent) {
+	if event == nil || event.Channel != r.channel {
+		return
+	}
+	data := event.Data
+	switch event.Event {
+	case PNMessageActionsAdded:
+		r.add(data.MessageTimetoken, data.ActionType, data.ActionValue, data.UUID, data.ActionTimetoken)
+	case PNMessageActionsRemoved:
+		r.remove(data.MessageTimetoken, data.ActionType, data.ActionValue, data.UUID)
+	}
+}
+
+// Reactions returns the aggregated reactions of a message sorted by action type and value.
+func (r *MessageReactions) Reactions(messageTimetoken string) PNMessageReactions {
+	r.RLock()
+	defer r.RUnlock()
+
+	res := PNMessageReactions{MessageTimetoken: messageTimetoken}
+	me := r.pubnub.Config.UUID
+	for actionType, values := range r.messages[messageTimetoken] {
+		for actionValue, uuids := range values {
+			reaction := PNMessageReaction{
+				ActionType:  actionType,
+				ActionValue: actionValue,
+				Count:       len(uuids),
+				UUIDs:       make([]string, 0, len(uuids)),
+			}
+			for uuid := range uuids {
+				reaction.UUIDs = append(reaction.UUIDs, uuid)
+				if uuid == me {
+					reaction.ReactedByMe = true
+				}
+			}
+			sort.Strings(reaction.UUIDs)
+			res.Reactions = append(res.Reactions, reaction)
+		}
+	}
+	sort.Slice(res.Reactions, func(i, j int) bool {
+		if res.Reactions[i].ActionType != res.Reactions[j].ActionType {
+			return res.Reactions[i].ActionType < res.Reactions[j].ActionType
+		}
+		return res.Reactions[i].ActionValue < res.Reactions[j].ActionValue
+	})
+	return res
+}
+
+// Count returns the number of UUIDs that reacted to a message with the action type and value.
+func (r *MessageReactions) Count(messageTimetoken, actionType, actionValue string) int {
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.messages[messageTimetoken][actionType][actionValue])
+}
+
+// HasReacted reports whether the current user reacted to a message with the action type and value.
+func (r *MessageReactions) HasReacted(messageTimetoken, actionType, actionValue string) bool {
+	_, ok := r.actionTimetoken(messageTimetoken, actionType, actionValue, r.pubnub.Config.UUID)
+	return ok
+}
+
+// AddReaction adds the current user's reaction unless it is already present.
+func (r *MessageReactions) AddReaction(messageTimetoken, actionType, actionValue string) error {
+	if r.HasReacted(messageTimetoken, actionType, actionValue) {
+		return nil
+	}
+	resp, _, err := r.pubnub.AddMessageAction().
+		Channel(r.channel).
+		MessageTimetoken(messageTimetoken).
+		Action(MessageAction{ActionType: actionType, ActionValue: actionValue}).
+		Execute()
+	if err != nil {
+		return err
+	}
+	actionTimetoken := ""
+	if resp != nil {
+		actionTimetoken = resp.Data.ActionTimetoken
+	}
+	r.add(messageTimetoken, actionType, actionValue, r.pubnub.Config.UUID, actionTimetoken)
+	return nil
+}
+
+// RemoveReaction removes the current user's reaction if it is present.
+func (r *MessageReactions) RemoveReaction(messageTimetoken, actionType, actionValue string) error {
+	me := r.pubnub.Config.UUID
+	actionTimetoken, ok := r.actionTimetoken(messageTimetoken, actionType, actionValue, me)
+	if !ok {
+		return nil
+	}
+	_, _, err := r.pubnub.RemoveMessageAction().
+		Channel(r.channel).
+		MessageTimetoken(messageTimetoken).
+		ActionTimetoken(actionTimetoken).
+		Execute()
+	if err != nil {
+		return err
+	}
+	r.remove(messageTimetoken, actionType, actionValue, me)
+	return nil
+}
+
+// ToggleReaction removes the current user's reaction if present, otherwise adds it.
+// It returns true when the reaction was added.
+func (r *MessageReactions) ToggleReaction(messageTimetoken, actionType, actionValue string) (bool, error) {
+	if r.HasReacted(messageTimetoken, actionType, actionValue) {
+		return false, r.RemoveReaction(messageTimetoken, actionType, actionValue)
+	}
+	return true, r.AddReaction(messageTimetoken, actionType, actionValue)
+}
+
+func (r *MessageReactions) actionTimetoken(messageTimetoken, actionType, actionValue, uuid string) (string, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	tt, ok := r.messages[messageTimetoken][actionType][actionValue][uuid]
+	return tt, ok
+}
+
+func (r *MessageReactions) add(messageTimetoken, actionType, actionValue, uuid, actionTimetoken string) {
+	if messageTimetoken == "" || actionType == "" || uuid == "" {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	types, ok := r.messages[messageTimetoken]
+	if !ok {
+		types = make(map[string]map[string]map[string]string)
+		r.messages[messageTimetoken] = types
+	}
+	values, ok := types[actionType]
+	if !ok {
+		values = make(map[string]map[string]string)
+		types[actionType] = values
+	}
+	uuids, ok := values[actionValue]
+	if !ok {
+		uuids = make(map[string]string)
+		values[actionValue] = uuids
+	}
+	uuids[uuid] = actionTimetoken
+}
+
+func (r *MessageReactions) remove(messageTimetoken, actionType, actionValue, uuid string) {
+	r.Lock()
+	defer r.Unlock()
+
+	types := r.messages[messageTimetoken]
+	values := types[actionType]
+	uuids := values[actionValue]
+	if uuids == nil {
+		return
+	}
+	delete(uuids, uuid)
+	if len(uuids) == 0 {
+		delete(values, actionValue)
+	}
+	if len(values) == 0 {
+		delete(types, actionType)
+	}
+	if len(types) == 0 {
+		delete(r.messages, messageTimetoken)
+	}
+}
@@ -0,0 +1,190 @@
+package pubnub
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageReactionsHandleEvent(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	r := NewMessageReactions(pn, "ch")
+
+	event := func(e PNMessageActionsEventType, channel, uuid, value string) *PNMessageActionsEvent {
+		return &PNMessageActionsEvent{
+			Event:   e,
+			Channel: channel,
+			Data: PNMessageActionsResponse{
+				ActionType:       "reaction",
+				ActionValue:      value,
+				MessageTimetoken: "100",
+				ActionTimetoken:  "200",
+				UUID:             uuid,
+			},
+		}
+	}
+
+	r.HandleEvent(event(PNMessageActionsAdded, "ch", "me", "smile"))
+	r.HandleEvent(event(PNMessageActionsAdded, "ch", "other", "smile"))
+	r.HandleEvent(event(PNMessageActionsAdded, "ch", "other", "smile"))
+	r.HandleEvent(event(PNMessageActionsAdded, "ch", "other", "heart"))
+	r.HandleEvent(event(PNMessageActionsAdded, "other-ch", "third", "smile"))
+
+	assert.Equal(2, r.Count("100", "reaction", "smile"))
+	assert.Equal(1, r.Count("100", "reaction", "heart"))
+	assert.True(r.HasReacted("100", "reaction", "smile"))
+	assert.False(r.HasReacted("100", "reaction", "heart"))
+
+	reactions := r.Reactions("100")
+	assert.Equal("100", reactions.MessageTimetoken)
+	require.Len(t, reactions.Reactions, 2)
+	assert.Equal("heart", reactions.Reactions[0].ActionValue)
+	assert.Equal("smile", reactions.Reactions[1].ActionValue)
+	assert.Equal([]string{"me", "other"}, reactions.Reactions[1].UUIDs)
+	assert.True(reactions.Reactions[1].ReactedByMe)
+
+	r.HandleEvent(event(PNMessageActionsRemoved, "ch", "me", "smile"))
+	r.HandleEvent(event(PNMessageActionsRemoved, "ch", "other", "heart"))
+	assert.Equal(1, r.Count("100", "reaction", "smile"))
+	assert.False(r.HasReacted("100", "reaction", "smile"))
+	assert.Len(r.Reactions("100").Reactions, 1)
+}
+
+func TestMessageReactionsLoadFetchResponse(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	r := NewMessageReactions(pn, "ch")
+
+	r.LoadFetchResponse(&FetchResponse{
+		Messages: map[string][]FetchResponseItem{
+			"ch": {{
+				Timetoken: "100",
+				MessageActions: map[string]PNHistoryMessageActionsTypeMap{
+					"reaction": {ActionsTypeValues: map[string][]PNHistoryMessageActionTypeVal{
+						"smile": {{UUID: "me", ActionTimetoken: "1"}, {UUID: "a", ActionTimetoken: "2"}},
+					}},
+				},
+			}},
+			"other": {{
+				Timetoken: "100",
+				MessageActions: map[string]PNHistoryMessageActionsTypeMap{
+					"reaction": {ActionsTypeValues: map[string][]PNHistoryMessageActionTypeVal{
+						"smile": {{UUID: "b", ActionTimetoken: "3"}},
+					}},
+				},
+			}},
+		},
+	})
+	r.LoadFetchResponse(nil)
+
+	assert.Equal(2, r.Count("100", "reaction", "smile"))
+	assert.True(r.HasReacted("100", "reaction", "smile"))
+}
+
+func TestMessageReactionsLoadPages(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		start := req.URL.Query().Get("start")
+
+		assert.Equal("2", req.URL.Query().Get("limit"))
+		switch start {
+		case "":
+			fmt.Fprint(w, `{"status":200,"data":[
+				{"type":"reaction","value":"smile","uuid":"a","actionTimetoken":"40","messageTimetoken":"1"},
+				{"type":"reaction","value":"smile","uuid":"b","actionTimetoken":"30","messageTimetoken":"1"}],
+				"more":{"url":"/v1/message-actions/sub/channel/ch?start=30&limit=2","start":"30","limit":2}}`)
+		case "30":
+			fmt.Fprint(w, `{"status":200,"data":[
+				{"type":"reaction","value":"heart","uuid":"me","actionTimetoken":"20","messageTimetoken":"2"}]}`)
+		default:
+			t.Errorf("unexpected start %s", start)
+		}
+	})
+
+	r := NewMessageReactions(pn, "ch")
+	assert.Nil(r.Load(2))
+	require.Len(t, requests(), 2)
+	assert.Equal("", requests()[0].URL.Query().Get("start"))
+	assert.Equal("30", requests()[1].URL.Query().Get("start"))
+	assert.Equal(2, r.Count("1", "reaction", "smile"))
+	assert.True(r.HasReacted("2", "reaction", "heart"))
+}
+
+func TestMessageReactionsLoadError(t *testing.T) {
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	r := NewMessageReactions(pn, "ch")
+	assert.NotNil(t, r.Load(0))
+}
+
+func TestMessageReactionsToggleReaction(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			fmt.Fprint(w, `{"status":200,"data":{"type":"reaction","value":"smile","uuid":"me","actionTimetoken":"500","messageTimetoken":"100"}}`)
+		case http.MethodDelete:
+			assert.True(strings.HasSuffix(req.URL.Path, "/message/100/action/500"))
+			fmt.Fprint(w, `{"status":200,"data":{}}`)
+		}
+	})
+
+	r := NewMessageReactions(pn, "ch")
+
+	added, err := r.ToggleReaction("100", "reaction", "smile")
+	assert.Nil(err)
+	assert.True(added)
+	assert.True(r.HasReacted("100", "reaction", "smile"))
+
+	// Adding again is a no-op.
+	assert.Nil(r.AddReaction("100", "reaction", "smile"))
+
+	added, err = r.ToggleReaction("100", "reaction", "smile")
+	assert.Nil(err)
+	assert.False(added)
+	assert.False(r.HasReacted("100", "reaction", "smile"))
+
+	// Removing again is a no-op.
+	assert.Nil(r.RemoveReaction("100", "reaction", "smile"))
+
+	require.Len(t, requests(), 2)
+	assert.Equal(http.MethodPost, requests()[0].Method)
+	assert.Equal("/v1/message-actions/sub/channel/ch/message/100", requests()[0].URL.Path)
+	assert.Equal(http.MethodDelete, requests()[1].Method)
+	assert.Equal("/v1/message-actions/sub/channel/ch/message/100/action/500", requests()[1].URL.Path)
+}
+
+func TestMessageReactionsStartStop(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	r := NewMessageReactions(pn, "ch")
+
+	r.Start()
+	r.Start()
+	assert.Len(pn.GetListeners(), 1)
+
+	pn.subscriptionManager.listenerManager.announceMessageActionsEvent(&PNMessageActionsEvent{
+		Event:   PNMessageActionsAdded,
+		Channel: "ch",
+		Data: PNMessageActionsResponse{
+			ActionType:       "reaction",
+			ActionValue:      "smile",
+			MessageTimetoken: "100",
+			UUID:             "other",
+		},
+	})
+	assert.Eventually(func() bool {
+		return r.Count("100", "reaction", "smile") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	r.Stop()
+	assert.Len(pn.GetListeners(), 0)
+}
@@ -0,0 +1,187 @@
+package pubnub
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// signatureMetaKey is the Meta key of the signature of a signed message.
+const signatureMetaKey = "pn_sig"
+
+// PublicKeyRegistry returns the public keys of the message signers.
+type PublicKeyRegistry interface {
+	// PublicKey returns the public key of a signer ID, or an error when the
+	// signer is unknown.
+	PublicKey(signerID string) (ed25519.PublicKey, error)
+}
+
+// StaticPublicKeyRegistry is a PublicKeyRegistry of a fixed set of keys, by signer ID.
+type StaticPublicKeyRegistry map[string]ed25519.PublicKey
+
+// PublicKey returns the key of signerID.
+func (r StaticPublicKeyRegistry) PublicKey(signerID string) (ed25519.PublicKey, error) {
+	key, ok := r[signerID]
+	if !ok {
+		return nil, fmt.Errorf("unknown signer %s", signerID)
+	}
+	return key, nil
+}
+
+// messageSignature is the signature of a message, stored in the Meta under
+// signatureMetaKey: the signer ID, the signing time in unix milliseconds and
+// the base64 Ed25519 signature of signedMessageData.
+type messageSignature struct {
+	SignerID  string
+	Timestamp int64
+	Signature []byte
+}
+
+func (s messageSignature) toMeta() map[string]interface{} {
+	return map[string]interface{}{
+		"id":  s.SignerID,
+		"ts":  s.Timestamp,
+		"sig": base64.StdEncoding.EncodeToString(s.Signature),
+	}
+}
+
+// parseMessageSignature returns the signature in meta and the meta without it.
+func parseMessageSignature(meta interface{}) (messageSignature, interface{}, bool) {
+	var sig messageSignature
+	m, ok := meta.(map[string]interface{})
+	if !ok {
+		return sig, meta, false
+	}
+	v, ok := m[signatureMetaKey].(map[string]interface{})
+	if !ok {
+		return sig, meta, false
+	}
+
+	userMeta := make(map[string]interface{}, len(m)-1)
+	for k, val := range m {
+		if k != signatureMetaKey {
+			userMeta[k] = val
+		}
+	}
+	var res interface{} = userMeta
+	if len(userMeta) == 0 {
+		res = nil
+	}
+
+	id, _ := v["id"].(string)
+	ts, okTs := v["ts"].(float64)
+	encoded, _ := v["sig"].(string)
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if id == "" || !okTs || err != nil {
+		return sig, res, false
+	}
+	return messageSignature{SignerID: id, Timestamp: int64(ts), Signature: signature}, res, true
+}
+
+// signedMessageData returns the data signed for a message: the channel, the
+// timestamp and the message as published, which is encrypted when a crypto
+// module is used. The Meta isn't signed, the receivers must not trust it. The
+// message is canonicalized by decoding and encoding it
+// again, so that the receivers get the same bytes from the decoded payload.
+func signedMessageData(channel string, timestamp int64, message interface{}) ([]byte, error) {
+	canonical, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, len(channel)+len(canonical)+22)
+	data = append(data, channel...)
+	data = append(data, '\n')
+	data = strconv.AppendInt(data, timestamp, 10)
+	data = append(data, '\n')
+	return append(data, canonical...), nil
+}
+
+// signedMeta returns meta with the signature of the serialized message msg
+// published on channel, signed with Config.SigningKey. The Meta must be a JSON
+// object.
+func (pn *PubNub) signedMeta(channel, msg string, meta interface{}) (map[string]interface{}, error) {
+	if pn.Config.SignerID == "" {
+		return nil, errors.New("SignerID is required to sign messages")
+	}
+	if len(pn.Config.SigningKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid SigningKey")
+	}
+
+	var message interface{}
+	if err := json.Unmarshal([]byte(msg), &message); err != nil {
+		return nil, err
+	}
+	sig := messageSignature{SignerID: pn.Config.SignerID, Timestamp: time.Now().UnixMilli()}
+	data, err := signedMessageData(channel, sig.Timestamp, message)
+	if err != nil {
+		return nil, err
+	}
+	sig.Signature = ed25519.Sign(pn.Config.SigningKey, data)
+
+	res, err := metaObject(meta, "Meta must be a JSON object to publish a signed message")
+	if err != nil {
+		return nil, err
+	}
+	res[signatureMetaKey] = sig.toMeta()
+	return res, nil
+}
+
+// verifyMessage verifies the signature in meta of a message received on
+// channel, before decryption, with Config.PublicKeyRegistry. The signature is
+// rejected when it was made more than Config.SignedMessageMaxAge away from the
+// timetoken of the message, so that a signed message published again is
+// unverified. It returns the
+// meta without the signature, the signer ID when the signature is valid and
+// whether the message must be delivered according to
+// Config.UnverifiedMessagePolicy. Without a PublicKeyRegistry the meta is
+// returned as is and the message is delivered.
+func (pn *PubNub) verifyMessage(channel string, message, meta interface{}, timetoken int64) (interface{}, string, bool) {
+	registry := pn.Config.PublicKeyRegistry
+	if registry == nil {
+		return meta, "", true
+	}
+
+	maxAge := time.Duration(pn.Config.SignedMessageMaxAge) * time.Second
+	signerID, err := verifyMessageSignature(registry, channel, message, meta, timetoken, maxAge)
+	_, userMeta, _ := parseMessageSignature(meta)
+	if err == nil {
+		return userMeta, signerID, true
+	}
+
+	drop := pn.Config.UnverifiedMessagePolicy == PNDropUnverifiedMessages
+	pn.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Unverified message: channel=%s, drop=%t, reason=%v", channel, drop, err), false)
+	return userMeta, "", !drop
+}
+
+func verifyMessageSignature(registry PublicKeyRegistry, channel string, message, meta interface{}, timetoken int64, maxAge time.Duration) (string, error) {
+	sig, _, ok := parseMessageSignature(meta)
+	if !ok {
+		return "", errors.New("missing signature")
+	}
+	key, err := registry.PublicKey(sig.SignerID)
+	if err != nil {
+		return "", err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("invalid public key of signer %s", sig.SignerID)
+	}
+	data, err := signedMessageData(channel, sig.Timestamp, message)
+	if err != nil {
+		return "", err
+	}
+	if !ed25519.Verify(key, data, sig.Signature) {
+		return "", errors.New("invalid signature")
+	}
+	if maxAge > 0 {
+		// The timetoken is in 100 nanoseconds, the timestamp in milliseconds.
+		age := time.Duration(timetoken/10000-sig.Timestamp) * time.Millisecond
+		if age > maxAge || age < -maxAge {
+			return "", fmt.Errorf("signature made %v before the message", age)
+		}
+	}
+	return sig.SignerID, nil
+}
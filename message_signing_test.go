@@ -0,0 +1,226 @@
+package pubnub
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSigningKeys(t *testing.T) (ed25519.PrivateKey, StaticPublicKeyRegistry) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return private, StaticPublicKeyRegistry{"alice": public}
+}
+
+func TestPublishSignedMessageVerifiedOnSubscribe(t *testing.T) {
+	assert := assert.New(t)
+	pn, published := newChunkedPublishTestPubNub(t)
+	privateKey, registry := newSigningKeys(t)
+	pn.Config.SigningKey = privateKey
+	pn.Config.SignerID = "alice"
+	pn.Config.PublicKeyRegistry = registry
+
+	message := map[string]interface{}{"text": "hello", "n": 1.5}
+	_, _, err := pn.Publish().Channel("ch").Message(message).Meta(map[string]interface{}{"k": "v"}).UsePost(true).Execute()
+	require.NoError(t, err)
+
+	chunks := published()
+	require.Len(t, chunks, 1)
+	assert.Equal("v", chunks[0].meta["k"])
+	assert.NotNil(chunks[0].meta[signatureMetaKey])
+
+	received := subscribeChunks(t, pn, chunks)
+	require.Len(t, received, 1)
+	assert.True(received[0].Verified)
+	assert.Equal("alice", received[0].SignerID)
+	assert.Equal(message, received[0].Message)
+	assert.Equal(map[string]interface{}{"k": "v"}, received[0].UserMetadata)
+
+	// The message is flagged when it was modified.
+	chunks[0].body = `{"text":"bye","n":1.5}`
+	received = subscribeChunks(t, pn, chunks)
+	require.Len(t, received, 1)
+	assert.False(received[0].Verified)
+	assert.Empty(received[0].SignerID)
+
+	pn.Config.UnverifiedMessagePolicy = PNDropUnverifiedMessages
+	assert.Empty(subscribeChunks(t, pn, chunks))
+}
+
+func TestPublishSignedEncryptedChunkedMessage(t *testing.T) {
+	assert := assert.New(t)
+	pn, published := newChunkedPublishTestPubNub(t)
+	privateKey, registry := newSigningKeys(t)
+	pn.Config.SigningKey = privateKey
+	pn.Config.SignerID = "alice"
+	pn.Config.PublicKeyRegistry = registry
+	pn.Config.UnverifiedMessagePolicy = PNDropUnverifiedMessages
+	pn.Config.MessageChunkSize = 1024
+	module, err := crypto.NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, err)
+	pn.Config.CryptoModule = module
+
+	message := strings.Repeat("secret ", 400)
+	_, _, err = pn.Publish().Channel("ch").Message(message).Chunked(true).Execute()
+	require.NoError(t, err)
+
+	received := subscribeChunks(t, pn, published())
+	require.Len(t, received, 1)
+	assert.True(received[0].Verified)
+	assert.Equal(message, received[0].Message)
+	assert.Nil(received[0].UserMetadata)
+}
+
+func TestVerifyMessageUnsignedAndUnknownSigner(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewDemoConfig())
+	privateKey, registry := newSigningKeys(t)
+	pn.Config.SigningKey = privateKey
+	pn.Config.SignerID = "bob"
+
+	meta, err := pn.signedMeta("ch", `"hello"`, nil)
+	require.NoError(t, err)
+	var decodedMeta interface{}
+	b, _ := json.Marshal(meta)
+	require.NoError(t, json.Unmarshal(b, &decodedMeta))
+
+	now := time.Now().UnixNano() / 100
+
+	// Without a registry the meta is returned as is.
+	userMeta, signerID, deliver := pn.verifyMessage("ch", "hello", decodedMeta, now)
+	assert.Equal(decodedMeta, userMeta)
+	assert.Empty(signerID)
+	assert.True(deliver)
+
+	pn.Config.PublicKeyRegistry = registry
+	userMeta, signerID, deliver = pn.verifyMessage("ch", "hello", decodedMeta, now)
+	assert.Nil(userMeta)
+	assert.Empty(signerID)
+	assert.True(deliver)
+
+	_, signerID, deliver = pn.verifyMessage("ch", "hello", map[string]interface{}{"k": "v"}, now)
+	assert.Empty(signerID)
+	assert.True(deliver)
+
+	pn.Config.UnverifiedMessagePolicy = PNDropUnverifiedMessages
+	_, _, deliver = pn.verifyMessage("ch", "hello", nil, now)
+	assert.False(deliver)
+
+	registry["bob"] = registry["alice"]
+	_, signerID, deliver = pn.verifyMessage("other", "hello", decodedMeta, now)
+	assert.Empty(signerID)
+	assert.False(deliver)
+	_, signerID, deliver = pn.verifyMessage("ch", "hello", decodedMeta, now)
+	assert.Equal("bob", signerID)
+	assert.True(deliver)
+}
+
+func TestPublishSigningValidation(t *testing.T) {
+	pn, published := newChunkedPublishTestPubNub(t)
+	privateKey, _ := newSigningKeys(t)
+	pn.Config.SigningKey = privateKey
+
+	_, _, err := pn.Publish().Channel("ch").Message("hello").Execute()
+	assert.ErrorContains(t, err, "SignerID is required")
+
+	pn.Config.SignerID = "alice"
+	_, _, err = pn.Publish().Channel("ch").Message("hello").Meta("meta").Execute()
+	assert.ErrorContains(t, err, "Meta must be a JSON object")
+	assert.Empty(t, published())
+}
+
+func TestFetchVerifiesSignedMessages(t *testing.T) {
+	assert := assert.New(t)
+	opts := initFetchOpts("")
+	privateKey, registry := newSigningKeys(t)
+	opts.pubnub.Config.SigningKey = privateKey
+	opts.pubnub.Config.SignerID = "alice"
+	opts.pubnub.Config.PublicKeyRegistry = registry
+
+	meta, err := opts.pubnub.signedMeta("ch", `{"text":"hey"}`, map[string]interface{}{"k": "v"})
+	require.NoError(t, err)
+	metaJSON, _ := json.Marshal(meta)
+	timetoken := strconv.FormatInt(time.Now().UnixNano()/100, 10)
+	jsonString := []byte(fmt.Sprintf(`{"status":200,"error":false,"error_message":"","channels":{"ch":[
+		{"message":{"text":"hey"},"timetoken":"%s","meta":%s},
+		{"message":{"text":"changed"},"timetoken":"2","meta":%s},
+		{"message":"unsigned","timetoken":"3","meta":""}]}}`, timetoken, metaJSON, metaJSON))
+
+	resp, _, err := newFetchResponse(jsonString, opts, fakeResponseState)
+	assert.Nil(err)
+	items := resp.Messages["ch"]
+	require.Len(t, items, 3)
+	assert.True(items[0].Verified)
+	assert.Equal("alice", items[0].SignerID)
+	assert.Equal(map[string]interface{}{"k": "v"}, items[0].Meta)
+	assert.False(items[1].Verified)
+	assert.False(items[2].Verified)
+
+	opts.pubnub.Config.UnverifiedMessagePolicy = PNDropUnverifiedMessages
+	resp, _, err = newFetchResponse(jsonString, opts, fakeResponseState)
+	assert.Nil(err)
+	require.Len(t, resp.Messages["ch"], 1)
+	assert.Equal(timetoken, resp.Messages["ch"][0].Timetoken)
+}
+
+func TestVerifyMessageMaxAge(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewDemoConfig())
+	privateKey, registry := newSigningKeys(t)
+	pn.Config.SigningKey = privateKey
+	pn.Config.SignerID = "alice"
+	pn.Config.PublicKeyRegistry = registry
+	assert.Equal(300, pn.Config.SignedMessageMaxAge)
+
+	meta, err := pn.signedMeta("ch", `"hello"`, nil)
+	require.NoError(t, err)
+	var decodedMeta interface{}
+	b, _ := json.Marshal(meta)
+	require.NoError(t, json.Unmarshal(b, &decodedMeta))
+	now := time.Now().UnixNano() / 100
+	minute := int64(time.Minute / 100)
+
+	_, signerID, _ := pn.verifyMessage("ch", "hello", decodedMeta, now+minute)
+	assert.Equal("alice", signerID)
+
+	// A message published again later, or with a timestamp in the future, is unverified.
+	_, signerID, _ = pn.verifyMessage("ch", "hello", decodedMeta, now+10*minute)
+	assert.Empty(signerID)
+	_, signerID, _ = pn.verifyMessage("ch", "hello", decodedMeta, now-10*minute)
+	assert.Empty(signerID)
+
+	pn.Config.SignedMessageMaxAge = 0
+	_, signerID, _ = pn.verifyMessage("ch", "hello", decodedMeta, now+10*minute)
+	assert.Equal("alice", signerID)
+}
+
+func TestEstimatePublishSizeSigned(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newPublishSizeTestPubNub(t)
+	privateKey, _ := newSigningKeys(t)
+	pn.Config.SigningKey = privateKey
+	pn.Config.SignerID = "alice"
+	meta := map[string]interface{}{"k": "v"}
+
+	size, err := pn.EstimatePublishSize("ch", "hello", meta)
+	assert.Nil(err)
+	_, _, err = pn.Publish().Channel("ch").Message("hello").Meta(meta).Execute()
+	require.NoError(t, err)
+	require.Len(t, requests(), 1)
+	r := requests()[0]
+	assert.Contains(r.meta, signatureMetaKey)
+	assert.Equal(len("ch")+len(r.message)+len(r.meta), size)
+
+	pn.Config.SignerID = ""
+	_, err = pn.EstimatePublishSize("ch", "hello", meta)
+	assert.ErrorContains(err, "SignerID is required")
+}
@@ -0,0 +1,340 @@
+package pubnub
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics records the metrics of the client, see Config.Metrics.
+// MetricsRegistry implements it.
+type Metrics interface {
+	// RecordRequest records a request of operation with its status category,
+	// its latency, the bytes of the request path, query and body and the bytes
+	// of the response body.
+	RecordRequest(operation OperationType, category StatusCategory, latency time.Duration, bytesOut, bytesIn int)
+	// RecordReconnect records a reconnection of the subscribe loop.
+	RecordReconnect()
+	// RecordListenerQueueDepth records the number of received messages waiting
+	// to be announced to the listeners.
+	RecordListenerQueueDepth(depth int)
+	// RecordMessageReceived records a message received for subscription, the
+	// channel group or wildcard channel matched, or the channel.
+	RecordMessageReceived(subscription string)
+	// RecordDecryptFailure records a message or a file which can't be decrypted.
+	RecordDecryptFailure()
+	// RecordRateLimit records a request of a rate limited group with the tokens
+	// left in its bucket, negative while requests wait, its wait for a token, or
+	// whether it was rejected. channel is the channel of the bucket when the
+	// group is limited per channel, empty otherwise.
+	RecordRateLimit(group RateLimitGroup, channel string, tokens float64, wait time.Duration, rejected bool)
+	// RecordOriginFailover records a move of the client from an origin of
+	// Config.Origins to another.
+	RecordOriginFailover(from, to string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordRequest(operation OperationType, category StatusCategory, latency time.Duration, bytesOut, bytesIn int) {
+}
+
+func (noopMetrics) RecordReconnect() {}
+
+func (noopMetrics) RecordListenerQueueDepth(depth int) {}
+
+func (noopMetrics) RecordMessageReceived(subscription string) {}
+
+func (noopMetrics) RecordDecryptFailure() {}
+
+func (noopMetrics) RecordRateLimit(group RateLimitGroup, channel string, tokens float64, wait time.Duration, rejected bool) {
+}
+
+func (noopMetrics) RecordOriginFailover(from, to string) {}
+
+// metrics returns Config.Metrics, or no-op metrics when it isn't set.
+func (c *Config) metrics() Metrics {
+	if c.Metrics == nil {
+		return noopMetrics{}
+	}
+	return c.Metrics
+}
+
+// LatencyBuckets are the upper bounds in seconds of the buckets of the request
+// latency histograms. The last ones cover the subscribe long polls.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// RequestMetricKey identifies the requests of an operation with a status category.
+type RequestMetricKey struct {
+	Operation string
+	Category  string
+}
+
+// RequestMetrics are the metrics of the requests of a RequestMetricKey.
+type RequestMetrics struct {
+	Count int64
+	// LatencySum is the sum of the latencies in seconds.
+	LatencySum float64
+	// LatencyBuckets are the counts of the requests per bucket of LatencyBuckets,
+	// with a last bucket for the slower requests. They aren't cumulative.
+	LatencyBuckets []int64
+}
+
+// RateLimitMetrics are the metrics of the bucket of a RateLimitGroup.
+type RateLimitMetrics struct {
+	// Tokens are the tokens left after the last request, not recorded when
+	// the group is limited per channel.
+	Tokens     float64
+	PerChannel bool
+	Waits      int64
+	WaitSum    float64
+	Rejections int64
+}
+
+// MetricsSnapshot is a copy of the metrics of a MetricsRegistry.
+type MetricsSnapshot struct {
+	Requests           map[RequestMetricKey]RequestMetrics
+	Reconnects         int64
+	ListenerQueueDepth int
+	// MessagesReceived are the counts of the received messages per subscription.
+	MessagesReceived map[string]int64
+	DecryptFailures  int64
+	BytesOut         int64
+	BytesIn          int64
+	// RateLimits are the metrics of the rate limits per RateLimitGroup name.
+	RateLimits map[string]RateLimitMetrics
+	// OriginFailovers are the counts of the failovers per origin they left.
+	OriginFailovers map[string]int64
+	// ActiveOrigin is the origin of the last failover, empty before it.
+	ActiveOrigin string
+}
+
+// MetricsRegistry keeps the metrics of the client in memory and exposes them
+// in the Prometheus text format.
+type MetricsRegistry struct {
+	sync.Mutex
+	snapshot MetricsSnapshot
+}
+
+// NewMetricsRegistry returns an empty MetricsRegistry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{snapshot: MetricsSnapshot{
+		Requests:         make(map[RequestMetricKey]RequestMetrics),
+		MessagesReceived: make(map[string]int64),
+		RateLimits:       make(map[string]RateLimitMetrics),
+		OriginFailovers:  make(map[string]int64),
+	}}
+}
+
+// RecordRequest implements Metrics.
+func (r *MetricsRegistry) RecordRequest(operation OperationType, category StatusCategory, latency time.Duration, bytesOut, bytesIn int) {
+	key := RequestMetricKey{Operation: operation.String(), Category: category.String()}
+	seconds := latency.Seconds()
+	bucket := sort.SearchFloat64s(LatencyBuckets, seconds)
+
+	r.Lock()
+	defer r.Unlock()
+	m := r.snapshot.Requests[key]
+	if m.LatencyBuckets == nil {
+		m.LatencyBuckets = make([]int64, len(LatencyBuckets)+1)
+	}
+	m.Count++
+	m.LatencySum += seconds
+	m.LatencyBuckets[bucket]++
+	r.snapshot.Requests[key] = m
+	r.snapshot.BytesOut += int64(bytesOut)
+	r.snapshot.BytesIn += int64(bytesIn)
+}
+
+// RecordReconnect implements Metrics.
+func (r *MetricsRegistry) RecordReconnect() {
+	r.Lock()
+	r.snapshot.Reconnects++
+	r.Unlock()
+}
+
+// RecordListenerQueueDepth implements Metrics.
+func (r *MetricsRegistry) RecordListenerQueueDepth(depth int) {
+	r.Lock()
+	r.snapshot.ListenerQueueDepth = depth
+	r.Unlock()
+}
+
+// RecordMessageReceived implements Metrics.
+func (r *MetricsRegistry) RecordMessageReceived(subscription string) {
+	r.Lock()
+	r.snapshot.MessagesReceived[subscription]++
+	r.Unlock()
+}
+
+// RecordDecryptFailure implements Metrics.
+func (r *MetricsRegistry) RecordDecryptFailure() {
+	r.Lock()
+	r.snapshot.DecryptFailures++
+	r.Unlock()
+}
+
+// RecordRateLimit implements Metrics.
+func (r *MetricsRegistry) RecordRateLimit(group RateLimitGroup, channel string, tokens float64, wait time.Duration, rejected bool) {
+	r.Lock()
+	defer r.Unlock()
+	m := r.snapshot.RateLimits[group.String()]
+	// The buckets of the channels don't make a gauge of the group.
+	if channel != "" {
+		m.PerChannel = true
+	} else {
+		m.Tokens = tokens
+	}
+	if wait > 0 {
+		m.Waits++
+		m.WaitSum += wait.Seconds()
+	}
+	if rejected {
+		m.Rejections++
+	}
+	r.snapshot.RateLimits[group.String()] = m
+}
+
+// RecordOriginFailover implements Metrics.
+func (r *MetricsRegistry) RecordOriginFailover(from, to string) {
+	r.Lock()
+	r.snapshot.OriginFailovers[from]++
+	r.snapshot.ActiveOrigin = to
+	r.Unlock()
+}
+
+// Snapshot returns a copy of the metrics.
+func (r *MetricsRegistry) Snapshot() MetricsSnapshot {
+	r.Lock()
+	defer r.Unlock()
+	res := r.snapshot
+	res.Requests = make(map[RequestMetricKey]RequestMetrics, len(r.snapshot.Requests))
+	for k, v := range r.snapshot.Requests {
+		v.LatencyBuckets = append([]int64(nil), v.LatencyBuckets...)
+		res.Requests[k] = v
+	}
+	res.MessagesReceived = make(map[string]int64, len(r.snapshot.MessagesReceived))
+	for k, v := range r.snapshot.MessagesReceived {
+		res.MessagesReceived[k] = v
+	}
+	res.RateLimits = make(map[string]RateLimitMetrics, len(r.snapshot.RateLimits))
+	for k, v := range r.snapshot.RateLimits {
+		res.RateLimits[k] = v
+	}
+	res.OriginFailovers = make(map[string]int64, len(r.snapshot.OriginFailovers))
+	for k, v := range r.snapshot.OriginFailovers {
+		res.OriginFailovers[k] = v
+	}
+	return res
+}
+
+// Handler returns an http.Handler serving the metrics in the Prometheus text format.
+func (r *MetricsRegistry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format.
+func (r *MetricsRegistry) WritePrometheus(w io.Writer) error {
+	s := r.Snapshot()
+	var b strings.Builder
+
+	keys := make([]RequestMetricKey, 0, len(s.Requests))
+	for k := range s.Requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Operation != keys[j].Operation {
+			return keys[i].Operation < keys[j].Operation
+		}
+		return keys[i].Category < keys[j].Category
+	})
+
+	writePrometheusHeader(&b, "pubnub_requests_total", "counter", "Requests per operation and status category.")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "pubnub_requests_total{%s} %d\n", requestLabels(k), s.Requests[k].Count)
+	}
+	writePrometheusHeader(&b, "pubnub_request_duration_seconds", "histogram", "Request latency per operation and status category.")
+	for _, k := range keys {
+		m := s.Requests[k]
+		labels := requestLabels(k)
+		var cumulative int64
+		for i, bound := range LatencyBuckets {
+			cumulative += m.LatencyBuckets[i]
+			fmt.Fprintf(&b, "pubnub_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "pubnub_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, m.Count)
+		fmt.Fprintf(&b, "pubnub_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(m.LatencySum, 'g', -1, 64))
+		fmt.Fprintf(&b, "pubnub_request_duration_seconds_count{%s} %d\n", labels, m.Count)
+	}
+
+	writePrometheusHeader(&b, "pubnub_subscribe_reconnects_total", "counter", "Reconnections of the subscribe loop.")
+	fmt.Fprintf(&b, "pubnub_subscribe_reconnects_total %d\n", s.Reconnects)
+	writePrometheusHeader(&b, "pubnub_listener_queue_depth", "gauge", "Received messages waiting to be announced to the listeners.")
+	fmt.Fprintf(&b, "pubnub_listener_queue_depth %d\n", s.ListenerQueueDepth)
+
+	writePrometheusHeader(&b, "pubnub_messages_received_total", "counter", "Received messages per subscription.")
+	for _, sub := range sortedKeys(s.MessagesReceived) {
+		fmt.Fprintf(&b, "pubnub_messages_received_total{subscription=\"%s\"} %d\n", escapeLabelValue(sub), s.MessagesReceived[sub])
+	}
+
+	writePrometheusHeader(&b, "pubnub_decrypt_failures_total", "counter", "Messages and files which can't be decrypted.")
+	fmt.Fprintf(&b, "pubnub_decrypt_failures_total %d\n", s.DecryptFailures)
+	writePrometheusHeader(&b, "pubnub_bytes_sent_total", "counter", "Bytes of the request paths, queries and bodies.")
+	fmt.Fprintf(&b, "pubnub_bytes_sent_total %d\n", s.BytesOut)
+	writePrometheusHeader(&b, "pubnub_bytes_received_total", "counter", "Bytes of the response bodies.")
+	fmt.Fprintf(&b, "pubnub_bytes_received_total %d\n", s.BytesIn)
+
+	groups := sortedKeys(s.RateLimits)
+	writePrometheusHeader(&b, "pubnub_rate_limit_tokens", "gauge", "Tokens left in the rate limit buckets, negative while requests wait.")
+	for _, g := range groups {
+		if s.RateLimits[g].PerChannel {
+			continue
+		}
+		fmt.Fprintf(&b, "pubnub_rate_limit_tokens{group=\"%s\"} %s\n", escapeLabelValue(g), strconv.FormatFloat(s.RateLimits[g].Tokens, 'g', -1, 64))
+	}
+	writePrometheusHeader(&b, "pubnub_rate_limit_waits_total", "counter", "Requests which waited for a rate limit token.")
+	for _, g := range groups {
+		fmt.Fprintf(&b, "pubnub_rate_limit_waits_total{group=\"%s\"} %d\n", escapeLabelValue(g), s.RateLimits[g].Waits)
+	}
+	writePrometheusHeader(&b, "pubnub_rate_limit_wait_seconds_total", "counter", "Time waited for the rate limit tokens.")
+	for _, g := range groups {
+		fmt.Fprintf(&b, "pubnub_rate_limit_wait_seconds_total{group=\"%s\"} %s\n", escapeLabelValue(g), strconv.FormatFloat(s.RateLimits[g].WaitSum, 'g', -1, 64))
+	}
+	writePrometheusHeader(&b, "pubnub_rate_limit_rejections_total", "counter", "Requests rejected by a rate limit.")
+	for _, g := range groups {
+		fmt.Fprintf(&b, "pubnub_rate_limit_rejections_total{group=\"%s\"} %d\n", escapeLabelValue(g), s.RateLimits[g].Rejections)
+	}
+
+	writePrometheusHeader(&b, "pubnub_origin_failovers_total", "counter", "Failovers per origin left.")
+	for _, origin := range sortedKeys(s.OriginFailovers) {
+		fmt.Fprintf(&b, "pubnub_origin_failovers_total{origin=\"%s\"} %d\n", escapeLabelValue(origin), s.OriginFailovers[origin])
+	}
+	if s.ActiveOrigin != "" {
+		writePrometheusHeader(&b, "pubnub_active_origin", "gauge", "Origin of the requests after the last failover.")
+		fmt.Fprintf(&b, "pubnub_active_origin{origin=\"%s\"} 1\n", escapeLabelValue(s.ActiveOrigin))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePrometheusHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func requestLabels(k RequestMetricKey) string {
+	return fmt.Sprintf("operation=\"%s\",category=\"%s\"", escapeLabelValue(k.Operation), escapeLabelValue(k.Category))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
@@ -0,0 +1,109 @@
+package pubnub
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRegistryRequests(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `[1,"Sent","15000000000000001"]`)
+	})
+	registry := NewMetricsRegistry()
+	pn.Config.Metrics = registry
+
+	_, _, err := pn.Publish().Channel("ch").Message("hi").UsePost(true).Execute()
+	require.NoError(t, err)
+	_, _, err = pn.Signal().Channel("ch").Message("hi").Execute()
+	require.NoError(t, err)
+
+	failing, failed := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":400,"error":true}`)
+	})
+	failing.Config.Metrics = registry
+	_, _, err = failing.Time().Execute()
+	require.Error(t, err)
+
+	// The bytes sent include the path and the query, of the GET signal too.
+	var bytesOut int
+	for _, req := range append(requests(), failed()...) {
+		bytesOut += len(req.RequestURI) + len(req.body)
+	}
+
+	s := registry.Snapshot()
+	publish := s.Requests[RequestMetricKey{Operation: "Publish", Category: "Acknowledgment"}]
+	assert.Equal(int64(1), publish.Count)
+	assert.Len(publish.LatencyBuckets, len(LatencyBuckets)+1)
+	var total int64
+	for _, c := range publish.LatencyBuckets {
+		total += c
+	}
+	assert.Equal(int64(1), total)
+	assert.Equal(int64(1), s.Requests[RequestMetricKey{Operation: "Time", Category: "Bad Request"}].Count)
+	assert.Equal(int64(bytesOut), s.BytesOut)
+	assert.Equal(int64(2*len(`[1,"Sent","15000000000000001"]`)), s.BytesIn)
+
+	// The snapshot is a copy.
+	publish.LatencyBuckets[0] = 42
+	assert.NotEqual(int64(42), registry.Snapshot().Requests[RequestMetricKey{Operation: "Publish", Category: "Acknowledgment"}].LatencyBuckets[0])
+}
+
+func TestMetricsRegistrySubscribe(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	defer pn.Destroy()
+	registry := NewMetricsRegistry()
+	pn.Config.Metrics = registry
+	module, err := crypto.NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, err)
+	pn.Config.CryptoModule = module
+
+	subscribeChunks(t, pn, []publishedChunk{{body: `"bm90IGVuY3J5cHRlZA=="`}, {body: `"bm90IGVuY3J5cHRlZA=="`}})
+
+	s := registry.Snapshot()
+	assert.Equal(map[string]int64{"ch": 2}, s.MessagesReceived)
+	assert.Equal(int64(2), s.DecryptFailures)
+}
+
+func TestMetricsRegistryPrometheus(t *testing.T) {
+	assert := assert.New(t)
+	registry := NewMetricsRegistry()
+	registry.RecordRequest(PNPublishOperation, PNAcknowledgmentCategory, 30*time.Millisecond, 10, 20)
+	registry.RecordRequest(PNPublishOperation, PNAcknowledgmentCategory, 2*time.Second, 10, 20)
+	registry.RecordReconnect()
+	registry.RecordListenerQueueDepth(7)
+	registry.RecordMessageReceived(`cg"1`)
+	registry.RecordDecryptFailure()
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(rec.Header().Get("Content-Type"), "text/plain")
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE pubnub_requests_total counter",
+		`pubnub_requests_total{operation="Publish",category="Acknowledgment"} 2`,
+		`pubnub_request_duration_seconds_bucket{operation="Publish",category="Acknowledgment",le="0.025"} 0`,
+		`pubnub_request_duration_seconds_bucket{operation="Publish",category="Acknowledgment",le="0.05"} 1`,
+		`pubnub_request_duration_seconds_bucket{operation="Publish",category="Acknowledgment",le="2.5"} 2`,
+		`pubnub_request_duration_seconds_bucket{operation="Publish",category="Acknowledgment",le="+Inf"} 2`,
+		`pubnub_request_duration_seconds_sum{operation="Publish",category="Acknowledgment"} 2.03`,
+		"pubnub_subscribe_reconnects_total 1",
+		"pubnub_listener_queue_depth 7",
+		`pubnub_messages_received_total{subscription="cg\"1"} 1`,
+		"pubnub_decrypt_failures_total 1",
+		"pubnub_bytes_sent_total 20",
+		"pubnub_bytes_received_total 40",
+	} {
+		assert.True(strings.Contains(body, line+"\n"), line)
+	}
+}
@@ -52,6 +52,13 @@ func (b *getChannelMetadataBuilder) QueryParam(queryParam map[string]string) *ge
 	return b
 }
 
+// NoCache bypasses Config.RequestCache: the request is neither coalesced nor served from the cache.
+func (b *getChannelMetadataBuilder) NoCache() *getChannelMetadataBuilder {
+	b.opts.NoCache = true
+
+	return b
+}
+
 // Transport sets the Transport for the getChannelMetadata request.
 func (b *getChannelMetadataBuilder) Transport(tr http.RoundTripper) *getChannelMetadataBuilder {
 	b.opts.Transport = tr
@@ -84,6 +91,7 @@ type getChannelMetadataOpts struct {
 	Channel    string
 	Include    []string
 	QueryParam map[string]string
+	NoCache    bool
 
 	Transport http.RoundTripper
 }
@@ -133,6 +141,14 @@ func (o *getChannelMetadataOpts) connectTimeout() int {
 	return o.pubnub.Config.ConnectTimeout
 }
 
+func (o *getChannelMetadataOpts) cacheTags() []string {
+	return []string{channelMetadataCacheTag(o.Channel)}
+}
+
+func (o *getChannelMetadataOpts) bypassCache() bool {
+	return o.NoCache
+}
+
 func (o *getChannelMetadataOpts) operationType() OperationType {
 	return PNGetChannelMetadataOperation
 }
@@ -52,6 +52,13 @@ func (b *getUUIDMetadataBuilder) QueryParam(queryParam map[string]string) *getUU
 	return b
 }
 
+// NoCache bypasses Config.RequestCache: the request is neither coalesced nor served from the cache.
+func (b *getUUIDMetadataBuilder) NoCache() *getUUIDMetadataBuilder {
+	b.opts.NoCache = true
+
+	return b
+}
+
 // Transport sets the Transport for the getUUIDMetadata request.
 func (b *getUUIDMetadataBuilder) Transport(tr http.RoundTripper) *getUUIDMetadataBuilder {
 	b.opts.Transport = tr
@@ -90,6 +97,7 @@ type getUUIDMetadataOpts struct {
 	UUID       string
 	Include    []string
 	QueryParam map[string]string
+	NoCache    bool
 
 	Transport http.RoundTripper
 }
@@ -136,6 +144,14 @@ func (o *getUUIDMetadataOpts) httpMethod() string {
 	return "GET"
 }
 
+func (o *getUUIDMetadataOpts) cacheTags() []string {
+	return []string{uuidMetadataCacheTag(o.UUID)}
+}
+
+func (o *getUUIDMetadataOpts) bypassCache() bool {
+	return o.NoCache
+}
+
 func (o *getUUIDMetadataOpts) operationType() OperationType {
 	return PNGetUUIDMetadataOperation
 }
@@ -0,0 +1,210 @@
+package pubnub
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pubnub/go/v9/pnerr"
+)
+
+// originRetryInterval is the time an origin is skipped after a failure.
+const originRetryInterval = 30 * time.Second
+
+// OriginFailover is the AdditionalData of the PNStatus announced when the
+// client moves from an origin of Config.Origins to another.
+type OriginFailover struct {
+	From string
+	To   string
+}
+
+// originSelector tracks the health of Config.Origins and selects the active one.
+type originSelector struct {
+	sync.Mutex
+	active string
+	// unhealthyUntil are the origins skipped after a failure.
+	unhealthyUntil map[string]time.Time
+}
+
+func newOriginSelector() *originSelector {
+	return &originSelector{unhealthyUntil: make(map[string]time.Time)}
+}
+
+// current returns the active origin, Config.Origin when Config.Origins is
+// empty. The active origin moves back to the first healthy origin of
+// Config.Origins once the ones before it are retried.
+func (s *originSelector) current(pn *PubNub) string {
+	cfg := pn.Config
+	if s == nil || len(cfg.Origins) == 0 {
+		return cfg.Origin
+	}
+	s.Lock()
+	from := s.active
+	i := indexOf(cfg.Origins, from)
+	if i < 0 {
+		s.active = cfg.Origins[0]
+		s.Unlock()
+		return cfg.Origins[0]
+	}
+	now := time.Now()
+	for _, origin := range cfg.Origins[:i] {
+		if !now.Before(s.unhealthyUntil[origin]) {
+			s.active = origin
+			break
+		}
+	}
+	to := s.active
+	s.Unlock()
+
+	if from != to {
+		pn.loggerManager.LogSimple(PNLogLevelInfo, fmt.Sprintf("Origin recovery: %s -> %s", from, to), false)
+		s.announce(pn, from, to, &PNStatus{})
+	}
+	return to
+}
+
+// succeeded marks origin as healthy.
+func (s *originSelector) succeeded(origin string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	delete(s.unhealthyUntil, origin)
+	s.Unlock()
+}
+
+// failed marks origin as unhealthy after err, and moves the active origin to
+// the next healthy one when origin is the active one.
+func (s *originSelector) failed(pn *PubNub, origin string, operation OperationType, err error) {
+	cfg := pn.Config
+	if s == nil || len(cfg.Origins) < 2 {
+		return
+	}
+	s.Lock()
+	now := time.Now()
+	s.unhealthyUntil[origin] = now.Add(originRetryInterval)
+	if origin != s.active {
+		s.Unlock()
+		return
+	}
+	from := s.active
+	s.active = s.next(cfg.Origins, from, now)
+	to := s.active
+	s.Unlock()
+
+	pn.loggerManager.LogSimple(PNLogLevelWarn, fmt.Sprintf("Origin failover: %s -> %s after %s failed: %v", from, to, operation, err), false)
+	s.announce(pn, from, to, &PNStatus{Operation: operation, Error: true, ErrorData: err})
+}
+
+// announce records the move of the active origin and announces status with it.
+func (s *originSelector) announce(pn *PubNub, from, to string, status *PNStatus) {
+	pn.Config.metrics().RecordOriginFailover(from, to)
+	status.Category = PNOriginFailoverCategory
+	status.Origin = to
+	status.AdditionalData = OriginFailover{From: from, To: to}
+	pn.subscriptionManager.listenerManager.announceStatus(status)
+}
+
+// next returns the first healthy origin after from, or the next one when
+// they are all unhealthy.
+func (s *originSelector) next(origins []string, from string, now time.Time) string {
+	i := indexOf(origins, from)
+	for j := 1; j < len(origins); j++ {
+		origin := origins[(i+j)%len(origins)]
+		if !now.Before(s.unhealthyUntil[origin]) {
+			return origin
+		}
+	}
+	return origins[(i+1)%len(origins)]
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// ActiveOrigin returns the origin of the requests, the active one of
+// Config.Origins when it is set.
+func (pn *PubNub) ActiveOrigin() string {
+	return pn.originSelector.current(pn)
+}
+
+// isIdempotentOperation checks whether the request of op can be sent again
+// when it could have been delivered, the messages and the grants can't.
+func isIdempotentOperation(op OperationType) bool {
+	switch op {
+	case PNPublishOperation, PNSignalOperation, PNFireOperation, PNPublishFileMessageOperation,
+		PNAddMessageActionsOperation, PNAccessManagerGrant, PNAccessManagerGrantToken:
+		return false
+	}
+	return true
+}
+
+// isDialError checks whether err is a failure to connect to the origin, the
+// request wasn't sent then.
+func isDialError(err error) bool {
+	var connErr *pnerr.ConnectionError
+	if !errors.As(err, &connErr) {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(connErr.OrigError, &opErr) && opErr.Op == "dial"
+}
+
+// executeRequestWithFailover runs the request of opts on the active origin,
+// and on the next ones of Config.Origins while it fails with a connection
+// error or a 5xx, or their circuit breaker is open. The requests which can't
+// be sent twice only move on when they fail to connect. The subscribe loop
+// moves to the next origin on reconnect instead.
+func executeRequestWithFailover(opts endpoint, obs *requestObserver, attempt int) ([]byte, StatusResponse, error) {
+	pn := opts.getPubNub()
+	cfg := opts.config()
+	op := opts.operationType()
+	if len(cfg.Origins) < 2 || op == PNSubscribeOperation || op == PNSendFileToS3Operation {
+		return executeRequestWithCircuitBreaker(opts, obs, attempt)
+	}
+
+	var val []byte
+	var status StatusResponse
+	var err error
+	origin := pn.originSelector.current(pn)
+	for i := 0; i < len(cfg.Origins); i++ {
+		opts.setOrigin(origin)
+		val, status, err = executeRequestWithCircuitBreaker(opts, obs, attempt)
+		if ctx := opts.context(); ctx != nil && ctx.Err() != nil {
+			return val, status, err
+		}
+		var openErr *CircuitOpenError
+		if !errors.As(err, &openErr) {
+			if !isEndpointFailure(status, err) {
+				pn.originSelector.succeeded(origin)
+				return val, status, err
+			}
+			pn.originSelector.failed(pn, origin, op, err)
+			if !isIdempotentOperation(op) && !isDialError(err) {
+				return val, status, err
+			}
+		}
+		origin = cfg.Origins[(indexOf(cfg.Origins, origin)+1)%len(cfg.Origins)]
+	}
+	return val, status, err
+}
+
+// executeRequestWithCircuitBreaker runs the request of opts through the
+// circuit breaker of its origin.
+func executeRequestWithCircuitBreaker(opts endpoint, obs *requestObserver, attempt int) ([]byte, StatusResponse, error) {
+	cb := opts.getPubNub().circuitBreaker
+	c, status, err := cb.allow(opts)
+	if err != nil {
+		return nil, status, err
+	}
+	val, status, err := executeRequestOnce(opts, obs, attempt)
+	cb.done(c, opts, status, err)
+	return val, status, err
+}
@@ -0,0 +1,244 @@
+package pubnub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOriginsTestPubNub(origins ...string) (*PubNub, *Listener) {
+	config := NewConfigWithUserId(UserId("me"))
+	config.PublishKey = "pub"
+	config.SubscribeKey = "sub"
+	config.Secure = false
+	config.UseHTTP2 = false
+	config.MaxWorkers = 0
+	config.Origins = origins
+	pn := NewPubNub(config)
+	listener := NewListener()
+	pn.AddListener(listener)
+	return pn, listener
+}
+
+func waitOriginFailover(t *testing.T, listener *Listener) *PNStatus {
+	for {
+		select {
+		case status := <-listener.Status:
+			if status.Category == PNOriginFailoverCategory {
+				return status
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no origin failover")
+			return nil
+		}
+	}
+}
+
+func TestOriginsFailoverOnServerError(t *testing.T) {
+	assert := assert.New(t)
+	bad, badRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	good, goodRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn, listener := newOriginsTestPubNub(bad, good)
+	registry := NewMetricsRegistry()
+	pn.Config.Metrics = registry
+	assert.Equal(bad, pn.ActiveOrigin())
+
+	res, status, err := pn.Time().Execute()
+	require.NoError(t, err)
+	assert.Equal(int64(15000000000000000), res.Timetoken)
+	assert.Equal(good, status.Origin)
+	assert.Equal(good, pn.ActiveOrigin())
+
+	failover := waitOriginFailover(t, listener)
+	assert.Equal(PNTimeOperation, failover.Operation)
+	assert.Equal(good, failover.Origin)
+	assert.Equal(OriginFailover{From: bad, To: good}, failover.AdditionalData)
+	assert.Equal(int64(1), registry.Snapshot().OriginFailovers[bad])
+	assert.Equal(good, registry.Snapshot().ActiveOrigin)
+
+	// The next requests go to the active origin.
+	_, _, err = pn.Time().Execute()
+	assert.NoError(err)
+	assert.Len(badRequests(), 1)
+	assert.Len(goodRequests(), 2)
+}
+
+func TestOriginsFailoverOnConnectionError(t *testing.T) {
+	assert := assert.New(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL, _ := url.Parse(closed.URL)
+	closed.Close()
+	good, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn, _ := newOriginsTestPubNub(closedURL.Host, good)
+
+	_, _, err := pn.Time().Execute()
+	assert.NoError(err)
+	assert.Equal(good, pn.ActiveOrigin())
+}
+
+func TestOriginsNoFailoverOnClientError(t *testing.T) {
+	assert := assert.New(t)
+	bad, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	good, goodRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn, _ := newOriginsTestPubNub(bad, good)
+
+	_, status, err := pn.Time().Execute()
+	assert.NotNil(err)
+	assert.Equal(PNBadRequestCategory, status.Category)
+	assert.Equal(bad, pn.ActiveOrigin())
+	assert.Len(goodRequests(), 0)
+}
+
+func TestOriginsSubscribeMovesOnReconnect(t *testing.T) {
+	assert := assert.New(t)
+	// The first origin answers the handshake and fails the next long poll.
+	first, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if tt := r.URL.Query().Get("tt"); strings.Contains(r.URL.Path, "/v2/subscribe/") && (tt == "" || tt == "0") {
+			w.Write([]byte(`{"t":{"t":"15000000000000000","r":12},"m":[]}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	queries := make(chan url.Values, 10)
+	done := make(chan struct{})
+	second, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/v2/subscribe/") {
+			w.Write([]byte(`{"status":200,"message":"OK","service":"Presence"}`))
+			return
+		}
+		queries <- r.URL.Query()
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	})
+	// The cleanups run in reverse order, so the long poll ends before the server closes.
+	t.Cleanup(func() { close(done) })
+	pn, listener := newOriginsTestPubNub(first, second)
+	defer pn.UnsubscribeAll()
+
+	pn.Subscribe().Channels([]string{"ch"}).Execute()
+	failover := waitOriginFailover(t, listener)
+	assert.Equal(PNSubscribeOperation, failover.Operation)
+	assert.Equal(second, pn.ActiveOrigin())
+
+	// The reconnect resumes from the last timetoken on the next origin.
+	pn.subscriptionManager.reconnect()
+	select {
+	case query := <-queries:
+		assert.Equal("15000000000000000", query.Get("tt"))
+		assert.Equal("12", query.Get("tr"))
+	case <-time.After(2 * time.Second):
+		t.Fatal("the subscribe loop didn't move to the next origin")
+	}
+}
+
+func TestOriginsRecoverPreferredOrigin(t *testing.T) {
+	assert := assert.New(t)
+	var failing int32 = 1
+	preferred, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	backup, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn, listener := newOriginsTestPubNub(preferred, backup)
+
+	_, _, err := pn.Time().Execute()
+	require.NoError(t, err)
+	waitOriginFailover(t, listener)
+	assert.Equal(backup, pn.ActiveOrigin())
+
+	// The preferred origin is retried after originRetryInterval.
+	atomic.StoreInt32(&failing, 0)
+	pn.originSelector.Lock()
+	pn.originSelector.unhealthyUntil[preferred] = time.Now().Add(-time.Second)
+	pn.originSelector.Unlock()
+	_, status, err := pn.Time().Execute()
+	require.NoError(t, err)
+	assert.Equal(preferred, status.Origin)
+	assert.Equal(preferred, pn.ActiveOrigin())
+
+	recovery := waitOriginFailover(t, listener)
+	assert.False(recovery.Error)
+	assert.Equal(OriginFailover{From: backup, To: preferred}, recovery.AdditionalData)
+}
+
+func TestOriginsPublishFailoverOnlyWhenNotSent(t *testing.T) {
+	assert := assert.New(t)
+	bad, badRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	good, goodRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[1,"Sent","15000000000000000"]`))
+	})
+	pn, _ := newOriginsTestPubNub(bad, good)
+
+	// The message can have been delivered by the failed origin.
+	_, status, err := pn.Publish().Channel("ch").Message("hi").Execute()
+	assert.NotNil(err)
+	assert.Equal(http.StatusBadGateway, status.StatusCode)
+	assert.Len(badRequests(), 1)
+	assert.Len(goodRequests(), 0)
+	assert.Equal(good, pn.ActiveOrigin())
+
+	// A publish failing to connect moves to the next origin.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL, _ := url.Parse(closed.URL)
+	closed.Close()
+	pn, _ = newOriginsTestPubNub(closedURL.Host, good)
+	_, _, err = pn.Publish().Channel("ch").Message("hi").Execute()
+	assert.NoError(err)
+	assert.Len(goodRequests(), 1)
+}
+
+func TestOriginsCircuitBreakerPerOrigin(t *testing.T) {
+	assert := assert.New(t)
+	bad, badRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	good, goodRequests := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[15000000000000000]`))
+	})
+	pn, _ := newOriginsTestPubNub(bad, good)
+	pn.Config.CircuitBreaker = &CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Minute}
+
+	_, _, err := pn.Time().Execute()
+	require.NoError(t, err)
+	cb := pn.circuitBreaker
+	cb.Lock()
+	assert.Equal(CircuitOpen, cb.circuits[circuitKey{group: "time", origin: bad}].state)
+	assert.Equal(CircuitClosed, cb.circuits[circuitKey{group: "time", origin: good}].state)
+	cb.Unlock()
+
+	// The open circuit of the preferred origin moves the request to the next one.
+	pn.originSelector.Lock()
+	delete(pn.originSelector.unhealthyUntil, bad)
+	pn.originSelector.Unlock()
+	_, status, err := pn.Time().Execute()
+	require.NoError(t, err)
+	assert.Equal(good, status.Origin)
+	assert.Len(badRequests(), 1)
+	assert.Len(goodRequests(), 2)
+}
@@ -0,0 +1,31 @@
+module github.com/pubnub/go/v9/pnotel
+
+go 1.25.0
+
+require (
+	github.com/pubnub/go/v9 v9.1.0
+	github.com/stretchr/testify v1.11.1
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/sdk v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/brianolson/cbor_go v1.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/net v0.55.0 // indirect
+	golang.org/x/sys v0.45.0 // indirect
+	golang.org/x/text v0.37.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// v9.1.0 is the first SDK release with Config.Tracer. The adapter is built
+// against the SDK of this repository until it is tagged: pnotel must only be
+// tagged after v9.1.0, without this replace.
+replace github.com/pubnub/go/v9 => ../
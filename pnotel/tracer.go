@@ -0,0 +1,102 @@
+// Package pnotel adapts OpenTelemetry to the tracing hooks of the PubNub client.
+// It is a module of its own, so that the SDK doesn't depend on OpenTelemetry,
+// and requires the SDK v9.1.0 or later.
+//
+//	config.Tracer = pnotel.NewTracer(nil, nil)
+//	config.PropagateTraceContext = true
+package pnotel
+
+import (
+	"context"
+	"fmt"
+
+	pubnub "github.com/pubnub/go/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer of the client.
+const instrumentationName = "github.com/pubnub/go/v9"
+
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer returns a pubnub.Tracer starting the spans with t and propagating
+// the trace context with propagator. The global tracer provider and propagator
+// are used when they are nil.
+func NewTracer(t trace.Tracer, propagator propagation.TextMapPropagator) pubnub.Tracer {
+	if t == nil {
+		t = otel.Tracer(instrumentationName)
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &tracer{tracer: t, propagator: propagator}
+}
+
+func (t *tracer) Start(ctx pubnub.Context, name string, attrs ...pubnub.SpanAttribute) (pubnub.Context, pubnub.Span) {
+	kind := trace.SpanKindClient
+	if name == pubnub.SpanNameReceiveMessage {
+		kind = trace.SpanKindConsumer
+	}
+	spanCtx, span := t.tracer.Start(contextOf(ctx), name, trace.WithSpanKind(kind), trace.WithAttributes(convertAttributes(attrs)...))
+	return spanCtx, &spanAdapter{span}
+}
+
+func (t *tracer) Inject(ctx pubnub.Context, carrier map[string]string) {
+	t.propagator.Inject(contextOf(ctx), propagation.MapCarrier(carrier))
+}
+
+func (t *tracer) Extract(ctx pubnub.Context, carrier map[string]string) pubnub.Context {
+	return t.propagator.Extract(contextOf(ctx), propagation.MapCarrier(carrier))
+}
+
+type spanAdapter struct {
+	span trace.Span
+}
+
+func (s *spanAdapter) SetAttributes(attrs ...pubnub.SpanAttribute) {
+	s.span.SetAttributes(convertAttributes(attrs)...)
+}
+
+func (s *spanAdapter) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *spanAdapter) End() {
+	s.span.End()
+}
+
+func convertAttributes(attrs []pubnub.SpanAttribute) []attribute.KeyValue {
+	res := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			res = append(res, attribute.String(a.Key, v))
+		case bool:
+			res = append(res, attribute.Bool(a.Key, v))
+		case int:
+			res = append(res, attribute.Int(a.Key, v))
+		case int64:
+			res = append(res, attribute.Int64(a.Key, v))
+		case float64:
+			res = append(res, attribute.Float64(a.Key, v))
+		default:
+			res = append(res, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+	return res
+}
+
+func contextOf(ctx pubnub.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
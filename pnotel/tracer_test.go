@@ -0,0 +1,66 @@
+package pnotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pubnub "github.com/pubnub/go/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracer() (pubnub.Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return NewTracer(provider.Tracer("test"), propagation.TraceContext{}), recorder
+}
+
+func TestTracerSpans(t *testing.T) {
+	assert := assert.New(t)
+	tracer, recorder := newTestTracer()
+
+	_, span := tracer.Start(context.Background(), "Publish",
+		pubnub.SpanAttribute{Key: pubnub.SpanAttrChannelCount, Value: 2},
+		pubnub.SpanAttribute{Key: pubnub.SpanAttrOperation, Value: "Publish"})
+	span.SetAttributes(pubnub.SpanAttribute{Key: pubnub.SpanAttrStatusCode, Value: 403})
+	span.RecordError(errors.New("forbidden"))
+	span.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal("Publish", ended[0].Name())
+	assert.Equal(trace.SpanKindClient, ended[0].SpanKind())
+	assert.Equal(codes.Error, ended[0].Status().Code)
+	assert.ElementsMatch([]attribute.KeyValue{
+		attribute.Int(pubnub.SpanAttrChannelCount, 2),
+		attribute.String(pubnub.SpanAttrOperation, "Publish"),
+		attribute.Int(pubnub.SpanAttrStatusCode, 403),
+	}, ended[0].Attributes())
+}
+
+func TestTracerPropagation(t *testing.T) {
+	assert := assert.New(t)
+	tracer, recorder := newTestTracer()
+
+	ctx, span := tracer.Start(nil, "Publish")
+	carrier := make(map[string]string)
+	tracer.Inject(ctx, carrier)
+	span.End()
+	assert.NotEmpty(carrier["traceparent"])
+
+	_, receive := tracer.Start(tracer.Extract(context.Background(), carrier), pubnub.SpanNameReceiveMessage)
+	receive.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(trace.SpanKindConsumer, ended[1].SpanKind())
+	assert.Equal(ended[0].SpanContext().TraceID(), ended[1].SpanContext().TraceID())
+	assert.Equal(ended[0].SpanContext().SpanID(), ended[1].Parent().SpanID())
+}
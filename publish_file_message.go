@@ -331,7 +331,7 @@ func (o *publishFileMessageOpts) buildPath() (string, error) {
 		messageToProcess = o.Message
 	}
 
-	if o.pubnub.getCryptoModule() != nil {
+	if o.pubnub.getChannelCryptoModule(o.Channel) != nil {
 		var msg string
 		var p *publishBuilder
 		if o.context() != nil {
@@ -340,6 +340,7 @@ func (o *publishFileMessageOpts) buildPath() (string, error) {
 			p = newPublishBuilder(o.pubnub)
 		}
 
+		p.opts.Channel = o.Channel
 		p.opts.Message = messageToProcess
 		msg, errJSONMarshal := p.opts.encryptProcessing()
 		if errJSONMarshal != nil {
@@ -441,6 +442,14 @@ func (o *publishFileMessageOpts) connectTimeout() int {
 	return o.pubnub.Config.ConnectTimeout
 }
 
+func (o *publishFileMessageOpts) channelName() string {
+	return o.Channel
+}
+
+func (o *publishFileMessageOpts) message() interface{} {
+	return o.Message
+}
+
 func (o *publishFileMessageOpts) operationType() OperationType {
 	return PNPublishFileMessageOperation
 }
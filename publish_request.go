@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strconv"
 
+	"github.com/pubnub/go/v9/crypto"
 	"github.com/pubnub/go/v9/pnerr"
 	"github.com/pubnub/go/v9/utils"
 
@@ -18,6 +19,12 @@ import (
 const publishGetPath = "/publish/%s/%s/0/%s/%s/%s"
 const publishPostPath = "/publish/%s/%s/0/%s/%s"
 
+// maxPublishMessageSize is the server limit on the size of a published message, see EstimatePublishSize.
+const maxPublishMessageSize = 32768
+
+// maxPublishGetMessageSize is the max size of a message sent with GET, larger messages are sent with POST.
+const maxPublishGetMessageSize = 8192
+
 var emptyPublishResponse *PublishResponse
 
 type publishOpts struct {
@@ -35,12 +42,24 @@ type publishOpts struct {
 	QueryParam     map[string]string
 
 	CustomMessageType string
+	Chunked           bool
+	Recipients        []string
 
 	Transport http.RoundTripper
 
 	// nil hacks
 	setTTL         bool
 	setShouldStore bool
+
+	// the message is a chunk of an already encrypted message
+	skipEncryption bool
+
+	// message as sent, serialized and encrypted once per Execute
+	serialized    string
+	setSerialized bool
+
+	// sealed box module of the Recipients, resolved once per Execute
+	sealedBox crypto.CryptoModule
 }
 
 // PublishResponse is the response after the execution on Publish and Fire operations.
@@ -178,6 +197,26 @@ func (b *publishBuilder) CustomMessageType(messageType string) *publishBuilder {
 	return b
 }
 
+// Chunked splits a message larger than Config.MessageChunkSize into several
+// publishes. Subscribers and Fetch with IncludeMeta reassemble the chunks into
+// one message. The Meta must be a JSON object.
+func (b *publishBuilder) Chunked(chunked bool) *publishBuilder {
+	b.opts.Chunked = chunked
+
+	return b
+}
+
+// Recipients encrypts the message as a sealed box which only the recipient
+// UUIDs, and this client, can decrypt. The public keys of the recipients are
+// the keys of Config.SealedBoxPublicKeys or read from their App Context
+// metadata, see PublishSealedBoxKey and GetSealedBoxKey. It requires
+// Config.SealedBoxKey.
+func (b *publishBuilder) Recipients(uuids ...string) *publishBuilder {
+	b.opts.Recipients = uuids
+
+	return b
+}
+
 // GetLogParams returns the user-provided parameters for logging
 func (o *publishOpts) GetLogParams() map[string]interface{} {
 	params := map[string]interface{}{
@@ -186,6 +225,12 @@ func (o *publishOpts) GetLogParams() map[string]interface{} {
 		"Serialize":      o.Serialize,
 		"DoNotReplicate": o.DoNotReplicate,
 	}
+	if o.Chunked {
+		params["Chunked"] = o.Chunked
+	}
+	if len(o.Recipients) > 0 {
+		params["Recipients"] = o.Recipients
+	}
 	if o.setTTL {
 		params["TTL"] = o.TTL
 	}
@@ -214,7 +259,55 @@ func (o *publishOpts) GetLogParams() map[string]interface{} {
 func (b *publishBuilder) Execute() (*PublishResponse, StatusResponse, error) {
 	b.opts.pubnub.loggerManager.LogUserInput(PNLogLevelDebug, PNPublishOperation, b.opts.GetLogParams(), true)
 
-	rawJSON, status, err := executeRequest(b.opts)
+	b.opts.setSerialized = false
+	if err := b.opts.validate(); err != nil {
+		b.opts.pubnub.loggerManager.LogError(err, "ValidationFailed", PNPublishOperation, true)
+		return emptyPublishResponse, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
+	}
+	b.opts.sealedBox = nil
+	if len(b.opts.Recipients) > 0 {
+		module, err := b.opts.pubnub.sealedBoxModule(b.opts.Recipients)
+		if err != nil {
+			b.opts.pubnub.loggerManager.LogError(err, "SealedBoxRecipientsFailed", PNPublishOperation, true)
+			return emptyPublishResponse, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
+		}
+		b.opts.sealedBox = module
+	}
+	msg, err := b.opts.serializedMessage()
+	if err != nil {
+		return emptyPublishResponse, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
+	}
+	meta, err := b.opts.sentMeta(msg)
+	if err != nil {
+		err = newValidationError(b.opts, err.Error())
+		b.opts.pubnub.loggerManager.LogError(err, "ValidationFailed", PNPublishOperation, true)
+		return emptyPublishResponse, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
+	}
+	defer func(userMeta interface{}) { b.opts.Meta = userMeta }(b.opts.Meta)
+	b.opts.Meta = meta
+	if b.opts.Chunked && len(msg) > b.opts.pubnub.Config.MessageChunkSize {
+		return b.opts.publishChunks(msg)
+	}
+
+	size, post, err := b.opts.publishSize(msg)
+	if err != nil {
+		return emptyPublishResponse, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
+	}
+	if size > maxPublishMessageSize {
+		err := newValidationError(b.opts, fmt.Sprintf("%s: %d bytes, the limit is %d bytes", StrMessageTooLarge, size, maxPublishMessageSize))
+		b.opts.pubnub.loggerManager.LogError(err, "ValidationFailed", PNPublishOperation, true)
+		return emptyPublishResponse, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
+	}
+	opts := b.opts
+	if post && !b.opts.UsePost {
+		b.opts.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Publish: message exceeds %d bytes with GET, using POST", maxPublishGetMessageSize), false)
+		// The builder keeps its method for the next calls.
+		postOpts := *b.opts
+		postOpts.UsePost = true
+		opts = &postOpts
+	}
+
+	rawJSON, status, err := executeRequest(opts)
 	if err != nil {
 		return emptyPublishResponse, status, err
 	}
@@ -247,6 +340,10 @@ func (o *publishOpts) validate() error {
 		return newValidationError(o, StrInvalidCustomMessageType)
 	}
 
+	if o.Chunked && o.config().MessageChunkSize < minMessageChunkSize {
+		return newValidationError(o, fmt.Sprintf("MessageChunkSize must be at least %d bytes", minMessageChunkSize))
+	}
+
 	return nil
 }
 
@@ -256,7 +353,7 @@ func (o *publishOpts) encryptProcessing() (string, error) {
 
 	o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Crypto: encrypting message", false)
 	if o.pubnub.Config.DisablePNOtherProcessing {
-		if msg, errJSONMarshal = serializeEncryptAndSerialize(o.pubnub.getCryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); errJSONMarshal != nil {
+		if msg, errJSONMarshal = serializeEncryptAndSerialize(o.cryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); errJSONMarshal != nil {
 			o.pubnub.loggerManager.LogError(errJSONMarshal, "PublishSerializationFailed", PNPublishOperation, true)
 			return "", errJSONMarshal
 		}
@@ -270,14 +367,19 @@ func (o *publishOpts) encryptProcessing() (string, error) {
 
 			if ok {
 				o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Crypto: encrypting pn_other field", false)
-				encMsg, errJSONMarshal := serializeAndEncrypt(o.pubnub.getCryptoModule(), msgPart, o.Serialize, o.pubnub.loggerManager)
+				encMsg, errJSONMarshal := serializeAndEncrypt(o.cryptoModule(), msgPart, o.Serialize, o.pubnub.loggerManager)
 				if errJSONMarshal != nil {
 					o.pubnub.loggerManager.LogError(errJSONMarshal, "PublishPnOtherSerializationFailed", PNPublishOperation, true)
 					return "", errJSONMarshal
 				}
 				o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Serialization: pn_other field serialised successfully", false)
-				v["pn_other"] = encMsg
-				jsonEncBytes, errEnc := json.Marshal(v)
+				// copy the map, the message of the caller is left unchanged
+				encrypted := make(map[string]interface{}, len(v))
+				for key, val := range v {
+					encrypted[key] = val
+				}
+				encrypted["pn_other"] = encMsg
+				jsonEncBytes, errEnc := json.Marshal(encrypted)
 				if errEnc != nil {
 					o.pubnub.loggerManager.LogError(errEnc, "PublishMessageMarshalFailed", PNPublishOperation, true)
 					return "", errEnc
@@ -285,7 +387,7 @@ func (o *publishOpts) encryptProcessing() (string, error) {
 				o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Serialization: message with pn_other serialised successfully", false)
 				msg = string(jsonEncBytes)
 			} else {
-				if msg, errJSONMarshal = serializeEncryptAndSerialize(o.pubnub.getCryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); errJSONMarshal != nil {
+				if msg, errJSONMarshal = serializeEncryptAndSerialize(o.cryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); errJSONMarshal != nil {
 					o.pubnub.loggerManager.LogError(errJSONMarshal, "PublishSerializationFailed", PNPublishOperation, true)
 					return "", errJSONMarshal
 				}
@@ -293,7 +395,7 @@ func (o *publishOpts) encryptProcessing() (string, error) {
 			}
 			break
 		default:
-			if msg, errJSONMarshal = serializeEncryptAndSerialize(o.pubnub.getCryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); errJSONMarshal != nil {
+			if msg, errJSONMarshal = serializeEncryptAndSerialize(o.cryptoModule(), o.Message, o.Serialize, o.pubnub.loggerManager); errJSONMarshal != nil {
 				o.pubnub.loggerManager.LogError(errJSONMarshal, "PublishSerializationFailed", PNPublishOperation, true)
 				return "", errJSONMarshal
 			}
@@ -314,34 +416,9 @@ func (o *publishOpts) buildPath() (string, error) {
 			"0"), nil
 	}
 
-	var msg string
-	var errJSONMarshal error
-
-	if o.pubnub.getCryptoModule() != nil {
-		if msg, errJSONMarshal = o.encryptProcessing(); errJSONMarshal != nil {
-			return "", errJSONMarshal
-		}
-
-		o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Crypto: message encrypted successfully", false)
-	} else {
-		if o.Serialize {
-			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Serialization: serialising message content", false)
-			jsonEncBytes, errEnc := json.Marshal(o.Message)
-			if errEnc != nil {
-				o.pubnub.loggerManager.LogError(errEnc, "PublishMessageMarshalFailed", PNPublishOperation, true)
-				return "", errEnc
-			}
-			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Serialization: message serialised successfully", false)
-			msg = string(jsonEncBytes)
-			o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Publish: message serialized, length=%d", len(jsonEncBytes)), false)
-
-		} else {
-			if serializedMsg, ok := o.Message.(string); ok {
-				msg = serializedMsg
-			} else {
-				return "", pnerr.NewBuildRequestError("buildpath: Message is not JSON serialized.")
-			}
-		}
+	msg, err := o.serializedMessage()
+	if err != nil {
+		return "", err
 	}
 
 	return fmt.Sprintf(publishGetPath,
@@ -398,28 +475,136 @@ func (o *publishOpts) buildQuery() (*url.Values, error) {
 
 func (o *publishOpts) buildBody() ([]byte, error) {
 	if o.UsePost {
-		if o.pubnub.getCryptoModule() != nil {
-			msg, errJSONMarshal := o.encryptProcessing()
-			if errJSONMarshal != nil {
-				return []byte{}, errJSONMarshal
-			}
-			return []byte(msg), nil
+		msg, err := o.serializedMessage()
+		if err != nil {
+			return []byte{}, err
 		}
-		if o.Serialize {
-			jsonEncBytes, errEnc := json.Marshal(o.Message)
-			if errEnc != nil {
-				o.pubnub.loggerManager.LogError(errEnc, "PublishMessageMarshalFailed", PNPublishOperation, true)
-				return []byte{}, errEnc
-			}
-			return jsonEncBytes, nil
+		return []byte(msg), nil
+	}
+	return []byte{}, nil
+}
+
+// serializedMessage returns the message as sent to the server: serialized and
+// encrypted when a crypto module is set.
+func (o *publishOpts) serializedMessage() (string, error) {
+	if o.setSerialized {
+		return o.serialized, nil
+	}
+	msg, err := o.serializeMessage()
+	if err != nil {
+		return "", err
+	}
+	o.serialized = msg
+	o.setSerialized = true
+	return msg, nil
+}
+
+// cryptoModule returns the sealed box module of the Recipients, or the crypto module of the channel.
+func (o *publishOpts) cryptoModule() crypto.CryptoModule {
+	if o.sealedBox != nil {
+		return o.sealedBox
+	}
+	return o.pubnub.getChannelCryptoModule(o.Channel)
+}
+
+// sentMeta returns the Meta sent with the serialized message msg: the Meta
+// with the trace context of the publish context when
+// Config.PropagateTraceContext is set and the signature of msg when
+// Config.SigningKey is set.
+func (o *publishOpts) sentMeta(msg string) (interface{}, error) {
+	meta, err := o.pubnub.tracedMeta(o.ctx, o.Meta)
+	if err != nil || len(o.pubnub.Config.SigningKey) == 0 {
+		return meta, err
+	}
+	return o.pubnub.signedMeta(o.Channel, msg, meta)
+}
+
+// publishSize returns the size of the serialized message msg as counted against
+// the publish size limit, and whether it is sent with POST: the URL-escaped
+// channel and meta, and the message URL-escaped in the path of a GET or as is
+// in the body of a POST. The messages too large for a GET are sent with POST.
+func (o *publishOpts) publishSize(msg string) (int, bool, error) {
+	size := len(utils.URLEncode(o.Channel))
+	if o.Meta != nil {
+		meta, err := utils.ValueAsString(o.Meta)
+		if err != nil {
+			return 0, false, err
 		}
-		serializedMsg, ok := o.Message.(string)
-		if ok {
-			return []byte(serializedMsg), nil
+		size += len(utils.URLEncode(string(meta)))
+	}
+	if getSize := size + len(utils.URLEncode(msg)); !o.UsePost && getSize <= maxPublishGetMessageSize {
+		return getSize, false, nil
+	}
+	return size + len(msg), true, nil
+}
+
+func (o *publishOpts) serializeMessage() (string, error) {
+	if o.cryptoModule() != nil && !o.skipEncryption {
+		msg, errJSONMarshal := o.encryptProcessing()
+		if errJSONMarshal != nil {
+			return "", errJSONMarshal
 		}
-		return []byte{}, pnerr.NewBuildRequestError("buildBody: Message is not JSON serialized.")
+		o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Crypto: message encrypted successfully", false)
+		return msg, nil
 	}
-	return []byte{}, nil
+	if o.Serialize {
+		o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "Serialization: serialising message content", false)
+		jsonEncBytes, errEnc := json.Marshal(o.Message)
+		if errEnc != nil {
+			o.pubnub.loggerManager.LogError(errEnc, "PublishMessageMarshalFailed", PNPublishOperation, true)
+			return "", errEnc
+		}
+		o.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Publish: message serialized, length=%d", len(jsonEncBytes)), false)
+		return string(jsonEncBytes), nil
+	}
+	if serializedMsg, ok := o.Message.(string); ok {
+		return serializedMsg, nil
+	}
+	return "", pnerr.NewBuildRequestError("Message is not JSON serialized.")
+}
+
+// publishChunks publishes msg in chunks of at most Config.MessageChunkSize bytes
+// with POST. Each chunk carries the message ID, its index, the number of chunks
+// and the hash of msg in the Meta. The response is the one of the last chunk.
+// A failure after the first chunk returns a ChunkedPublishError.
+func (o *publishOpts) publishChunks(msg string) (*PublishResponse, StatusResponse, error) {
+	parts := splitMessageChunks(msg, o.pubnub.Config.MessageChunkSize)
+	id := GenerateUUID()
+	hash := chunkHash(msg)
+	o.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Publish: splitting message %s of %d bytes in %d chunks", id, len(msg), len(parts)), false)
+
+	var resp *PublishResponse
+	var status StatusResponse
+	var timetokens []int64
+	for i, part := range parts {
+		meta, err := chunkedMeta(o.Meta, messageChunk{ID: id, Index: i, Total: len(parts), Hash: hash})
+		if err != nil {
+			return emptyPublishResponse, status, newValidationError(o, err.Error())
+		}
+
+		chunkOpts := *o
+		chunkOpts.Message = part
+		chunkOpts.Meta = meta
+		chunkOpts.Serialize = true
+		chunkOpts.UsePost = true
+		chunkOpts.skipEncryption = true
+		chunkOpts.setSerialized = false
+
+		rawJSON, s, err := executeRequest(&chunkOpts)
+		status = s
+		if err == nil {
+			resp, status, err = newPublishResponse(rawJSON, status, o.pubnub.loggerManager)
+		}
+		if err != nil {
+			if len(timetokens) > 0 {
+				err = &ChunkedPublishError{ID: id, Total: len(parts), Timetokens: timetokens, Err: err}
+				o.pubnub.loggerManager.LogError(err, "ChunkedPublishFailed", PNPublishOperation, true)
+			}
+			return emptyPublishResponse, status, err
+		}
+		timetokens = append(timetokens, resp.Timestamp)
+	}
+	return resp, status, nil
 }
 
 func (o *publishOpts) httpMethod() string {
@@ -429,6 +614,18 @@ func (o *publishOpts) httpMethod() string {
 	return "GET"
 }
 
+func (o *publishOpts) channelCount() int {
+	return 1
+}
+
+func (o *publishOpts) channelName() string {
+	return o.Channel
+}
+
+func (o *publishOpts) message() interface{} {
+	return o.Message
+}
+
 func (o *publishOpts) operationType() OperationType {
 	return PNPublishOperation
 }
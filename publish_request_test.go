@@ -1,12 +1,18 @@
 package pubnub
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/pubnub/go/v9/pnerr"
 	h "github.com/pubnub/go/v9/tests/helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func AssertSuccessPublishGet(t *testing.T, expectedString string, message interface{}) {
@@ -546,3 +552,112 @@ func TestPublishValidateCustomMessageType(t *testing.T) {
 	opts.CustomMessageType = "!@#$%^&*("
 	assert.False(opts.isCustomMessageTypeCorrect())
 }
+
+type publishRequest struct {
+	method  string
+	message string
+	meta    string
+}
+
+func newPublishSizeTestPubNub(t *testing.T) (*PubNub, func() []publishRequest) {
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[1,"Sent","15000000000000000"]`))
+	})
+	return pn, func() []publishRequest {
+		var res []publishRequest
+		for _, req := range requests() {
+			r := publishRequest{method: req.Method, message: req.body}
+			if req.Method == http.MethodGet {
+				parts := strings.Split(req.URL.EscapedPath(), "/")
+				r.message = parts[len(parts)-1]
+			}
+			for _, param := range strings.Split(req.URL.RawQuery, "&") {
+				if strings.HasPrefix(param, "meta=") {
+					r.meta = strings.TrimPrefix(param, "meta=")
+				}
+			}
+			res = append(res, r)
+		}
+		return res
+	}
+}
+
+func TestEstimatePublishSize(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newPublishSizeTestPubNub(t)
+	module, err := crypto.NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, err)
+
+	message := map[string]interface{}{"text": "héllo wörld & co", "pn_other": "secret"}
+	meta := map[string]interface{}{"k": "a b"}
+
+	for _, cryptoModule := range []crypto.CryptoModule{nil, module} {
+		pn.Config.CryptoModule = cryptoModule
+		n := len(requests())
+
+		size, err := pn.EstimatePublishSize("ch 1", message, meta)
+		assert.Nil(err)
+
+		_, _, err = pn.Publish().Channel("ch 1").Message(message).Meta(meta).Execute()
+		assert.Nil(err)
+		require.Len(t, requests(), n+1)
+		r := requests()[n]
+		assert.Equal(http.MethodGet, r.method)
+		if cryptoModule == nil {
+			assert.Equal(len("ch%201")+len(r.message)+len(r.meta), size)
+		} else {
+			// The escaped size of the encrypted message depends on the random IV.
+			assert.InEpsilon(len("ch%201")+len(r.message)+len(r.meta), size, 0.1)
+		}
+	}
+	// The message of the caller is not modified by the encryption of pn_other.
+	assert.Equal("secret", message["pn_other"])
+
+	_, err = pn.EstimatePublishSize("ch", make(chan int), nil)
+	assert.NotNil(err)
+}
+
+func TestPublishLargeMessageUsesPost(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newPublishSizeTestPubNub(t)
+
+	builder := pn.Publish().Channel("ch").Message(strings.Repeat("a", maxPublishGetMessageSize))
+	_, _, err := builder.Execute()
+	assert.Nil(err)
+	require.Len(t, requests(), 1)
+	assert.Equal(http.MethodPost, requests()[0].method)
+
+	// The builder isn't switched to POST.
+	_, _, err = builder.Message("small").Execute()
+	assert.Nil(err)
+	assert.Equal(http.MethodGet, requests()[1].method)
+}
+
+func TestPublishPostSizeIsTheBodySize(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newPublishSizeTestPubNub(t)
+
+	// Escaped, the message would exceed the limit.
+	message := strings.Repeat("é ", maxPublishMessageSize/8)
+	size, err := pn.EstimatePublishSize("ch", message, nil)
+	assert.Nil(err)
+	assert.Equal(len("ch")+len(message)+2, size)
+
+	_, _, err = pn.Publish().Channel("ch").Message(message).Execute()
+	assert.Nil(err)
+	require.Len(t, requests(), 1)
+	assert.Equal(http.MethodPost, requests()[0].method)
+	assert.Equal(size-len("ch"), len(requests()[0].message))
+}
+
+func TestPublishMessageTooLarge(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newPublishSizeTestPubNub(t)
+
+	_, _, err := pn.Publish().Channel("ch").Message(strings.Repeat("a", maxPublishMessageSize)).UsePost(true).Execute()
+	assert.NotNil(err)
+	var validationErr *pnerr.ValidationError
+	assert.True(errors.As(err, &validationErr))
+	assert.Contains(err.Error(), StrMessageTooLarge)
+	assert.Empty(requests())
+}
@@ -60,6 +60,8 @@ const (
 	StrMissingToken = "Missing PAMv3 token"
 	// StrInvalidCustomMessageType shows `Invalid CustomMessageType` message
 	StrInvalidCustomMessageType = "Invalid CustomMessageType: size different than 3-50 or contains invalid characters"
+	// StrMessageTooLarge shows `Message too large` message
+	StrMessageTooLarge = "Message too large"
 )
 
 // PubNub No server connection will be established when you create a new PubNub object.
@@ -84,6 +86,11 @@ type PubNub struct {
 	ctx                   Context
 	cancel                func()
 	tokenManager          *TokenManager
+	rateLimiter           *rateLimiter
+	circuitBreaker        *circuitBreaker
+	originSelector        *originSelector
+	requestCache          *requestCache
+	sealedBoxKeys         *sealedBoxKeyCache
 	previousCipherKey     string
 	previousIvFlag        bool
 
@@ -168,6 +175,17 @@ func (pn *PubNub) getCryptoModule() crypto.CryptoModule {
 	return nil
 }
 
+// getChannelCryptoModule returns the crypto module of Config.CryptoModuleResolver
+// for the channel, or the global crypto module when it returns nil.
+func (pn *PubNub) getChannelCryptoModule(channel string) crypto.CryptoModule {
+	if resolver := pn.Config.CryptoModuleResolver; resolver != nil && channel != "" {
+		if module := resolver(channel); module != nil {
+			return module
+		}
+	}
+	return pn.getCryptoModule()
+}
+
 // Publish is used to send a message to all subscribers of a channel.
 func (pn *PubNub) Publish() *publishBuilder {
 	return newPublishBuilder(pn)
@@ -178,6 +196,36 @@ func (pn *PubNub) PublishWithContext(ctx Context) *publishBuilder {
 	return newPublishBuilderWithContext(pn, ctx)
 }
 
+// EstimatePublishSize returns the size in bytes of a message as counted against the
+// publish size limit: the URL-escaped channel and meta, with the signature when
+// Config.SigningKey is set, and the message after it is serialized and
+// encrypted with the configured crypto module, URL-escaped when it fits in a
+// GET and as is in the body of a POST otherwise. With a random IV the size of
+// an encrypted message can differ by a few bytes between calls.
+func (pn *PubNub) EstimatePublishSize(channel string, message, meta interface{}) (int, error) {
+	return pn.EstimatePublishSizeWithContext(pn.ctx, channel, message, meta)
+}
+
+// EstimatePublishSizeWithContext returns the size of a message published with
+// PublishWithContext(ctx), which includes the trace context of ctx in the meta
+// when Config.PropagateTraceContext is set. See EstimatePublishSize.
+func (pn *PubNub) EstimatePublishSizeWithContext(ctx Context, channel string, message, meta interface{}) (int, error) {
+	opts := newPublishOpts(pn, ctx)
+	opts.Channel = channel
+	opts.Message = message
+	opts.Meta = meta
+
+	msg, err := opts.serializedMessage()
+	if err != nil {
+		return 0, err
+	}
+	if opts.Meta, err = opts.sentMeta(msg); err != nil {
+		return 0, err
+	}
+	size, _, err := opts.publishSize(msg)
+	return size, err
+}
+
 // Fire endpoint allows the client to send a message to PubNub Functions Event Handlers. These messages will go directly to any Event Handlers registered on the channel that you fire to and will trigger their execution.
 func (pn *PubNub) Fire() *fireBuilder {
 	return newFireBuilder(pn)
@@ -538,11 +586,11 @@ func (pn *PubNub) GetClient() *http.Client {
 
 	if pn.client == nil {
 		if pn.Config.UseHTTP2 {
-			pn.client = newHTTP2Client(pn.Config.ConnectTimeout,
+			pn.client = newHTTP2Client(pn.Config, pn.Config.ConnectTimeout,
 				pn.Config.NonSubscribeRequestTimeout,
 				pn.Config.MaxIdleConnsPerHost)
 		} else {
-			pn.client = NewHTTP1Client(pn.Config.ConnectTimeout,
+			pn.client = newHTTP1Client(pn.Config, pn.Config.ConnectTimeout,
 				pn.Config.NonSubscribeRequestTimeout,
 				pn.Config.MaxIdleConnsPerHost)
 		}
@@ -566,11 +614,11 @@ func (pn *PubNub) GetSubscribeClient() *http.Client {
 	if pn.subscribeClient == nil {
 
 		if pn.Config.UseHTTP2 {
-			pn.subscribeClient = newHTTP2Client(pn.Config.ConnectTimeout,
+			pn.subscribeClient = newHTTP2Client(pn.Config, pn.Config.ConnectTimeout,
 				pn.Config.SubscribeRequestTimeout,
 				pn.Config.MaxIdleConnsPerHost)
 		} else {
-			pn.subscribeClient = NewHTTP1Client(pn.Config.ConnectTimeout,
+			pn.subscribeClient = newHTTP1Client(pn.Config, pn.Config.ConnectTimeout,
 				pn.Config.SubscribeRequestTimeout, pn.Config.MaxIdleConnsPerHost)
 		}
 
@@ -951,6 +999,12 @@ func NewPubNub(pnconf *Config) *PubNub {
 	pn.jobQueue = make(chan *JobQItem)
 	pn.requestWorkers = pn.newNonSubQueueProcessor(pnconf.MaxWorkers, ctx)
 	pn.tokenManager = newTokenManager(pn, ctx)
+	pn.rateLimiter = newRateLimiter()
+	pn.circuitBreaker = newCircuitBreaker()
+	pn.originSelector = newOriginSelector()
+	pn.requestCache = newRequestCache()
+	pn.sealedBoxKeys = newSealedBoxKeyCache()
+	pn.tokenManager.start()
 
 	return pn
 }
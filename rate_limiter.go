@@ -0,0 +1,232 @@
+package pubnub
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitGroup is a group of operations sharing a rate limit, see Config.RateLimits.
+type RateLimitGroup int
+
+const (
+	// RateLimitPublish groups the Publish, Fire, Signal and PublishFileMessage operations.
+	RateLimitPublish RateLimitGroup = 1 + iota
+	// RateLimitPresence groups the HereNow, WhereNow, SetState and GetState
+	// operations. The heartbeats and the leaves of the subscribe loop aren't limited.
+	RateLimitPresence
+	// RateLimitObjects groups the operations of the Objects API.
+	RateLimitObjects
+	// RateLimitAccessManager groups the grant and revoke operations.
+	RateLimitAccessManager
+	// RateLimitHistory groups the History, Fetch, MessageCounts and DeleteMessages operations.
+	RateLimitHistory
+)
+
+func (g RateLimitGroup) String() string {
+	switch g {
+	case RateLimitPublish:
+		return "publish"
+	case RateLimitPresence:
+		return "presence"
+	case RateLimitObjects:
+		return "objects"
+	case RateLimitAccessManager:
+		return "pam"
+	case RateLimitHistory:
+		return "history"
+	default:
+		return "unknown"
+	}
+}
+
+// RateLimitMode is whether the requests over a rate limit wait or fail, see Config.RateLimitMode.
+type RateLimitMode int
+
+const (
+	// RateLimitWait makes the requests wait for a token of their bucket, until
+	// their context is done.
+	RateLimitWait RateLimitMode = iota
+	// RateLimitFailFast makes the requests without a token fail with a RateLimitError.
+	RateLimitFailFast
+)
+
+// RateLimit is the token bucket of a RateLimitGroup.
+type RateLimit struct {
+	// Rate is the number of tokens added per second, the group isn't limited when 0.
+	Rate float64
+	// Burst is the size of the bucket, 1 when 0.
+	Burst int
+	// PerChannel gives a bucket to each channel, for RateLimitPublish.
+	PerChannel bool
+}
+
+// RateLimitError is the error of the requests rejected by Config.RateLimits
+// in the RateLimitFailFast mode.
+type RateLimitError struct {
+	Operation OperationType
+	Group     RateLimitGroup
+	// Channel is the channel of the bucket, empty when it isn't per channel.
+	Channel string
+	// RetryAfter is the wait for the next token.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Channel != "" {
+		return fmt.Sprintf("rate limit of %s exceeded on channel %s: operation=%s, retry after %s", e.Group, e.Channel, e.Operation, e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limit of %s exceeded: operation=%s, retry after %s", e.Group, e.Operation, e.RetryAfter)
+}
+
+// channelEndpoint is implemented by the endpoints of a single channel, for
+// the buckets per channel.
+type channelEndpoint interface {
+	channelName() string
+}
+
+func rateLimitGroupForOperation(t OperationType) (RateLimitGroup, bool) {
+	switch t {
+	case PNPublishOperation, PNFireOperation, PNSignalOperation, PNPublishFileMessageOperation:
+		return RateLimitPublish, true
+	case PNHereNowOperation, PNWhereNowOperation, PNSetStateOperation, PNGetStateOperation:
+		return RateLimitPresence, true
+	case PNAccessManagerGrant, PNAccessManagerRevoke, PNAccessManagerGrantToken, PNAccessManagerRevokeToken:
+		return RateLimitAccessManager, true
+	case PNHistoryOperation, PNFetchMessagesOperation, PNHistoryWithActionsOperation, PNMessageCountsOperation, PNDeleteMessagesOperation:
+		return RateLimitHistory, true
+	}
+	if telemetryEndpointNameForOperation(t) == "obj" {
+		return RateLimitObjects, true
+	}
+	return 0, false
+}
+
+type rateLimitKey struct {
+	group   RateLimitGroup
+	channel string
+}
+
+// tokenBucket is a token bucket, its tokens are negative while requests wait for them.
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) burst() float64 {
+	if b.limit.Burst <= 0 {
+		return 1
+	}
+	return float64(b.limit.Burst)
+}
+
+// refill adds the tokens of the time elapsed since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst(), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+}
+
+// waitFor returns the wait for the tokens to reach n.
+func (b *tokenBucket) waitFor(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+// rateLimitSweepInterval is the interval of the removal of the full buckets.
+const rateLimitSweepInterval = time.Minute
+
+// rateLimiter applies Config.RateLimits to the requests of a client.
+type rateLimiter struct {
+	sync.Mutex
+	buckets   map[rateLimitKey]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[rateLimitKey]*tokenBucket), lastSweep: time.Now()}
+}
+
+// sweep removes the buckets refilled to their burst, a new bucket is the
+// same. It keeps the buckets of the channels of RateLimit.PerChannel from
+// growing with all the channels ever used.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.burst() {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// wait takes a token for the request of opts, waiting for it or failing
+// depending on Config.RateLimitMode. It returns the status of the rejected
+// requests, and records the state of the bucket in Config.Metrics.
+func (l *rateLimiter) wait(opts endpoint) (StatusResponse, error) {
+	cfg := opts.config()
+	group, ok := rateLimitGroupForOperation(opts.operationType())
+	if !ok {
+		return StatusResponse{}, nil
+	}
+	limit, ok := cfg.RateLimits[group]
+	if !ok || limit.Rate <= 0 {
+		return StatusResponse{}, nil
+	}
+	key := rateLimitKey{group: group}
+	if e, ok := opts.(channelEndpoint); ok && limit.PerChannel {
+		key.channel = e.channelName()
+	}
+
+	l.Lock()
+	now := time.Now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &tokenBucket{limit: limit, last: now}
+		b.tokens = b.burst()
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	if cfg.RateLimitMode == RateLimitFailFast && b.tokens < 1 {
+		wait, tokens := b.waitFor(1), b.tokens
+		l.Unlock()
+		cfg.metrics().RecordRateLimit(group, key.channel, tokens, 0, true)
+		err := &RateLimitError{Operation: opts.operationType(), Group: group, Channel: key.channel, RetryAfter: wait}
+		opts.getPubNub().loggerManager.LogError(err, "RateLimitExceeded", opts.operationType(), true)
+		return createStatus(PNRateLimitedCategory, "", ResponseInfo{Operation: opts.operationType()}, err), err
+	}
+	b.tokens--
+	wait, tokens := b.waitFor(0), b.tokens
+	l.Unlock()
+	cfg.metrics().RecordRateLimit(group, key.channel, tokens, wait, false)
+	if wait == 0 {
+		return StatusResponse{}, nil
+	}
+
+	opts.getPubNub().loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Rate limit of %s: operation=%s waits %s", group, opts.operationType(), wait), false)
+	ctx := opts.context()
+	if ctx == nil {
+		ctx = backgroundContext
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return StatusResponse{}, nil
+	case <-ctx.Done():
+		// The token is given back to the requests waiting after this one.
+		l.Lock()
+		b.tokens++
+		l.Unlock()
+		err := ctx.Err()
+		return createStatus(PNCancelledCategory, "", ResponseInfo{Operation: opts.operationType()}, err), err
+	}
+}
@@ -0,0 +1,142 @@
+package pubnub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitTestPubNub(t *testing.T) (*PubNub, *MetricsRegistry, func() []testRequest) {
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[1,"Sent","15000000000000000"]`))
+	})
+	registry := NewMetricsRegistry()
+	pn.Config.Metrics = registry
+	return pn, registry, requests
+}
+
+func TestRateLimitFailFast(t *testing.T) {
+	assert := assert.New(t)
+	pn, registry, requests := newRateLimitTestPubNub(t)
+	pn.Config.RateLimits = map[RateLimitGroup]RateLimit{RateLimitPublish: {Rate: 0.01, Burst: 2}}
+	pn.Config.RateLimitMode = RateLimitFailFast
+
+	for i := 0; i < 2; i++ {
+		_, _, err := pn.Publish().Channel("ch").Message("hi").Execute()
+		require.NoError(t, err)
+	}
+	_, status, err := pn.Signal().Channel("ch").Message("hi").Execute()
+	var rateLimitErr *RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.Equal(RateLimitPublish, rateLimitErr.Group)
+	assert.Equal(PNSignalOperation, rateLimitErr.Operation)
+	assert.True(rateLimitErr.RetryAfter > 90*time.Second)
+	assert.Equal(PNRateLimitedCategory, status.Category)
+	assert.Len(requests(), 2)
+
+	// The other groups aren't limited.
+	_, _, err = pn.Time().Execute()
+	assert.NoError(err)
+
+	m := registry.Snapshot().RateLimits["publish"]
+	assert.Equal(int64(1), m.Rejections)
+	assert.Equal(int64(0), m.Waits)
+	assert.InDelta(0, m.Tokens, 0.01)
+}
+
+func TestRateLimitPerChannel(t *testing.T) {
+	assert := assert.New(t)
+	pn, _, requests := newRateLimitTestPubNub(t)
+	pn.Config.RateLimits = map[RateLimitGroup]RateLimit{RateLimitPublish: {Rate: 0.01, PerChannel: true}}
+	pn.Config.RateLimitMode = RateLimitFailFast
+
+	_, _, err := pn.Publish().Channel("ch1").Message("hi").Execute()
+	assert.NoError(err)
+	_, _, err = pn.Publish().Channel("ch2").Message("hi").Execute()
+	assert.NoError(err)
+	_, _, err = pn.Publish().Channel("ch1").Message("hi").Execute()
+	var rateLimitErr *RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.Equal("ch1", rateLimitErr.Channel)
+	assert.Len(requests(), 2)
+}
+
+func TestRateLimitPerChannelMetrics(t *testing.T) {
+	assert := assert.New(t)
+	pn, registry, _ := newRateLimitTestPubNub(t)
+	pn.Config.RateLimits = map[RateLimitGroup]RateLimit{RateLimitPublish: {Rate: 0.01, PerChannel: true}}
+
+	_, _, err := pn.Publish().Channel("ch1").Message("hi").Execute()
+	assert.NoError(err)
+
+	// The buckets of the channels don't make a tokens gauge of the group.
+	assert.True(registry.Snapshot().RateLimits["publish"].PerChannel)
+	var b strings.Builder
+	require.NoError(t, registry.WritePrometheus(&b))
+	assert.NotContains(b.String(), "pubnub_rate_limit_tokens{group=\"publish\"}")
+}
+
+func TestRateLimitSweep(t *testing.T) {
+	assert := assert.New(t)
+	pn, _, _ := newRateLimitTestPubNub(t)
+	pn.Config.RateLimits = map[RateLimitGroup]RateLimit{RateLimitPublish: {Rate: 1, PerChannel: true}}
+
+	_, _, err := pn.Publish().Channel("ch1").Message("hi").Execute()
+	assert.NoError(err)
+	_, _, err = pn.Publish().Channel("ch2").Message("hi").Execute()
+	assert.NoError(err)
+
+	l := pn.rateLimiter
+	l.Lock()
+	defer l.Unlock()
+	assert.Len(l.buckets, 2)
+	l.buckets[rateLimitKey{RateLimitPublish, "ch2"}].last = time.Now().Add(time.Hour)
+	l.sweep(time.Now().Add(rateLimitSweepInterval))
+	assert.Len(l.buckets, 1)
+	assert.NotNil(l.buckets[rateLimitKey{RateLimitPublish, "ch2"}])
+}
+
+func TestRateLimitWait(t *testing.T) {
+	assert := assert.New(t)
+	pn, registry, requests := newRateLimitTestPubNub(t)
+	pn.Config.RateLimits = map[RateLimitGroup]RateLimit{RateLimitPublish: {Rate: 20, Burst: 1}}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, _, err := pn.Publish().Channel("ch").Message("hi").Execute()
+		require.NoError(t, err)
+	}
+	assert.True(time.Since(start) >= 90*time.Millisecond)
+	assert.Len(requests(), 3)
+
+	m := registry.Snapshot().RateLimits["publish"]
+	assert.Equal(int64(2), m.Waits)
+	assert.True(m.WaitSum > 0.08)
+}
+
+func TestRateLimitWaitCancelled(t *testing.T) {
+	assert := assert.New(t)
+	pn, _, requests := newRateLimitTestPubNub(t)
+	pn.Config.RateLimits = map[RateLimitGroup]RateLimit{RateLimitPublish: {Rate: 0.01}}
+
+	_, _, err := pn.Publish().Channel("ch").Message("hi").Execute()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, status, err := pn.PublishWithContext(ctx).Channel("ch").Message("hi").Execute()
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.Equal(PNCancelledCategory, status.Category)
+	assert.Len(requests(), 1)
+
+	// The token of the cancelled request is given back.
+	pn.rateLimiter.Lock()
+	assert.InDelta(0, pn.rateLimiter.buckets[rateLimitKey{group: RateLimitPublish}].tokens, 0.01)
+	pn.rateLimiter.Unlock()
+}
@@ -0,0 +1,227 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// DefaultReadPositionCustomKey is the membership custom field used by MembershipReadPositionStore.
+const DefaultReadPositionCustomKey = "lastReadTimetoken"
+
+const readPositionMembershipsPageLimit = 100
+
+// ReadPositionStore persists the last read timetoken of each channel.
+type ReadPositionStore interface {
+	// GetReadPositions returns the stored read positions of the channels.
+	// Channels without a stored position are omitted from the result.
+	GetReadPositions(channels []string) (map[string]int64, error)
+	// SetReadPosition stores the read position of a channel.
+	SetReadPosition(channel string, timetoken int64) error
+}
+
+// MemoryReadPositionStore keeps read positions in memory.
+type MemoryReadPositionStore struct {
+	sync.RWMutex
+	positions map[string]int64
+}
+
+// NewMemoryReadPositionStore creates an empty in-memory read position store.
+func NewMemoryReadPositionStore() *MemoryReadPositionStore {
+	return &MemoryReadPositionStore{positions: make(map[string]int64)}
+}
+
+// GetReadPositions returns the stored read positions of the channels.
+func (s *MemoryReadPositionStore) GetReadPositions(channels []string) (map[string]int64, error) {
+	s.RLock()
+	defer s.RUnlock()
+	res := make(map[string]int64, len(channels))
+	for _, ch := range channels {
+		if tt, ok := s.positions[ch]; ok {
+			res[ch] = tt
+		}
+	}
+	return res, nil
+}
+
+// SetReadPosition stores the read position of a channel.
+func (s *MemoryReadPositionStore) SetReadPosition(channel string, timetoken int64) error {
+	s.Lock()
+	s.positions[channel] = timetoken
+	s.Unlock()
+	return nil
+}
+
+// FileReadPositionStore keeps read positions in a JSON file.
+// The file is rewritten atomically on every update.
+type FileReadPositionStore struct {
+	sync.Mutex
+	path string
+}
+
+// NewFileReadPositionStore creates a read position store backed by the file at path.
+// The file is created on the first update.
+func NewFileReadPositionStore(path string) *FileReadPositionStore {
+	return &FileReadPositionStore{path: path}
+}
+
+// GetReadPositions returns the stored read positions of the channels.
+func (s *FileReadPositionStore) GetReadPositions(channels []string) (map[string]int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	positions, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int64, len(channels))
+	for _, ch := range channels {
+		if tt, ok := positions[ch]; ok {
+			res[ch] = tt
+		}
+	}
+	return res, nil
+}
+
+// SetReadPosition stores the read position of a channel.
+func (s *FileReadPositionStore) SetReadPosition(channel string, timetoken int64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	positions, err := s.load()
+	if err != nil {
+		return err
+	}
+	positions[channel] = timetoken
+
+	b, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileReadPositionStore) load() (map[string]int64, error) {
+	positions := make(map[string]int64)
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return positions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return positions, nil
+	}
+	if err = json.Unmarshal(b, &positions); err != nil {
+		return nil, fmt.Errorf("read position store: invalid file %s: %v", s.path, err)
+	}
+	return positions, nil
+}
+
+// MembershipReadPositionStore keeps read positions in the custom field of the
+// App Context memberships of the client UUID. Timetokens are stored as strings
+// as they exceed the precision of JSON numbers.
+type MembershipReadPositionStore struct {
+	pubnub *PubNub
+	key    string
+}
+
+// NewMembershipReadPositionStore creates a read position store which uses the membership
+// custom field key. An empty key uses DefaultReadPositionCustomKey.
+func NewMembershipReadPositionStore(pubnub *PubNub, key string) *MembershipReadPositionStore {
+	if key == "" {
+		key = DefaultReadPositionCustomKey
+	}
+	return &MembershipReadPositionStore{pubnub: pubnub, key: key}
+}
+
+// GetReadPositions returns the stored read positions of the channels.
+func (s *MembershipReadPositionStore) GetReadPositions(channels []string) (map[string]int64, error) {
+	wanted := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		wanted[ch] = true
+	}
+
+	res := make(map[string]int64, len(channels))
+	start := ""
+	for {
+		builder := s.pubnub.GetMemberships().
+			Include([]PNMembershipsInclude{PNMembershipsIncludeCustom}).
+			Limit(readPositionMembershipsPageLimit)
+		if start != "" {
+			builder.Start(start)
+		}
+		resp, _, err := builder.Execute()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range resp.Data {
+			if !wanted[m.Channel.ID] {
+				continue
+			}
+			if tt, ok := readPositionFromCustom(m.Custom, s.key); ok {
+				res[m.Channel.ID] = tt
+			}
+		}
+		if len(resp.Data) < readPositionMembershipsPageLimit || resp.Next == "" || resp.Next == start {
+			return res, nil
+		}
+		start = resp.Next
+	}
+}
+
+// SetReadPosition stores the read position of a channel, keeping the other custom fields of the membership.
+func (s *MembershipReadPositionStore) SetReadPosition(channel string, timetoken int64) error {
+	resp, _, err := s.pubnub.GetMemberships().
+		Include([]PNMembershipsInclude{PNMembershipsIncludeCustom}).
+		Filter(fmt.Sprintf("channel.id == %s", strconv.Quote(channel))).
+		Limit(1).
+		Execute()
+	if err != nil {
+		return err
+	}
+
+	custom := make(map[string]interface{})
+	if len(resp.Data) > 0 {
+		for k, v := range resp.Data[0].Custom {
+			custom[k] = v
+		}
+	}
+	custom[s.key] = strconv.FormatInt(timetoken, 10)
+
+	_, _, err = s.pubnub.SetMemberships().
+		Set([]PNMembershipsSet{{
+			Channel: PNMembershipsChannel{ID: channel},
+			Custom:  custom,
+		}}).
+		Execute()
+	return err
+}
+
+func readPositionFromCustom(custom map[string]interface{}, key string) (int64, bool) {
+	switch v := custom[key].(type) {
+	case string:
+		tt, err := strconv.ParseInt(v, 10, 64)
+		return tt, err == nil
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryReadPositionStore(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryReadPositionStore()
+
+	assert.Nil(store.SetReadPosition("a", 10))
+	positions, err := store.GetReadPositions([]string{"a", "b"})
+	assert.Nil(err)
+	assert.Equal(map[string]int64{"a": 10}, positions)
+}
+
+func TestFileReadPositionStore(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "positions.json")
+	store := NewFileReadPositionStore(path)
+
+	positions, err := store.GetReadPositions([]string{"a"})
+	assert.Nil(err)
+	assert.Empty(positions)
+
+	assert.Nil(store.SetReadPosition("a", 17000000000000001))
+	assert.Nil(store.SetReadPosition("b", 20))
+
+	positions, err = NewFileReadPositionStore(path).GetReadPositions([]string{"a", "b", "c"})
+	assert.Nil(err)
+	assert.Equal(map[string]int64{"a": 17000000000000001, "b": 20}, positions)
+}
+
+func TestFileReadPositionStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "positions.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	_, err := NewFileReadPositionStore(path).GetReadPositions([]string{"a"})
+	assert.NotNil(t, err)
+	assert.NotNil(t, NewFileReadPositionStore(path).SetReadPosition("a", 1))
+}
+
+func TestMembershipReadPositionStore(t *testing.T) {
+	assert := assert.New(t)
+	var setBody map[string]interface{}
+
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			assert.Equal("custom", req.URL.Query().Get("include"))
+			if req.URL.Query().Get("filter") != "" {
+				assert.Equal(`channel.id == "a"`, req.URL.Query().Get("filter"))
+				fmt.Fprint(w, `{"status":200,"data":[{"channel":{"id":"a"},"custom":{"color":"red","lastReadTimetoken":"10"}}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":200,"data":[
+				{"channel":{"id":"a"},"custom":{"lastReadTimetoken":"17000000000000001"}},
+				{"channel":{"id":"b"},"custom":{"lastReadTimetoken":20}},
+				{"channel":{"id":"c"},"custom":{"other":"x"}},
+				{"channel":{"id":"d"},"custom":{"lastReadTimetoken":"30"}}]}`)
+		case http.MethodPatch:
+			b, _ := io.ReadAll(req.Body)
+			require.NoError(t, json.Unmarshal(b, &setBody))
+			fmt.Fprint(w, `{"status":200,"data":[]}`)
+		}
+	})
+
+	store := NewMembershipReadPositionStore(pn, "")
+
+	positions, err := store.GetReadPositions([]string{"a", "b", "c"})
+	assert.Nil(err)
+	assert.Equal(map[string]int64{"a": 17000000000000001, "b": 20}, positions)
+
+	assert.Nil(store.SetReadPosition("a", 17000000000000002))
+	set := setBody["set"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(map[string]interface{}{"color": "red", "lastReadTimetoken": "17000000000000002"}, set["custom"])
+}
@@ -95,7 +95,88 @@ func buildBody(opts endpoint, url *url.URL) ([]byte, io.Reader, error) {
 	return b, bytes.NewReader(b), nil
 }
 
+// executeRequest runs the request of opts through Config.Interceptors in a
+// span of Config.Tracer and records it in Config.Metrics, after a token of
+// Config.RateLimits and unless Config.CircuitBreaker rejects it. With
+// Config.TokenProvider, a request denied with a 403 is retried once with a new
+// token of the provider. With Config.RequestCache, the identical read-only
+// requests share their response.
 func executeRequest(opts endpoint) ([]byte, StatusResponse, error) {
+	if key, tags, ok := requestCacheKey(opts); ok {
+		return opts.getPubNub().requestCache.do(opts, key, tags, executeRequestUncached)
+	}
+	return executeRequestUncached(opts)
+}
+
+func executeRequestUncached(opts endpoint) ([]byte, StatusResponse, error) {
+	pn := opts.getPubNub()
+	obs := newRequestObserver(opts)
+	var val []byte
+	status, err := pn.rateLimiter.wait(opts)
+	if err == nil {
+		val, status, err = executeRequestWithToken(opts, obs)
+	}
+	obs.end(opts, val, status, err)
+	return val, status, err
+}
+
+// requestObserver records a request and its attempts in its span and in Config.Metrics.
+type requestObserver struct {
+	span     Span
+	start    time.Time
+	bytesOut int
+}
+
+func newRequestObserver(opts endpoint) *requestObserver {
+	return &requestObserver{span: startRequestSpan(opts), start: time.Now()}
+}
+
+// sent records an attempt of the request of bytesOut, its path, query and body.
+func (o *requestObserver) sent(attempt, bytesOut int) {
+	o.bytesOut += bytesOut
+	o.span.SetAttributes(SpanAttribute{SpanAttrRetryAttempt, attempt}, SpanAttribute{SpanAttrRequestBytes, bytesOut})
+}
+
+func (o *requestObserver) end(opts endpoint, val []byte, status StatusResponse, err error) {
+	endRequestSpan(o.span, val, status, err)
+	category := status.Category
+	if err == nil {
+		category = PNAcknowledgmentCategory
+	}
+	opts.config().metrics().RecordRequest(opts.operationType(), category, time.Since(o.start), o.bytesOut, len(val))
+}
+
+// executeRequestWithToken runs the request of opts with the token of
+// Config.TokenProvider. The access manager requests skip the provider, which
+// can grant the tokens with the client.
+func executeRequestWithToken(opts endpoint, obs *requestObserver) ([]byte, StatusResponse, error) {
+	tokens := opts.tokenManager()
+	switch opts.operationType() {
+	case PNSendFileToS3Operation, PNAccessManagerGrant, PNAccessManagerRevoke, PNAccessManagerGrantToken, PNAccessManagerRevokeToken:
+		return executeRequestWithFailover(opts, obs, 0)
+	}
+	if tokens.provider() == nil {
+		return executeRequestWithFailover(opts, obs, 0)
+	}
+
+	token, err := tokens.ensureToken()
+	if err != nil {
+		opts.getPubNub().loggerManager.LogError(err, "TokenProviderFailed", opts.operationType(), true)
+	}
+	val, status, err := executeRequestWithFailover(opts, obs, 0)
+	if err == nil || status.StatusCode != http.StatusForbidden {
+		return val, status, err
+	}
+
+	if _, refreshErr := tokens.refresh(token, "access denied"); refreshErr != nil {
+		opts.getPubNub().loggerManager.LogError(refreshErr, "TokenProviderFailed", opts.operationType(), true)
+		return val, status, err
+	}
+	opts.getPubNub().loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Retrying request with the refreshed token: operation=%s", opts.operationType()), false)
+	return executeRequestWithFailover(opts, obs, 1)
+}
+
+func executeRequestOnce(opts endpoint, obs *requestObserver, attempt int) ([]byte, StatusResponse, error) {
 	var err error
 
 	err = opts.validate()
@@ -120,6 +201,7 @@ func executeRequest(opts endpoint) ([]byte, StatusResponse, error) {
 
 	var req *http.Request
 	var requestBodyBytes []byte
+	var uploadBytes int
 
 	if opts.httpMethod() == "POST" {
 		var body io.Reader
@@ -137,6 +219,7 @@ func executeRequest(opts endpoint) ([]byte, StatusResponse, error) {
 			return nil, createStatus(PNUnknownCategory, "", ResponseInfo{}, err), err
 		}
 
+		uploadBytes = body.Len()
 		req, err = newRequestForMultipartWriter("POST", url.RequestURI(), &body, w, opts.config().UseHTTP2)
 		if err != nil {
 			opts.getPubNub().loggerManager.LogError(err, "CreateMultipartRequestFailed", opts.operationType(), true)
@@ -185,6 +268,24 @@ func executeRequest(opts endpoint) ([]byte, StatusResponse, error) {
 		req = setRequestContext(req, ctx)
 	}
 
+	intercepted := &InterceptedRequest{Operation: opts.operationType(), Options: opts, Request: req, Attempt: attempt}
+	signedQuery := url.RawQuery
+	return interceptRequest(opts, intercepted, func(intercepted *InterceptedRequest) ([]byte, StatusResponse, error) {
+		if err := resignInterceptedRequest(opts, intercepted.Request, signedQuery); err != nil {
+			opts.getPubNub().loggerManager.LogError(err, "SignInterceptedRequestFailed", opts.operationType(), true)
+			return nil, createStatus(PNUnknownCategory, "", ResponseInfo{Operation: opts.operationType()}, err), err
+		}
+		return sendRequest(opts, obs, intercepted, url, requestBodyBytes, uploadBytes)
+	})
+}
+
+// sendRequest sends the request of opts after the interceptors and parses its response.
+func sendRequest(opts endpoint, obs *requestObserver, intercepted *InterceptedRequest, url *url.URL, requestBodyBytes []byte, uploadBytes int) ([]byte, StatusResponse, error) {
+	req := intercepted.Request
+	if req.URL.IsAbs() {
+		url = req.URL
+	}
+	ctx := opts.context()
 	client := opts.client()
 
 	// Log the outgoing network request
@@ -203,11 +304,13 @@ func executeRequest(opts endpoint) ([]byte, StatusResponse, error) {
 			}
 		}
 	}
-	opts.getPubNub().loggerManager.LogNetworkRequest(PNLogLevelDebug, req.Method, req.URL.String(), requestHeaders, requestBody, true)
+	opts.getPubNub().loggerManager.LogNetworkRequest(PNLogLevelDebug, opts.operationType(), req.Method, req.URL.String(), requestHeaders, requestBody, true)
+	obs.sent(intercepted.Attempt, len(req.URL.RequestURI())+len(requestBodyBytes)+uploadBytes)
 
 	startTimestamp := time.Now()
 
 	var res *http.Response
+	var err error
 	runRequestWorker := false
 
 	switch opts.operationType() {
@@ -0,0 +1,223 @@
+package pubnub
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestCacheConfig configures the coalescing and the cache of the read-only
+// requests GetChannelMetadata, GetUUIDMetadata, HereNow and Time, see
+// Config.RequestCache. The concurrent identical requests share one request,
+// and its successful response is cached for TTL. The objects and presence
+// events of the subscribe loop drop the matching cached responses.
+type RequestCacheConfig struct {
+	// TTL is the time the successful responses are cached, the requests are
+	// only coalesced when 0.
+	TTL time.Duration
+	// MaxEntries is the maximum number of cached responses, 1000 when 0.
+	MaxEntries int
+}
+
+func (c *RequestCacheConfig) maxEntries() int {
+	if c.MaxEntries <= 0 {
+		return 1000
+	}
+	return c.MaxEntries
+}
+
+// volatileQueryParams are the query parameters left out of the cache keys,
+// with the latencies of the telemetry.
+var volatileQueryParams = []string{"requestid", "timestamp", "signature"}
+
+// cachedEndpoint is implemented by the read-only endpoints whose requests are
+// coalesced and cached.
+type cachedEndpoint interface {
+	// cacheTags returns the tags of the events which invalidate the response.
+	cacheTags() []string
+	// bypassCache checks whether NoCache was set on the request.
+	bypassCache() bool
+}
+
+// cacheTagPresence is the tag of the global HereNow, invalidated by all the
+// presence events.
+const cacheTagPresence = "presence"
+
+func channelMetadataCacheTag(channel string) string {
+	return "channel:" + channel
+}
+
+func uuidMetadataCacheTag(uuid string) string {
+	return "uuid:" + uuid
+}
+
+func presenceCacheTag(channel string) string {
+	return "presence:" + channel
+}
+
+func presenceGroupCacheTag(group string) string {
+	return "presence-cg:" + group
+}
+
+type cacheEntry struct {
+	val     []byte
+	status  StatusResponse
+	tags    []string
+	expires time.Time
+}
+
+// cacheFlight is an in-flight request shared by the identical requests.
+type cacheFlight struct {
+	done   chan struct{}
+	tags   []string
+	val    []byte
+	status StatusResponse
+	err    error
+	// cancelled is set when the context of the request was cancelled.
+	cancelled bool
+	// stale is set when an event invalidates the response before it arrives.
+	stale bool
+}
+
+// requestCache holds the cached and the in-flight requests of a client.
+type requestCache struct {
+	sync.Mutex
+	entries map[string]*cacheEntry
+	flights map[string]*cacheFlight
+}
+
+func newRequestCache() *requestCache {
+	return &requestCache{
+		entries: make(map[string]*cacheEntry),
+		flights: make(map[string]*cacheFlight),
+	}
+}
+
+// requestCacheKey returns the cache key of the request of opts, its operation
+// and its path and query without the volatile query parameters, and the tags
+// of its response. It returns false when the request isn't cached. The key is
+// built before the request is signed, the cache hits don't call the
+// Config.RequestSigner.
+func requestCacheKey(opts endpoint) (string, []string, bool) {
+	e, ok := opts.(cachedEndpoint)
+	if !ok || opts.config().RequestCache == nil || e.bypassCache() || opts.validate() != nil {
+		return "", nil, false
+	}
+	path, query, err := buildPathAndQuery(opts)
+	if err != nil {
+		return "", nil, false
+	}
+	for name := range *query {
+		if strings.HasPrefix(name, "l_") {
+			query.Del(name)
+		}
+	}
+	for _, name := range volatileQueryParams {
+		query.Del(name)
+	}
+	return fmt.Sprintf("%s %s?%s", opts.operationType(), path, query.Encode()), e.cacheTags(), true
+}
+
+// do runs execute for the request of opts, or returns the response cached or
+// in flight for key.
+func (c *requestCache) do(opts endpoint, key string, tags []string, execute func(endpoint) ([]byte, StatusResponse, error)) ([]byte, StatusResponse, error) {
+	pn := opts.getPubNub()
+	cfg := opts.config().RequestCache
+
+	c.Lock()
+	if e, ok := c.entries[key]; ok {
+		if time.Now().Before(e.expires) {
+			c.Unlock()
+			pn.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Request served from cache: operation=%s", opts.operationType()), false)
+			return e.val, e.status, nil
+		}
+		delete(c.entries, key)
+	}
+	if f, ok := c.flights[key]; ok {
+		c.Unlock()
+		return c.wait(opts, f, execute)
+	}
+	f := &cacheFlight{done: make(chan struct{}), tags: tags}
+	c.flights[key] = f
+	c.Unlock()
+
+	f.val, f.status, f.err = execute(opts)
+	f.cancelled = opts.context() != nil && opts.context().Err() != nil
+
+	c.Lock()
+	delete(c.flights, key)
+	if f.err == nil && !f.stale && cfg.TTL > 0 {
+		c.store(key, &cacheEntry{val: f.val, status: f.status, tags: tags, expires: time.Now().Add(cfg.TTL)}, cfg.maxEntries())
+	}
+	c.Unlock()
+	close(f.done)
+
+	return f.val, f.status, f.err
+}
+
+// wait returns the response of the flight f, or runs execute when the request
+// of f was cancelled.
+func (c *requestCache) wait(opts endpoint, f *cacheFlight, execute func(endpoint) ([]byte, StatusResponse, error)) ([]byte, StatusResponse, error) {
+	var cancel <-chan struct{}
+	if ctx := opts.context(); ctx != nil {
+		cancel = ctx.Done()
+	}
+	select {
+	case <-f.done:
+	case <-cancel:
+		err := opts.context().Err()
+		return nil, createStatus(PNCancelledCategory, "", ResponseInfo{Operation: opts.operationType()}, err), err
+	}
+	if f.cancelled {
+		return execute(opts)
+	}
+	opts.getPubNub().loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Request coalesced: operation=%s", opts.operationType()), false)
+	return f.val, f.status, f.err
+}
+
+// store adds e, and drops the expired entries and then the oldest one when
+// the cache is full.
+func (c *requestCache) store(key string, e *cacheEntry, maxEntries int) {
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxEntries {
+		now := time.Now()
+		oldest := ""
+		for k, v := range c.entries {
+			if !now.Before(v.expires) {
+				delete(c.entries, k)
+			} else if oldest == "" || v.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= maxEntries {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = e
+}
+
+// invalidate drops the cached responses with one of tags, and keeps the
+// responses in flight with one of them out of the cache.
+func (c *requestCache) invalidate(tags ...string) {
+	c.Lock()
+	defer c.Unlock()
+	for key, e := range c.entries {
+		if hasCacheTag(e.tags, tags) {
+			delete(c.entries, key)
+		}
+	}
+	for _, f := range c.flights {
+		if hasCacheTag(f.tags, tags) {
+			f.stale = true
+		}
+	}
+}
+
+func hasCacheTag(tags, invalidated []string) bool {
+	for _, tag := range invalidated {
+		if indexOf(tags, tag) >= 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package pubnub
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequestCacheTestPubNub(t *testing.T, cfg *RequestCacheConfig, handler http.HandlerFunc) (*PubNub, func() []testRequest) {
+	pn, requests := newRecordingTestServerPubNub(t, handler)
+	pn.Config.RequestCache = cfg
+	return pn, requests
+}
+
+func writeRequestCacheTestResponse(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.Contains(r.URL.Path, "/v2/objects/"):
+		w.Write([]byte(`{"status":200,"data":{"id":"ch","name":"Channel"}}`))
+	case strings.Contains(r.URL.Path, "/v2/presence/"):
+		w.Write([]byte(`{"status":200,"message":"OK","occupancy":1,"uuids":["me"],"service":"Presence"}`))
+	default:
+		w.Write([]byte(`[15000000000000000]`))
+	}
+}
+
+func TestRequestCacheCoalescesConcurrentRequests(t *testing.T) {
+	assert := assert.New(t)
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	pn, requests := newRequestCacheTestPubNub(t, &RequestCacheConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		writeRequestCacheTestResponse(w, r)
+	})
+
+	var wg sync.WaitGroup
+	results := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, _, err := pn.GetChannelMetadata().Channel("ch").Execute()
+			if assert.NoError(err) {
+				results <- res.Data.Name
+			}
+		}()
+	}
+	<-arrived
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	assert.Len(requests(), 1)
+	for name := range results {
+		assert.Equal("Channel", name)
+	}
+
+	// Without a TTL the next request isn't cached.
+	_, _, err := pn.GetChannelMetadata().Channel("ch").Execute()
+	assert.NoError(err)
+	assert.Len(requests(), 2)
+}
+
+func TestRequestCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRequestCacheTestPubNub(t, &RequestCacheConfig{TTL: 100 * time.Millisecond}, writeRequestCacheTestResponse)
+
+	for i := 0; i < 3; i++ {
+		res, _, err := pn.Time().Execute()
+		require.NoError(t, err)
+		assert.Equal(int64(15000000000000000), res.Timetoken)
+	}
+	assert.Len(requests(), 1)
+
+	// NoCache and the other parameters bypass the cached response.
+	_, _, err := pn.Time().NoCache().Execute()
+	assert.NoError(err)
+	_, _, err = pn.Time().QueryParam(map[string]string{"a": "b"}).Execute()
+	assert.NoError(err)
+	assert.Len(requests(), 3)
+
+	time.Sleep(120 * time.Millisecond)
+	_, _, err = pn.Time().Execute()
+	assert.NoError(err)
+	assert.Len(requests(), 4)
+}
+
+func TestRequestCacheSkipsErrors(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRequestCacheTestPubNub(t, &RequestCacheConfig{TTL: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, _, err := pn.Time().Execute()
+		assert.NotNil(err)
+	}
+	assert.Len(requests(), 2)
+}
+
+func TestRequestCacheInvalidatedByEvents(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRequestCacheTestPubNub(t, &RequestCacheConfig{TTL: time.Minute}, writeRequestCacheTestResponse)
+
+	execute := func() {
+		_, _, err := pn.GetChannelMetadata().Channel("ch").Execute()
+		assert.NoError(err)
+		_, _, err = pn.HereNow().Channels([]string{"ch"}).Execute()
+		assert.NoError(err)
+	}
+	execute()
+	execute()
+	assert.Len(requests(), 2)
+
+	// An objects event of another channel keeps the cached responses.
+	objectsEvent := func(id string) subscribeMessage {
+		return subscribeMessage{
+			Shard:   "1",
+			Channel: id,
+			Payload: map[string]interface{}{
+				"type":    "channel",
+				"event":   "set",
+				"version": "2.0",
+				"data":    map[string]interface{}{"id": id},
+			},
+			MessageType: PNMessageTypeObjects,
+		}
+	}
+	processSubscribePayload(pn.subscriptionManager, objectsEvent("other"))
+	execute()
+	assert.Len(requests(), 2)
+
+	processSubscribePayload(pn.subscriptionManager, objectsEvent("ch"))
+	execute()
+	assert.Len(requests(), 3)
+
+	processSubscribePayload(pn.subscriptionManager, subscribeMessage{
+		Shard:   "1",
+		Channel: "ch-pnpres",
+		Payload: map[string]interface{}{"action": "join", "uuid": "other", "occupancy": float64(2)},
+	})
+	execute()
+	assert.Len(requests(), 4)
+}
+
+func TestRequestCacheFollowerContextCancelled(t *testing.T) {
+	assert := assert.New(t)
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	pn, requests := newRequestCacheTestPubNub(t, &RequestCacheConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		writeRequestCacheTestResponse(w, r)
+	})
+	defer close(release)
+
+	go pn.Time().Execute()
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, status, err := pn.TimeWithContext(ctx).Execute()
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.Equal(PNCancelledCategory, status.Category)
+	assert.Len(requests(), 1)
+}
+
+type countingRequestSigner struct {
+	calls int32
+}
+
+func (s *countingRequestSigner) Sign(ctx Context, req SignatureRequest) (string, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return "sig", nil
+}
+
+func TestRequestCacheHitsAreNotSigned(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRequestCacheTestPubNub(t, &RequestCacheConfig{TTL: time.Minute}, writeRequestCacheTestResponse)
+	signer := &countingRequestSigner{}
+	pn.Config.RequestSigner = signer
+
+	for i := 0; i < 3; i++ {
+		_, _, err := pn.GetChannelMetadata().Channel("ch").Execute()
+		require.NoError(t, err)
+	}
+	assert.Len(requests(), 1)
+	assert.Equal("sig", requests()[0].URL.Query().Get("signature"))
+	assert.Equal(int32(1), atomic.LoadInt32(&signer.calls))
+}
@@ -0,0 +1,150 @@
+package pubnub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pubnub/go/v9/utils"
+)
+
+// SignatureRequest is the canonical form of a request signed by a RequestSigner.
+type SignatureRequest struct {
+	// Version is 2 for the access manager v3 signatures, which sign Method and
+	// Body, and 1 for the legacy ones, which sign SubscribeKey instead.
+	Version      int    `json:"version"`
+	Method       string `json:"method"`
+	SubscribeKey string `json:"subscribeKey"`
+	PublishKey   string `json:"publishKey"`
+	Path         string `json:"path"`
+	// Query is the sorted and encoded query, with its timestamp, as signed.
+	Query string `json:"query"`
+	Body  string `json:"body"`
+}
+
+// RequestSigner signs the requests of the client, see Config.RequestSigner.
+type RequestSigner interface {
+	// Sign returns the signature query param of req.
+	Sign(ctx Context, req SignatureRequest) (string, error)
+}
+
+// HMACRequestSigner signs the requests with the secret key of the keyset. It is
+// the signer of the clients with a Config.SecretKey.
+type HMACRequestSigner struct {
+	SecretKey string
+}
+
+// Sign returns the HMAC-SHA256 signature of req.
+func (s *HMACRequestSigner) Sign(ctx Context, req SignatureRequest) (string, error) {
+	if s.SecretKey == "" {
+		return "", errors.New(StrMissingSecretKey)
+	}
+	if req.Version == 2 {
+		return createSignatureV2FromStrings(req.Method, req.PublishKey, s.SecretKey, req.Path, req.Query, req.Body), nil
+	}
+	return utils.GetHmacSha256(s.SecretKey, req.SubscribeKey+"\n"+req.PublishKey+"\n"+req.Path+"\n"+req.Query), nil
+}
+
+// HTTPRequestSigner signs the requests with a signing service, so that the
+// secret key doesn't live in the client process. The service receives the
+// SignatureRequest as a JSON POST and responds with {"signature": "..."}.
+type HTTPRequestSigner struct {
+	URL string
+	// Client is the HTTP client of the requests to the service, http.DefaultClient when nil.
+	Client *http.Client
+	// Header is added to the requests to the service, for ex. to authenticate them.
+	Header http.Header
+}
+
+// Sign calls the signing service for req.
+func (s *HTTPRequestSigner) Sign(ctx Context, req SignatureRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	if ctx != nil {
+		httpReq = httpReq.WithContext(ctx)
+	}
+	for k, v := range s.Header {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("signing service responded %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var res struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return "", fmt.Errorf("invalid signing service response: %v", err)
+	}
+	if res.Signature == "" {
+		return "", errors.New("signing service returned an empty signature")
+	}
+	return res.Signature, nil
+}
+
+// requestSigner returns Config.RequestSigner, or the HMAC signer of
+// Config.SecretKey, or nil when the requests aren't signed.
+func (c *Config) requestSigner() RequestSigner {
+	if c.RequestSigner != nil {
+		return c.RequestSigner
+	}
+	if c.SecretKey != "" {
+		return &HMACRequestSigner{SecretKey: c.SecretKey}
+	}
+	return nil
+}
+
+// signRequest returns the signature of the request of o with path and query.
+func signRequest(o endpoint, signer RequestSigner, path string, query *url.Values) (string, error) {
+	req := SignatureRequest{
+		Version:      1,
+		SubscribeKey: o.config().SubscribeKey,
+		PublishKey:   o.config().PublishKey,
+		Path:         path,
+		Query:        utils.PreparePamParams(query),
+	}
+	if o.config().UsePAMV3 && !(o.operationType() == PNPublishOperation && o.httpMethod() == "POST") {
+		req.Version = 2
+		req.Method = o.httpMethod()
+		b, err := o.buildBody()
+		if err == nil {
+			req.Body = string(b)
+		} else {
+			o.getPubNub().loggerManager.LogSimple(PNLogLevelWarn, fmt.Sprintf("PAM: buildBody error: %v", err.Error()), false)
+		}
+	}
+	o.getPubNub().loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("PAM: signing version=%d method=%s path=%s query=%s", req.Version, req.Method, req.Path, req.Query), false)
+
+	sig, err := signer.Sign(o.context(), req)
+	if err != nil {
+		return "", err
+	}
+	o.getPubNub().loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("PAM: signature=%s", sig), false)
+	return sig, nil
+}
@@ -0,0 +1,77 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pubnub/go/v9/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACRequestSigner(t *testing.T) {
+	assert := assert.New(t)
+	signer := &HMACRequestSigner{SecretKey: "wMfbo9G0xVUG8yfTfYw5qIdfJkTd7A"}
+
+	sig, err := signer.Sign(nil, SignatureRequest{Version: 2, Method: "GET", PublishKey: "demo", Path: "/v2/auth/grant/sub-key/demo", Query: "PoundsSterling=%C2%A313.37&timestamp=123456789"})
+	assert.Nil(err)
+	assert.Equal(createSignatureV2FromStrings("GET", "demo", signer.SecretKey, "/v2/auth/grant/sub-key/demo", "PoundsSterling=%C2%A313.37&timestamp=123456789", ""), sig)
+
+	sig, err = signer.Sign(nil, SignatureRequest{Version: 1, SubscribeKey: "sub", PublishKey: "pub", Path: "/publish", Query: "timestamp=1"})
+	assert.Nil(err)
+	assert.Equal(utils.GetHmacSha256(signer.SecretKey, "sub\npub\n/publish\ntimestamp=1"), sig)
+
+	_, err = (&HMACRequestSigner{}).Sign(nil, SignatureRequest{})
+	assert.NotNil(err)
+}
+
+func TestHTTPRequestSignerGrantToken(t *testing.T) {
+	assert := assert.New(t)
+	var signed SignatureRequest
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal("Bearer edge", req.Header.Get("Authorization"))
+		b, _ := io.ReadAll(req.Body)
+		require.NoError(t, json.Unmarshal(b, &signed))
+		fmt.Fprint(w, `{"signature":"v2.sidecar"}`)
+	}))
+	defer sidecar.Close()
+
+	var signature string
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		signature = req.URL.Query().Get("signature")
+		fmt.Fprint(w, `{"status":200,"data":{"message":"Success","token":"tok"}}`)
+	})
+	pn.Config.RequestSigner = &HTTPRequestSigner{URL: sidecar.URL, Header: http.Header{"Authorization": {"Bearer edge"}}}
+
+	res, _, err := pn.GrantToken().TTL(10).Channels(map[string]ChannelPermissions{"ch": {Read: true}}).Execute()
+	require.NoError(t, err)
+	assert.Equal("tok", res.Data.Token)
+	assert.Equal("v2.sidecar", signature)
+	assert.Equal(2, signed.Version)
+	assert.Equal("POST", signed.Method)
+	assert.Equal("pub", signed.PublishKey)
+	assert.Equal("/v3/pam/sub/grant", signed.Path)
+	assert.Contains(signed.Query, "timestamp=")
+	assert.Contains(signed.Body, `"ch":1`)
+}
+
+func TestHTTPRequestSignerError(t *testing.T) {
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer sidecar.Close()
+
+	requests := 0
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	})
+	pn.Config.RequestSigner = &HTTPRequestSigner{URL: sidecar.URL}
+
+	_, _, err := pn.RevokeToken().Token("tok").Execute()
+	assert.ErrorContains(t, err, "signing service responded 403: denied")
+	assert.Equal(t, 0, requests)
+}
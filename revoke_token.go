@@ -87,7 +87,7 @@ func (o *revokeTokenOpts) validate() error {
 		return newValidationError(o, StrMissingSubKey)
 	}
 
-	if o.config().SecretKey == "" {
+	if o.config().requestSigner() == nil {
 		return newValidationError(o, StrMissingSecretKey)
 	}
 
@@ -0,0 +1,195 @@
+package pubnub
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/pubnub/go/v9/pnerr"
+)
+
+// sealedBoxCustomKey is the App Context UUID Custom key of the sealed box public key.
+const sealedBoxCustomKey = "pn_x25519"
+
+// maxCachedSealedBoxKeys is the number of cached keys above which the expired
+// keys are removed.
+const maxCachedSealedBoxKeys = 1000
+
+// sealedBoxKeyCache caches the sealed box public keys read from App Context
+// for Config.SealedBoxKeyTTL.
+type sealedBoxKeyCache struct {
+	sync.Mutex
+	keys map[string]cachedSealedBoxKey
+}
+
+type cachedSealedBoxKey struct {
+	key     *ecdh.PublicKey
+	expires time.Time
+}
+
+func newSealedBoxKeyCache() *sealedBoxKeyCache {
+	return &sealedBoxKeyCache{keys: make(map[string]cachedSealedBoxKey)}
+}
+
+func (c *sealedBoxKeyCache) get(uuid string, now time.Time) *ecdh.PublicKey {
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.keys[uuid]
+	if !ok || !now.Before(entry.expires) {
+		return nil
+	}
+	return entry.key
+}
+
+func (c *sealedBoxKeyCache) set(uuid string, key *ecdh.PublicKey, expires time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.keys) >= maxCachedSealedBoxKeys {
+		now := time.Now()
+		for k, entry := range c.keys {
+			if !now.Before(entry.expires) {
+				delete(c.keys, k)
+			}
+		}
+	}
+	c.keys[uuid] = cachedSealedBoxKey{key: key, expires: expires}
+}
+
+func (c *sealedBoxKeyCache) remove(uuid string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.keys, uuid)
+}
+
+// PublishSealedBoxKey stores the public key of Config.SealedBoxKey in the
+// Custom field of the App Context metadata of Config.UUID, so that other
+// clients can send it direct messages with Publish().Recipients. The other
+// Custom keys are kept.
+func (pn *PubNub) PublishSealedBoxKey() error {
+	key := pn.Config.SealedBoxKey
+	if key == nil {
+		return pnerr.NewValidationError(PNSetUUIDMetadataOperation.String(), "Missing SealedBoxKey")
+	}
+
+	custom := make(map[string]interface{})
+	var eTag string
+	res, status, err := pn.GetUUIDMetadata().UUID(pn.Config.UUID).Include([]PNUUIDMetadataInclude{PNUUIDMetadataIncludeCustom}).Execute()
+	switch {
+	case err == nil:
+		for k, v := range res.Data.Custom {
+			custom[k] = v
+		}
+		eTag = res.Data.ETag
+	case status.StatusCode != http.StatusNotFound:
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	if custom[sealedBoxCustomKey] == encoded {
+		return nil
+	}
+	custom[sealedBoxCustomKey] = encoded
+
+	set := pn.SetUUIDMetadata().UUID(pn.Config.UUID).Custom(custom)
+	if eTag != "" {
+		set = set.IfMatchETag(eTag)
+	}
+	if _, _, err := set.Execute(); err != nil {
+		return err
+	}
+	pn.sealedBoxKeys.remove(pn.Config.UUID)
+	pn.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Sealed box public key published: uuid=%s", pn.Config.UUID), false)
+	return nil
+}
+
+// GetSealedBoxKey returns the sealed box public key stored in the App Context
+// metadata of uuid. The key is as trustworthy as the access to the metadata:
+// a client allowed to update the metadata of uuid can replace the key and
+// read the next direct messages. Restrict the updates with Access Manager or
+// pin the keys with Config.SealedBoxPublicKeys.
+func (pn *PubNub) GetSealedBoxKey(uuid string) (*ecdh.PublicKey, error) {
+	res, _, err := pn.GetUUIDMetadata().UUID(uuid).Include([]PNUUIDMetadataInclude{PNUUIDMetadataIncludeCustom}).Execute()
+	if err != nil {
+		return nil, err
+	}
+	encoded, ok := res.Data.Custom[sealedBoxCustomKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("no sealed box public key for uuid %s", uuid)
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed box public key for uuid %s: %w", uuid, err)
+	}
+	key, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed box public key for uuid %s: %w", uuid, err)
+	}
+	return key, nil
+}
+
+// sealedBoxModule returns the module encrypting for the sealed box public keys of the recipients.
+func (pn *PubNub) sealedBoxModule(recipients []string) (crypto.CryptoModule, error) {
+	if pn.Config.SealedBoxKey == nil {
+		return nil, pnerr.NewValidationError(PNPublishOperation.String(), "Missing SealedBoxKey")
+	}
+	keys := make([]*ecdh.PublicKey, len(recipients))
+	for i, uuid := range recipients {
+		key, err := pn.sealedBoxPublicKey(uuid)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return crypto.NewSealedBoxCryptoModule(pn.Config.SealedBoxKey, keys...)
+}
+
+// sealedBoxPublicKey returns the sealed box public key of a recipient: the key
+// pinned in Config.SealedBoxPublicKeys, or the key in its App Context
+// metadata, cached for Config.SealedBoxKeyTTL.
+func (pn *PubNub) sealedBoxPublicKey(uuid string) (*ecdh.PublicKey, error) {
+	if key, ok := pn.Config.SealedBoxPublicKeys[uuid]; ok {
+		return key, nil
+	}
+	now := time.Now()
+	if key := pn.sealedBoxKeys.get(uuid, now); key != nil {
+		return key, nil
+	}
+	key, err := pn.GetSealedBoxKey(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if ttl := pn.Config.SealedBoxKeyTTL; ttl > 0 {
+		pn.sealedBoxKeys.set(uuid, key, now.Add(time.Duration(ttl)*time.Second))
+	}
+	return key, nil
+}
+
+// getDecryptionCryptoModule returns the module decrypting data received on
+// channel: the sealed box module of Config.SealedBoxKey when data is a sealed
+// box, or the crypto module of the channel.
+func (pn *PubNub) getDecryptionCryptoModule(channel string, data interface{}) crypto.CryptoModule {
+	if pn.Config.SealedBoxKey != nil && isSealedBoxPayload(data, pn.Config.DisablePNOtherProcessing) {
+		if module, err := crypto.NewSealedBoxCryptoModule(pn.Config.SealedBoxKey); err == nil {
+			return module
+		}
+	}
+	return pn.getChannelCryptoModule(channel)
+}
+
+// isSealedBoxPayload returns whether the encrypted part of a message payload,
+// the payload or its pn_other field, is a base64 sealed box.
+func isSealedBoxPayload(data interface{}, disablePNOtherProcessing bool) bool {
+	encrypted, ok := data.(string)
+	if m, isMap := data.(map[string]interface{}); isMap && !disablePNOtherProcessing {
+		encrypted, ok = m["pn_other"].(string)
+	}
+	if !ok {
+		return false
+	}
+	b, err := base64.StdEncoding.DecodeString(encrypted)
+	return err == nil && crypto.IsSealedBox(b)
+}
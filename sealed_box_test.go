@@ -0,0 +1,187 @@
+package pubnub
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pubnub/go/v9/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSealedBoxTestPubNub serves the sealed box public keys of the UUIDs and
+// returns the bodies of the published messages.
+func newSealedBoxTestPubNub(t *testing.T, keys map[string]*ecdh.PrivateKey) (*PubNub, func() []string) {
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/v2/objects/sub/uuids/"):
+			uuid := strings.TrimPrefix(req.URL.Path, "/v2/objects/sub/uuids/")
+			key, ok := keys[uuid]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"status":404,"error":{"message":"Requested object was not found."}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"status":200,"data":{"id":%q,"custom":{"pn_x25519":%q}}}`, uuid, base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()))
+		case strings.HasPrefix(req.URL.Path, "/publish/"):
+			fmt.Fprint(w, `[1,"Sent","15000000000000000"]`)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	})
+	return pn, func() []string {
+		var published []string
+		for _, req := range requests() {
+			if strings.HasPrefix(req.URL.Path, "/publish/") {
+				published = append(published, req.body)
+			}
+		}
+		return published
+	}
+}
+
+func TestPublishRecipientsSealedBox(t *testing.T) {
+	assert := assert.New(t)
+	keys := make(map[string]*ecdh.PrivateKey)
+	for _, uuid := range []string{"me", "alice", "eve"} {
+		key, err := crypto.GenerateSealedBoxKey()
+		require.NoError(t, err)
+		keys[uuid] = key
+	}
+	pn, published := newSealedBoxTestPubNub(t, keys)
+	pn.Config.SealedBoxKey = keys["me"]
+	shared, err := crypto.NewAesCbcCryptoModule("enigma", true)
+	require.NoError(t, err)
+	pn.Config.CryptoModule = shared
+
+	_, _, err = pn.Publish().Channel("dm").Message(map[string]interface{}{"text": "hi"}).Recipients("alice").UsePost(true).Execute()
+	require.NoError(t, err)
+	require.Len(t, published(), 1)
+	var payload interface{}
+	require.NoError(t, json.Unmarshal([]byte(published()[0]), &payload))
+	assert.True(isSealedBoxPayload(payload, false))
+
+	// The recipient and the sender decrypt the message, even with another crypto module.
+	for _, uuid := range []string{"alice", "me"} {
+		receiver := NewPubNub(NewConfigWithUserId(UserId(uuid)))
+		receiver.Config.SealedBoxKey = keys[uuid]
+		receiver.Config.CryptoModule = shared
+		msg, err := parseCipherInterface(payload, receiver, "dm")
+		assert.Nil(err, uuid)
+		assert.Equal(map[string]interface{}{"text": "hi"}, msg, uuid)
+	}
+
+	eve := NewPubNub(NewConfigWithUserId(UserId("eve")))
+	eve.Config.SealedBoxKey = keys["eve"]
+	_, err = parseCipherInterface(payload, eve, "dm")
+	assert.NotNil(err)
+
+	// The messages without recipients still use the crypto module.
+	_, _, err = pn.Publish().Channel("dm").Message("plain").UsePost(true).Execute()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(published()[1]), &payload))
+	assert.False(isSealedBoxPayload(payload, false))
+	msg, err := parseCipherInterface(payload, pn, "dm")
+	assert.Nil(err)
+	assert.Equal("plain", msg)
+}
+
+func TestPublishRecipientsErrors(t *testing.T) {
+	key, err := crypto.GenerateSealedBoxKey()
+	require.NoError(t, err)
+	pn, published := newSealedBoxTestPubNub(t, map[string]*ecdh.PrivateKey{"me": key})
+
+	_, _, err = pn.Publish().Channel("dm").Message("hi").Recipients("me").Execute()
+	assert.ErrorContains(t, err, "Missing SealedBoxKey")
+
+	pn.Config.SealedBoxKey = key
+	_, _, err = pn.Publish().Channel("dm").Message("hi").Recipients("unknown").Execute()
+	assert.NotNil(t, err)
+	assert.Empty(t, published())
+}
+
+func TestPublishSealedBoxKey(t *testing.T) {
+	assert := assert.New(t)
+	key, err := crypto.GenerateSealedBoxKey()
+	require.NoError(t, err)
+
+	var setBody map[string]interface{}
+	var ifMatch string
+	pn := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{"status":200,"data":{"id":"me","eTag":"tag","custom":{"color":"red"}}}`)
+		case http.MethodPatch:
+			ifMatch = req.Header.Get("If-Match")
+			b, _ := io.ReadAll(req.Body)
+			require.NoError(t, json.Unmarshal(b, &setBody))
+			fmt.Fprint(w, `{"status":200,"data":{"id":"me"}}`)
+		}
+	})
+
+	assert.NotNil(pn.PublishSealedBoxKey())
+
+	pn.Config.SealedBoxKey = key
+	assert.Nil(pn.PublishSealedBoxKey())
+	assert.Equal("tag", ifMatch)
+	assert.Equal(map[string]interface{}{
+		"color":            "red",
+		sealedBoxCustomKey: base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()),
+	}, setBody["custom"])
+}
+
+func TestPublishRecipientsKeyCacheAndPinnedKeys(t *testing.T) {
+	assert := assert.New(t)
+	keys := make(map[string]*ecdh.PrivateKey)
+	for _, uuid := range []string{"me", "alice", "bob"} {
+		key, err := crypto.GenerateSealedBoxKey()
+		require.NoError(t, err)
+		keys[uuid] = key
+	}
+	pn, _ := newSealedBoxTestPubNub(t, keys)
+	var lookups []string
+	pn.Config.Interceptors = []Interceptor{InterceptorFunc(func(req *InterceptedRequest, next InterceptorNext) ([]byte, StatusResponse, error) {
+		if req.Operation == PNGetUUIDMetadataOperation {
+			lookups = append(lookups, req.Request.URL.Path)
+		}
+		return next(req)
+	})}
+	pn.Config.SealedBoxKey = keys["me"]
+	assert.Equal(300, pn.Config.SealedBoxKeyTTL)
+
+	// The keys read from App Context are cached.
+	for i := 0; i < 2; i++ {
+		_, _, err := pn.Publish().Channel("dm").Message("hi").Recipients("alice").Execute()
+		require.NoError(t, err)
+	}
+	assert.Len(lookups, 1)
+
+	pn.Config.SealedBoxKeyTTL = 0
+	for i := 0; i < 2; i++ {
+		_, _, err := pn.Publish().Channel("dm").Message("hi").Recipients("bob").Execute()
+		require.NoError(t, err)
+	}
+	assert.Len(lookups, 3)
+
+	// The pinned keys are used instead of the keys in App Context.
+	pinned, err := crypto.GenerateSealedBoxKey()
+	require.NoError(t, err)
+	pn.Config.SealedBoxPublicKeys = map[string]*ecdh.PublicKey{"carol": pinned.PublicKey()}
+	module, err := pn.sealedBoxModule([]string{"carol"})
+	require.NoError(t, err)
+	assert.Len(lookups, 3)
+
+	encrypted, err := module.Encrypt([]byte("hi"))
+	require.NoError(t, err)
+	carol, err := crypto.NewSealedBoxCryptoModule(pinned)
+	require.NoError(t, err)
+	decrypted, err := carol.Decrypt(encrypted)
+	assert.Nil(err)
+	assert.Equal([]byte("hi"), decrypted)
+}
@@ -19,6 +19,9 @@ var emptySignalResponse *SignalResponse
 const signalGetPath = "/signal/%s/%s/0/%s/%s/%s"
 const signalPostPath = "/signal/%s/%s/0/%s/%s"
 
+// maxSignalPayloadSize is the default size limit, in bytes, of the JSON encoded Signal payload.
+const maxSignalPayloadSize = 64
+
 type signalBuilder struct {
 	opts *signalOpts
 }
@@ -209,6 +212,18 @@ func (o *signalOpts) connectTimeout() int {
 	return o.pubnub.Config.ConnectTimeout
 }
 
+func (o *signalOpts) channelCount() int {
+	return 1
+}
+
+func (o *signalOpts) channelName() string {
+	return o.Channel
+}
+
+func (o *signalOpts) message() interface{} {
+	return o.Message
+}
+
 func (o *signalOpts) operationType() OperationType {
 	return PNSignalOperation
 }
@@ -195,6 +195,18 @@ func (o *subscribeOpts) requestTimeout() int {
 	return o.pubnub.Config.SubscribeRequestTimeout
 }
 
+func (o *subscribeOpts) channelCount() int {
+	return len(o.Channels) + len(o.ChannelGroups)
+}
+
+func (o *subscribeOpts) channelList() []string {
+	return o.Channels
+}
+
+func (o *subscribeOpts) channelGroupList() []string {
+	return o.ChannelGroups
+}
+
 func (o *subscribeOpts) operationType() OperationType {
 	return PNSubscribeOperation
 }
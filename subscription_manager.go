@@ -64,6 +64,7 @@ type SubscriptionManager struct {
 	queryParam                   map[string]string
 	channelsOpen                 bool
 	requestSentAt                int64
+	chunks                       *chunkReassembler
 }
 
 // SubscribeOperation is the type to store the subscribe op params
@@ -107,6 +108,7 @@ func newSubscriptionManager(pubnub *PubNub, ctx Context) *SubscriptionManager {
 	manager.messages = make(chan subscribeMessage, 1000)
 	manager.reconnectionManager = newReconnectionManager(pubnub)
 	manager.channelsOpen = true
+	manager.chunks = newChunkReassembler(manager.announceIncompleteChunkedMessage)
 	manager.Unlock()
 
 	if manager.pubnub.Config.PNReconnectionPolicy != PNNonePolicy {
@@ -166,6 +168,7 @@ func (m *SubscriptionManager) Destroy() {
 	if m.subscribeCancel != nil {
 		m.subscribeCancel()
 	}
+	m.chunks.reset()
 	if m.channelsOpen {
 		m.RLock()
 		m.channelsOpen = false
@@ -322,10 +325,16 @@ func (m *SubscriptionManager) startSubscribeLoop() {
 		m.requestSentAt = time.Now().Unix()
 		m.hbDataMutex.Unlock()
 
-		res, _, err := executeRequest(opts)
+		res, status, err := executeRequest(opts)
 		if err != nil {
 			m.pubnub.loggerManager.LogError(err, "SubscribeRequestFailed", PNSubscribeOperation, true)
 
+			// The reconnect moves to the next origin of Config.Origins, with
+			// the same timetoken and region.
+			if (ctx == nil || ctx.Err() == nil) && !strings.Contains(err.Error(), "timeout") && isEndpointFailure(status, err) {
+				m.pubnub.originSelector.failed(m.pubnub, opts.origin(), PNSubscribeOperation, err)
+			}
+
 			if strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "request canceled") {
 				m.listenerManager.announceStatus(&PNStatus{
 					Category: PNTimeoutCategory,
@@ -436,6 +445,7 @@ func (m *SubscriptionManager) startSubscribeLoop() {
 			for _, message := range envelope.Messages {
 				m.messages <- message
 			}
+			m.pubnub.Config.metrics().RecordListenerQueueDepth(len(m.messages))
 		}
 
 		m.Lock()
@@ -519,17 +529,17 @@ func subscribeMessageWorker(m *SubscriptionManager) {
 
 	m.Lock()
 	if m.ctx == nil && m.subscribeCancel == nil {
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: setting context", false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: setting context", false)
 		m.ctx, m.subscribeCancel = contextWithCancel(backgroundContext)
 	}
 
-	m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: started", false)
+	m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: started", false)
 
 	m.Unlock()
 	m.exitSubscriptionManagerMutex.Lock()
 	if m.exitSubscriptionManager != nil {
 		m.exitSubscriptionManager <- true
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: signaled old worker to exit", false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: signaled old worker to exit", false)
 	}
 	m.exitSubscriptionManager = make(chan bool)
 	m.exitSubscriptionManagerMutex.Unlock()
@@ -540,19 +550,20 @@ SubscribeMessageWorkerLabel:
 		combinedGroups := m.stateManager.prepareGroupList(true)
 
 		if len(combinedChannels) == 0 && len(combinedGroups) == 0 {
-			m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: all channels unsubscribed", false)
+			m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: all channels unsubscribed", false)
 			break
 		}
 		select {
 		case <-m.exitSubscriptionManager:
-			m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: exit signal received", false)
+			m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: exit signal received", false)
 			break SubscribeMessageWorkerLabel
 		case message := <-m.messages:
-			m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: processing message", false)
+			m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: processing message", false)
+			m.pubnub.Config.metrics().RecordListenerQueueDepth(len(m.messages))
 			safeProcessSubscribePayload(m, message)
 		}
 	}
-	m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, "subscribeMessageWorker: exited", false)
+	m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, "subscribeMessageWorker: exited", false)
 
 }
 
@@ -650,10 +661,10 @@ func processPresencePayload(m *SubscriptionManager, payload subscribeMessage, ch
 	if presencePayload["occupancy"] != nil {
 		occupancyFromJSON, _ := presencePayload["occupancy"].(float64)
 		occupancy = int(occupancyFromJSON)
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Presence occupancy: %d", occupancy), false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Presence occupancy: %d", occupancy), false)
 	}
 	if presencePayload["timestamp"] != nil {
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Presence timestamp type: %v", reflect.TypeOf(presencePayload["timestamp"]).Kind()), false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Presence timestamp type: %v", reflect.TypeOf(presencePayload["timestamp"]).Kind()), false)
 		switch presencePayload["timestamp"].(type) {
 		case int:
 			timestamp = int64(presencePayload["timestamp"].(int))
@@ -703,6 +714,11 @@ func processPresencePayload(m *SubscriptionManager, payload subscribeMessage, ch
 		Timestamp:         timestamp,
 		HereNowRefresh:    hereNowRefresh,
 	}
+	if strippedPresenceSubscription != "" {
+		m.pubnub.requestCache.invalidate(cacheTagPresence, presenceCacheTag(strippedPresenceChannel), presenceGroupCacheTag(strippedPresenceSubscription))
+	} else {
+		m.pubnub.requestCache.invalidate(cacheTagPresence, presenceCacheTag(strippedPresenceChannel))
+	}
 	m.listenerManager.announcePresence(pnPresenceResult)
 }
 
@@ -715,28 +731,31 @@ func processNonPresencePayload(m *SubscriptionManager, payload subscribeMessage,
 		actualCh = channel
 		subscribedCh = subscriptionMatch
 	}
+	m.pubnub.Config.metrics().RecordMessageReceived(subscribedCh)
 	var messagePayload interface{}
 
 	switch payload.MessageType {
 	case PNMessageTypeSignal:
 		pnMessageResult := createPNMessageResult(payload.Payload, actualCh, subscribedCh, channel, subscriptionMatch, payload.IssuingClientID, payload.UserMetadata, timetoken, payload.CustomMessageType /*no error*/, nil)
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Announcing signal: channel=%s", channel), false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Announcing signal: channel=%s", channel), false)
 		m.listenerManager.announceSignal(pnMessageResult)
 	case PNMessageTypeObjects:
 		pnUUIDEvent, pnChannelEvent, pnMembershipEvent, eventType, ok := createPNObjectsResult(payload.Payload, m, actualCh, subscribedCh, channel, subscriptionMatch)
 		if !ok {
 			return
 		}
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Announcing objects event: type=%v, channel=%s", eventType, channel), false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Announcing objects event: type=%v, channel=%s", eventType, channel), false)
 		switch eventType {
 		case PNObjectsUUIDEvent:
-			m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("UUID event: %s", pnUUIDEvent.UUID), false)
+			m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("UUID event: %s", pnUUIDEvent.UUID), false)
+			m.pubnub.requestCache.invalidate(uuidMetadataCacheTag(pnUUIDEvent.UUID))
 			m.listenerManager.announceUUIDEvent(pnUUIDEvent)
 		case PNObjectsChannelEvent:
-			m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Channel event: %s", pnChannelEvent.ChannelID), false)
+			m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Channel event: %s", pnChannelEvent.ChannelID), false)
+			m.pubnub.requestCache.invalidate(channelMetadataCacheTag(pnChannelEvent.ChannelID))
 			m.listenerManager.announceChannelEvent(pnChannelEvent)
 		case PNObjectsMembershipEvent:
-			m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Membership event: %s", pnMembershipEvent.UUID), false)
+			m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Membership event: %s", pnMembershipEvent.UUID), false)
 			m.listenerManager.announceMembershipEvent(pnMembershipEvent)
 		}
 	case PNMessageTypeMessageActions:
@@ -744,11 +763,11 @@ func processNonPresencePayload(m *SubscriptionManager, payload subscribeMessage,
 		if !ok {
 			return
 		}
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Announcing message actions event: channel=%s", channel), false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Announcing message actions event: channel=%s", channel), false)
 		m.listenerManager.announceMessageActionsEvent(pnMessageActionsEvent)
 	case PNMessageTypeFile:
 		var err error
-		messagePayload, err = parseCipherInterface(payload.Payload, m.pubnub)
+		messagePayload, err = parseCipherInterface(payload.Payload, m.pubnub, channel)
 		if err != nil {
 			m.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Crypto: decryption of file message failed due to %v", err), false)
 			// Surface only a generic error to customers; the specific reason is kept at Debug level to avoid leaking a decryption failure oracle.
@@ -769,12 +788,42 @@ func processNonPresencePayload(m *SubscriptionManager, payload subscribeMessage,
 		if !ok {
 			return
 		}
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Announcing file event: channel=%s", channel), false)
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Announcing file event: channel=%s", channel), false)
 		m.listenerManager.announceFile(pnFilesEvent)
 	default:
+		if chunk, userMeta, ok := parseMessageChunk(payload.UserMetadata); ok {
+			assembled, lastTimetoken, complete, err := m.chunks.add(channel, chunk, payload.Payload, timetoken,
+				time.Duration(m.pubnub.Config.ChunkReassemblyTimeout)*time.Second)
+			if err != nil {
+				m.pubnub.loggerManager.LogError(err, "ChunkedMessageReassemblyFailed", PNSubscribeOperation, true)
+				m.listenerManager.announceStatus(&PNStatus{
+					Category:         PNBadRequestCategory,
+					ErrorData:        err,
+					Error:            true,
+					Operation:        PNSubscribeOperation,
+					AffectedChannels: []string{channel},
+				})
+				return
+			}
+			if !complete {
+				m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Buffered chunk %d/%d of message %s: channel=%s", chunk.Index+1, chunk.Total, chunk.ID, channel), false)
+				return
+			}
+			payload.Payload = assembled
+			payload.UserMetadata = userMeta
+			timetoken = lastTimetoken
+		}
+		userMeta, signerID, deliver := m.pubnub.verifyMessage(channel, payload.Payload, payload.UserMetadata, timetoken)
+		if !deliver {
+			return
+		}
+		payload.UserMetadata = userMeta
+		span := m.pubnub.startReceiveSpan(channel, payload.UserMetadata)
+		defer span.End()
 		var err error
-		messagePayload, err = parseCipherInterface(payload.Payload, m.pubnub)
+		messagePayload, err = parseCipherInterface(payload.Payload, m.pubnub, channel)
 		if err != nil {
+			span.RecordError(err)
 			m.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Crypto: decryption of message failed due to %v", err), false)
 			// Surface only a generic error to customers; the specific reason is kept at Debug level to avoid leaking a decryption failure oracle.
 			err = errors.New("message decryption failed")
@@ -790,11 +839,27 @@ func processNonPresencePayload(m *SubscriptionManager, payload subscribeMessage,
 
 		}
 		pnMessageResult := createPNMessageResult(messagePayload, actualCh, subscribedCh, channel, subscriptionMatch, payload.IssuingClientID, payload.UserMetadata, timetoken, payload.CustomMessageType, err)
-		m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Announcing message: channel=%s", channel), false)
+		pnMessageResult.Verified = signerID != ""
+		pnMessageResult.SignerID = signerID
+		m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Announcing message: channel=%s", channel), false)
 		m.listenerManager.announceMessage(pnMessageResult)
 	}
 }
 
+// announceIncompleteChunkedMessage announces the chunked messages which were not
+// complete within Config.ChunkReassemblyTimeout.
+func (m *SubscriptionManager) announceIncompleteChunkedMessage(channel string, chunk messageChunk) {
+	err := fmt.Errorf("%w: message %s on channel %s", errIncompleteChunkedMessage, chunk.ID, channel)
+	m.pubnub.loggerManager.LogError(err, "ChunkedMessageTimeout", PNSubscribeOperation, true)
+	m.listenerManager.announceStatus(&PNStatus{
+		Category:         PNBadRequestCategory,
+		ErrorData:        err,
+		Error:            true,
+		Operation:        PNSubscribeOperation,
+		AffectedChannels: []string{channel},
+	})
+}
+
 func processSubscribePayload(m *SubscriptionManager, payload subscribeMessage) {
 	channel := payload.Channel
 	subscriptionMatch := payload.SubscriptionMatch
@@ -1125,8 +1190,8 @@ func createPNMessageResult(messagePayload interface{}, actualCh, subscribedCh, c
 // pubnub: PubNub instance for accessing config, crypto module, and logger.
 //
 // returns the decrypted data as interface and error.
-func parseCipherInterface(data interface{}, pubnub *PubNub) (interface{}, error) {
-	module := pubnub.getCryptoModule()
+func parseCipherInterface(data interface{}, pubnub *PubNub, channel string) (interface{}, error) {
+	module := pubnub.getDecryptionCryptoModule(channel, data)
 	if module != nil {
 		pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Crypto: decrypting data, type=%v", reflect.TypeOf(data).Kind()), false)
 		switch v := data.(type) {
@@ -1138,6 +1203,7 @@ func parseCipherInterface(data interface{}, pubnub *PubNub) (interface{}, error)
 				if ok {
 					decrypted, errDecryption := decryptString(module, msg, pubnub.loggerManager)
 					if errDecryption != nil {
+						pubnub.Config.metrics().RecordDecryptFailure()
 
 						return v, errDecryption
 					} else {
@@ -1159,6 +1225,7 @@ func parseCipherInterface(data interface{}, pubnub *PubNub) (interface{}, error)
 			var intf interface{}
 			decrypted, errDecryption := decryptString(module, data.(string), pubnub.loggerManager)
 			if errDecryption != nil {
+				pubnub.Config.metrics().RecordDecryptFailure()
 
 				intf = data
 				return intf, errDecryption
@@ -1203,6 +1270,7 @@ func (m *SubscriptionManager) GetListeners() map[*Listener]bool {
 
 func (m *SubscriptionManager) reconnect() {
 	m.pubnub.loggerManager.LogSimple(PNLogLevelDebug, "Subscription manager reconnecting", false)
+	m.pubnub.Config.metrics().RecordReconnect()
 	m.reconnectionManager.stopHeartbeatTimer()
 	m.stopSubscribeLoop()
 
@@ -1263,5 +1331,5 @@ func (m *SubscriptionManager) unsubscribeAll() {
 }
 
 func (m *SubscriptionManager) log(message string) {
-	m.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("Subscribe %s: UUID=%s, channels=%v, groups=%v", message, m.pubnub.Config.UUID, m.stateManager.prepareChannelList(true), m.stateManager.prepareGroupList(true)), false)
+	m.pubnub.loggerManager.LogOperation(PNLogLevelTrace, PNSubscribeOperation, fmt.Sprintf("Subscribe %s: UUID=%s, channels=%v, groups=%v", message, m.pubnub.Config.UUID, m.stateManager.prepareChannelList(true), m.stateManager.prepareGroupList(true)), false)
 }
@@ -24,7 +24,7 @@ func TestParseCipherInterfaceCipherWithCipher(t *testing.T) {
 	pn.Config.CipherKey = "enigma"
 	pn.Config.UseRandomInitializationVector = false
 
-	intf, err := parseCipherInterface(s, pn)
+	intf, err := parseCipherInterface(s, pn, "")
 
 	assert.Nil(err)
 	assert.Equal("yay!", intf.(string))
@@ -37,7 +37,7 @@ func TestParseCipherInterfacePlainWithCipher(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = "enigma"
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	assert.Equal("yay!", intf.(string))
 }
@@ -49,7 +49,7 @@ func TestParseCipherInterfaceCipherWithDiffCipher(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = "test"
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	assert.Equal("Wi24KS4pcTzvyuGOHubiXg==", intf.(string))
 
@@ -62,7 +62,7 @@ func TestParseCipherInterfacePlainWithDiffCipher(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = "test"
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	assert.Equal("yay!", intf.(string))
 }
@@ -74,7 +74,7 @@ func TestParseCipherInterfaceCipherWithoutCipher(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = ""
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	assert.Equal("Wi24KS4pcTzvyuGOHubiXg==", intf.(string))
 }
@@ -86,7 +86,7 @@ func TestParseCipherInterfacePlainWithoutCipher(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = ""
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	assert.Equal("yay!", intf.(string))
 }
@@ -101,7 +101,7 @@ func TestParseCipherInterfacePlainWithCipherStruct(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = "enigma"
 
-	intf, err := parseCipherInterface(s, pn)
+	intf, err := parseCipherInterface(s, pn, "")
 
 	assert.Nil(err)
 	if msg, ok := intf.(customStruct); !ok {
@@ -123,7 +123,7 @@ func TestParseCipherInterfacePlainWithoutCipherStruct(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = ""
 
-	intf, err := parseCipherInterface(s, pn)
+	intf, err := parseCipherInterface(s, pn, "")
 
 	assert.Nil(err)
 	if msg, ok := intf.(customStruct); !ok {
@@ -150,7 +150,7 @@ func TestParseCipherInterfacePlainWithCipherMapPNOther(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = "enigma"
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("12345", msg["not_other"])
@@ -176,7 +176,7 @@ func TestParseCipherInterfacePlainWithoutCipherMapPNOther(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = ""
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("12345", msg["not_other"])
@@ -198,7 +198,7 @@ func TestParseCipherInterfaceCipherWithoutCipherStringPNOther(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = ""
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("1234", msg["not_other"])
@@ -221,7 +221,7 @@ func TestParseCipherInterfaceCipherWithCipherStringPNOther(t *testing.T) {
 	pn.Config.CipherKey = "enigma"
 	pn.Config.UseRandomInitializationVector = false
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("1234", msg["not_other"])
@@ -240,7 +240,7 @@ func TestParseCipherInterfaceCipherWithoutCipherStruct(t *testing.T) {
 	pn.Config.CipherKey = "enigma"
 	pn.Config.UseRandomInitializationVector = false
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 	msg := intf.(map[string]interface{})
 	assert.Equal("hi!", msg["Foo"])
 
@@ -258,7 +258,7 @@ func TestParseCipherInterfaceCipherWithCipherStructPNOther(t *testing.T) {
 	pn.Config.CipherKey = "enigma"
 	pn.Config.UseRandomInitializationVector = false
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("1234", msg["not_other"])
@@ -280,7 +280,7 @@ func TestParseCipherInterfaceCipherWithOtherCipherStructPNOther(t *testing.T) {
 	pn := NewPubNub(NewDemoConfig())
 	pn.Config.CipherKey = "test"
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("1234", msg["not_other"])
@@ -300,7 +300,7 @@ func TestParseCipherInterfaceCipherWithCipherStructPNOtherDisable(t *testing.T)
 	pn.Config.UseRandomInitializationVector = false
 	pn.Config.CipherKey = "enigma"
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.(map[string]interface{})
 	assert.Equal("1234", msg["not_other"])
@@ -316,7 +316,7 @@ func TestParseCipherInterfaceCipherWithIntSlice(t *testing.T) {
 	pn.Config.DisablePNOtherProcessing = true
 	pn.Config.CipherKey = ""
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 
 	msg := intf.([]int)
 	assert.Equal(1, msg[0])
@@ -331,7 +331,7 @@ func TestParseCipherInterfaceCipherWithoutCipherStruct2(t *testing.T) {
 	pn.Config.CipherKey = "enigma"
 	pn.Config.UseRandomInitializationVector = false
 
-	intf, _ := parseCipherInterface(s, pn)
+	intf, _ := parseCipherInterface(s, pn, "")
 	msg := intf.(map[string]interface{})
 	assert.Equal("12345", msg["not_other"])
 	if msgOther, ok := msg["pn_other"].(map[string]interface{}); !ok {
@@ -933,7 +933,7 @@ func TestDecryptionProcessOnEncryptedMessage(t *testing.T) {
 	pn.Config.CryptoModule = crypto
 
 	// Rust generated cipher text
-	result, err := parseCipherInterface("UE5FRAFBQ1JIEALf+E65kseYJwTw2J6BUk9MePHiCcBCS+8ykXLkBIOA", pn)
+	result, err := parseCipherInterface("UE5FRAFBQ1JIEALf+E65kseYJwTw2J6BUk9MePHiCcBCS+8ykXLkBIOA", pn, "")
 
 	assert.Nil(err)
 	assert.Equal("test", result)
@@ -948,7 +948,7 @@ func TestDecryptionProcessOnNoEncryptedMessage(t *testing.T) {
 
 	pn.Config.CryptoModule = crypto
 
-	result, err := parseCipherInterface("test", pn)
+	result, err := parseCipherInterface("test", pn, "")
 
 	assert.NotNil(err)
 	assert.Equal("test", result)
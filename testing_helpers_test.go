@@ -0,0 +1,67 @@
+package pubnub
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testRequest is a request received by a test server, with its body.
+type testRequest struct {
+	*http.Request
+	body string
+}
+
+// newTestServer starts a test server serving the requests with handler, and
+// returns its host and the requests it received.
+func newTestServer(t *testing.T, handler http.HandlerFunc) (string, func() []testRequest) {
+	var mu sync.Mutex
+	var requests []testRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		mu.Lock()
+		requests = append(requests, testRequest{Request: r, body: string(b)})
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	return u.Host, func() []testRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]testRequest(nil), requests...)
+	}
+}
+
+// newTestServerPubNub returns a client whose requests are served by handler.
+func newTestServerPubNub(t *testing.T, handler http.HandlerFunc) *PubNub {
+	pn, _ := newRecordingTestServerPubNub(t, handler)
+	return pn
+}
+
+// newRecordingTestServerPubNub is newTestServerPubNub returning the requests
+// received by the test server.
+func newRecordingTestServerPubNub(t *testing.T, handler http.HandlerFunc) (*PubNub, func() []testRequest) {
+	host, requests := newTestServer(t, handler)
+
+	config := NewConfigWithUserId(UserId("me"))
+	config.PublishKey = "pub"
+	config.SubscribeKey = "sub"
+	config.Origin = host
+	config.Secure = false
+	config.UseHTTP2 = false
+	config.MaxWorkers = 0
+
+	return NewPubNub(config), requests
+}
@@ -44,6 +44,13 @@ func (b *timeBuilder) QueryParam(queryParam map[string]string) *timeBuilder {
 	return b
 }
 
+// NoCache bypasses Config.RequestCache: the request is neither coalesced nor served from the cache.
+func (b *timeBuilder) NoCache() *timeBuilder {
+	b.opts.NoCache = true
+
+	return b
+}
+
 // GetLogParams returns the user-provided parameters for logging
 func (o *timeOpts) GetLogParams() map[string]interface{} {
 	return map[string]interface{}{} // No user parameters for Time operation
@@ -64,6 +71,7 @@ func (b *timeBuilder) Execute() (*TimeResponse, StatusResponse, error) {
 type timeOpts struct {
 	endpointOpts
 	QueryParam map[string]string
+	NoCache    bool
 	Transport  http.RoundTripper
 }
 
@@ -93,6 +101,15 @@ func (o *timeOpts) connectTimeout() int {
 	return o.pubnub.Config.ConnectTimeout
 }
 
+// cacheTags returns no tags, the cached time is only dropped at the end of its TTL.
+func (o *timeOpts) cacheTags() []string {
+	return nil
+}
+
+func (o *timeOpts) bypassCache() bool {
+	return o.NoCache
+}
+
 func (o *timeOpts) operationType() OperationType {
 	return PNTimeOperation
 }
@@ -1,23 +1,60 @@
 package pubnub
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 )
 
+// TokenProvider provides the access manager tokens of the client, for ex. from
+// the server granting them. See Config.TokenProvider.
+type TokenProvider interface {
+	// Token returns a new token.
+	Token(ctx Context) (string, error)
+}
+
+// TokenProviderFunc is a function implementing TokenProvider.
+type TokenProviderFunc func(ctx Context) (string, error)
+
+// Token calls f.
+func (f TokenProviderFunc) Token(ctx Context) (string, error) {
+	return f(ctx)
+}
+
 // TokenManager struct is used to for token manager operations
 type TokenManager struct {
 	sync.RWMutex
 	Token string
+
+	pubnub *PubNub
+	ctx    Context
+	// refreshMutex guards inflight, the call to the token provider in progress.
+	// It isn't held during the call, the provider can make requests with the client.
+	refreshMutex sync.Mutex
+	inflight     *tokenRefresh
+	refreshTimer *time.Timer
+}
+
+// tokenRefresh is a call to the token provider shared by the concurrent refreshes.
+type tokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
 }
 
 func newTokenManager(pubnub *PubNub, ctx Context) *TokenManager {
-	return &TokenManager{}
+	return &TokenManager{pubnub: pubnub, ctx: ctx}
 }
 
 // CleanUp resets the token manager
 func (m *TokenManager) CleanUp() {
 	m.Lock()
 	m.Token = ""
+	if m.refreshTimer != nil {
+		m.refreshTimer.Stop()
+		m.refreshTimer = nil
+	}
 	m.Unlock()
 }
 
@@ -34,3 +71,102 @@ func (m *TokenManager) StoreToken(token string) {
 	m.Token = token
 	m.Unlock()
 }
+
+func (m *TokenManager) provider() TokenProvider {
+	if m == nil || m.pubnub == nil {
+		return nil
+	}
+	return m.pubnub.Config.TokenProvider
+}
+
+// start gets the first token of the provider.
+func (m *TokenManager) start() {
+	if m.provider() == nil {
+		return
+	}
+	go func() {
+		if _, err := m.ensureToken(); err != nil {
+			m.pubnub.loggerManager.LogError(err, "TokenProviderFailed", PNAccessManagerGrantToken, true)
+		}
+	}()
+}
+
+// ensureToken returns the current token, after getting one from the provider when there is none yet.
+func (m *TokenManager) ensureToken() (string, error) {
+	if token := m.GetToken(); token != "" || m.provider() == nil {
+		return token, nil
+	}
+	return m.refresh("", "startup")
+}
+
+// refresh gets a new token from the provider, unless the token isn't
+// previous anymore because another refresh already replaced it.
+func (m *TokenManager) refresh(previous, reason string) (string, error) {
+	provider := m.provider()
+	if provider == nil {
+		return "", errors.New("no token provider")
+	}
+
+	m.refreshMutex.Lock()
+	if token := m.GetToken(); token != previous {
+		m.refreshMutex.Unlock()
+		return token, nil
+	}
+	if r := m.inflight; r != nil {
+		m.refreshMutex.Unlock()
+		<-r.done
+		return r.token, r.err
+	}
+	r := &tokenRefresh{done: make(chan struct{})}
+	m.inflight = r
+	m.refreshMutex.Unlock()
+
+	r.token, r.err = m.fetch(provider, reason)
+
+	m.refreshMutex.Lock()
+	m.inflight = nil
+	m.refreshMutex.Unlock()
+	close(r.done)
+	return r.token, r.err
+}
+
+// fetch gets a new token from provider and stores it.
+func (m *TokenManager) fetch(provider TokenProvider, reason string) (string, error) {
+	m.pubnub.loggerManager.LogSimple(PNLogLevelDebug, fmt.Sprintf("Refreshing token: reason=%s", reason), false)
+	token, err := provider.Token(m.ctx)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", errors.New("token provider returned an empty token")
+	}
+	m.StoreToken(token)
+	m.scheduleRefresh(token)
+	return token, nil
+}
+
+// scheduleRefresh refreshes the token Config.TokenRefreshMargin seconds before it expires.
+func (m *TokenManager) scheduleRefresh(token string) {
+	parsed, err := ParseToken(token)
+	if err != nil {
+		m.pubnub.loggerManager.LogSimple(PNLogLevelWarn, fmt.Sprintf("Token refresh not scheduled, the token can't be parsed: %v", err), false)
+		return
+	}
+	expiry := parsed.ExpiresAt()
+	delay := time.Until(expiry) - time.Duration(m.pubnub.Config.TokenRefreshMargin)*time.Second
+	if delay <= 0 {
+		m.pubnub.loggerManager.LogSimple(PNLogLevelWarn, fmt.Sprintf("Token refresh not scheduled, the token expires at %v", expiry), false)
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if m.refreshTimer != nil {
+		m.refreshTimer.Stop()
+	}
+	m.refreshTimer = time.AfterFunc(delay, func() {
+		if _, err := m.refresh(token, "expiry"); err != nil {
+			m.pubnub.loggerManager.LogError(err, "TokenProviderFailed", PNAccessManagerGrantToken, true)
+		}
+	})
+}
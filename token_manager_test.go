@@ -1,10 +1,16 @@
 package pubnub
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewTokenManager(t *testing.T) {
@@ -156,3 +162,131 @@ func TestTokenManagerEmptyTokenStorage(t *testing.T) {
 	tm.StoreToken("")
 	assert.Empty(tm.GetToken())
 }
+
+func newTokenProviderTestPubNub(t *testing.T, valid func() string) (*PubNub, func() []testRequest) {
+	return newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("auth") != valid() {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"status":403,"message":"Forbidden","error":true}`)
+			return
+		}
+		if strings.HasPrefix(req.URL.Path, "/v2/subscribe/") {
+			fmt.Fprint(w, `{"t":{"t":"15000000000000001","r":1},"m":[]}`)
+			return
+		}
+		fmt.Fprint(w, `[1,"Sent","15000000000000000"]`)
+	})
+}
+
+func TestTokenProviderRetriesDeniedRequest(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	pn, requests := newTokenProviderTestPubNub(t, func() string { return "t2" })
+	pn.Config.TokenProvider = TokenProviderFunc(func(ctx Context) (string, error) {
+		return fmt.Sprintf("t%d", atomic.AddInt32(&calls, 1)), nil
+	})
+
+	_, _, err := pn.Publish().Channel("ch").Message("hi").Execute()
+	assert.Nil(err)
+	assert.Equal(int32(2), atomic.LoadInt32(&calls))
+	require.Len(t, requests(), 2)
+	assert.Equal("t1", requests()[0].URL.Query().Get("auth"))
+	assert.Equal("t2", requests()[1].URL.Query().Get("auth"))
+	assert.Equal("t2", pn.tokenManager.GetToken())
+
+	// A request denied with the new token isn't retried again.
+	pn2, requests := newTokenProviderTestPubNub(t, func() string { return "never" })
+	pn2.Config.TokenProvider = pn.Config.TokenProvider
+	_, status, err := pn2.Publish().Channel("ch").Message("hi").Execute()
+	assert.NotNil(err)
+	assert.Equal(http.StatusForbidden, status.StatusCode)
+	assert.Len(requests(), 2)
+}
+
+func TestTokenProviderSubscribeRetryKeepsTimetoken(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newTokenProviderTestPubNub(t, func() string { return "new" })
+	pn.SetToken("old")
+	pn.Config.TokenProvider = TokenProviderFunc(func(ctx Context) (string, error) {
+		return "new", nil
+	})
+
+	opts := newSubscribeOpts(pn, pn.ctx)
+	opts.Channels = []string{"ch"}
+	opts.Timetoken = 15000000000000000
+	_, _, err := executeRequest(opts)
+	assert.Nil(err)
+	require.Len(t, requests(), 2)
+	for _, req := range requests() {
+		assert.Equal("15000000000000000", req.URL.Query().Get("tt"))
+	}
+}
+
+func TestTokenProviderRefreshesBeforeExpiry(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	pn := NewPubNub(NewDemoConfig())
+	defer pn.Destroy()
+	pn.Config.TokenRefreshMargin = 59
+	pn.Config.TokenProvider = TokenProviderFunc(func(ctx Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return createTestToken(PNGrantTokenDecoded{Version: 2, Timestamp: time.Now().Unix(), TTL: 1})
+	})
+
+	token, err := pn.tokenManager.ensureToken()
+	assert.Nil(err)
+	assert.NotEmpty(token)
+	assert.Eventually(func() bool { return atomic.LoadInt32(&calls) >= 2 }, 5*time.Second, 50*time.Millisecond)
+	assert.NotEqual(token, pn.tokenManager.GetToken())
+}
+
+func TestTokenProviderGrantingWithTheClient(t *testing.T) {
+	assert := assert.New(t)
+	pn, _ := newTokenProviderTestPubNub(t, func() string { return "granted" })
+	pn.Config.SecretKey = "secret"
+	pn.Config.TokenProvider = TokenProviderFunc(func(ctx Context) (string, error) {
+		// The grant request doesn't wait for the token being refreshed.
+		pn.GrantToken().TTL(10).Channels(map[string]ChannelPermissions{"ch": {Read: true}}).Execute()
+		return "granted", nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := pn.Publish().Channel("ch").Message("hi").Execute()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Nil(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the token provider deadlocked")
+	}
+}
+
+func TestTokenProviderConcurrentRefreshes(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	release := make(chan struct{})
+	pn := NewPubNub(NewDemoConfig())
+	pn.Config.TokenProvider = TokenProviderFunc(func(ctx Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "token", nil
+	})
+	tm := newTokenManager(pn, pn.ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := tm.refresh("", "test")
+			assert.Nil(err)
+			assert.Equal("token", token)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,224 @@
+package pubnub
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PNTokenDecision is the result of a local permission check of a token.
+type PNTokenDecision struct {
+	Allowed bool
+	// Reason explains the decision, for ex. which resource or pattern granted the permission.
+	Reason string
+	// Resource is the resource name or the pattern the decision is based on, empty when none matched.
+	Resource string
+	// Pattern is true when Resource is a pattern.
+	Pattern bool
+}
+
+// ExpiresAt returns the time the token expires at.
+func (t *PNToken) ExpiresAt() time.Time {
+	return time.Unix(t.Timestamp, 0).Add(time.Duration(t.TTL) * time.Minute)
+}
+
+// CanRead checks if the token allows uuid to subscribe to and read the history of channel.
+func (t *PNToken) CanRead(channel, uuid string) PNTokenDecision {
+	return t.Can(PNChannels, channel, PNRead, uuid)
+}
+
+// CanWrite checks if the token allows uuid to publish to channel.
+func (t *PNToken) CanWrite(channel, uuid string) PNTokenDecision {
+	return t.Can(PNChannels, channel, PNWrite, uuid)
+}
+
+// CanManage checks if the token allows uuid to manage the members and the metadata of channel.
+func (t *PNToken) CanManage(channel, uuid string) PNTokenDecision {
+	return t.Can(PNChannels, channel, PNManage, uuid)
+}
+
+// CanJoin checks if the token allows uuid to join channel as a member.
+func (t *PNToken) CanJoin(channel, uuid string) PNTokenDecision {
+	return t.Can(PNChannels, channel, PNJoin, uuid)
+}
+
+// CanReadChannelGroup checks if the token allows uuid to subscribe to channelGroup.
+func (t *PNToken) CanReadChannelGroup(channelGroup, uuid string) PNTokenDecision {
+	return t.Can(PNGroups, channelGroup, PNRead, uuid)
+}
+
+// CanManageChannelGroup checks if the token allows uuid to add and remove the channels of channelGroup.
+func (t *PNToken) CanManageChannelGroup(channelGroup, uuid string) PNTokenDecision {
+	return t.Can(PNGroups, channelGroup, PNManage, uuid)
+}
+
+// CanUpdateUUID checks if the token allows uuid to update the metadata of target.
+func (t *PNToken) CanUpdateUUID(target, uuid string) PNTokenDecision {
+	return t.Can(PNUUIDs, target, PNUpdate, uuid)
+}
+
+// Can checks if the token allows uuid to use permission on the resource name
+// of resourceType. The token must not be expired and, when it is authorized
+// for a UUID, uuid must be that one. The permissions of an exact resource take
+// precedence over the patterns, whose permissions are combined when several
+// of them match.
+func (t *PNToken) Can(resourceType PNResourceType, name string, permission PNGrantBitMask, uuid string) PNTokenDecision {
+	if t.TTL > 0 && !time.Now().Before(t.ExpiresAt()) {
+		return PNTokenDecision{Reason: fmt.Sprintf("token expired at %v", t.ExpiresAt())}
+	}
+	if t.AuthorizedUUID != "" && t.AuthorizedUUID != uuid {
+		return PNTokenDecision{Reason: fmt.Sprintf("token is authorized for uuid %q, not %q", t.AuthorizedUUID, uuid)}
+	}
+
+	kind := resourceTypeName(resourceType)
+	perm := permissionName(permission)
+	if mask, ok := t.Resources.bitmask(resourceType, name); ok {
+		return newTokenDecision(mask&permission != 0, fmt.Sprintf("%s %q", kind, name), name, false, perm)
+	}
+
+	patterns := t.Patterns.bitmasks(resourceType)
+	names := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		names = append(names, pattern)
+	}
+	sort.Strings(names)
+	// The decision names the first pattern granting the permission, or the
+	// first matching pattern when none grants it.
+	var mask PNGrantBitMask
+	matched, granting := "", ""
+	for _, pattern := range names {
+		re := compileTokenPattern(pattern)
+		if re == nil || !re.MatchString(name) {
+			continue
+		}
+		mask |= patterns[pattern]
+		if matched == "" {
+			matched = pattern
+		}
+		if granting == "" && patterns[pattern]&permission != 0 {
+			granting = pattern
+		}
+	}
+	if mask&permission != 0 {
+		return newTokenDecision(true, fmt.Sprintf("%s pattern %q", kind, granting), granting, true, perm)
+	}
+	if matched != "" {
+		return newTokenDecision(false, fmt.Sprintf("%s pattern %q", kind, matched), matched, true, perm)
+	}
+	return PNTokenDecision{Reason: fmt.Sprintf("no %s resource or pattern matches %q", kind, name)}
+}
+
+// maxTokenPatterns is the size of the cache of the compiled patterns, cleared when full.
+const maxTokenPatterns = 1000
+
+var tokenPatterns = struct {
+	sync.Mutex
+	compiled map[string]*regexp.Regexp
+}{compiled: make(map[string]*regexp.Regexp)}
+
+// compileTokenPattern returns the compiled regular expression of a token
+// pattern, nil when it is invalid.
+func compileTokenPattern(pattern string) *regexp.Regexp {
+	tokenPatterns.Lock()
+	defer tokenPatterns.Unlock()
+	if re, ok := tokenPatterns.compiled[pattern]; ok {
+		return re
+	}
+	// The invalid patterns are cached as nil.
+	re, _ := regexp.Compile(pattern)
+	if len(tokenPatterns.compiled) >= maxTokenPatterns {
+		tokenPatterns.compiled = make(map[string]*regexp.Regexp)
+	}
+	tokenPatterns.compiled[pattern] = re
+	return re
+}
+
+func newTokenDecision(allowed bool, source, resource string, pattern bool, perm string) PNTokenDecision {
+	verb := "grants"
+	if !allowed {
+		verb = "doesn't grant"
+	}
+	return PNTokenDecision{
+		Allowed:  allowed,
+		Reason:   fmt.Sprintf("%s %s %s", source, verb, perm),
+		Resource: resource,
+		Pattern:  pattern,
+	}
+}
+
+// bitmask returns the permissions of the resource name of resourceType.
+func (r PNTokenResources) bitmask(resourceType PNResourceType, name string) (PNGrantBitMask, bool) {
+	mask, ok := r.bitmasks(resourceType)[name]
+	return mask, ok
+}
+
+// bitmasks returns the permissions of the resources of resourceType.
+func (r PNTokenResources) bitmasks(resourceType PNResourceType) map[string]PNGrantBitMask {
+	res := make(map[string]PNGrantBitMask)
+	switch resourceType {
+	case PNChannels:
+		for k, p := range r.Channels {
+			res[k] = permissionsBitmask(map[PNGrantBitMask]bool{
+				PNRead: p.Read, PNWrite: p.Write, PNManage: p.Manage, PNDelete: p.Delete,
+				PNGet: p.Get, PNUpdate: p.Update, PNJoin: p.Join,
+			})
+		}
+	case PNGroups:
+		for k, p := range r.ChannelGroups {
+			res[k] = permissionsBitmask(map[PNGrantBitMask]bool{PNRead: p.Read, PNManage: p.Manage})
+		}
+	case PNUUIDs:
+		for k, p := range r.UUIDs {
+			res[k] = permissionsBitmask(map[PNGrantBitMask]bool{PNGet: p.Get, PNUpdate: p.Update, PNDelete: p.Delete})
+		}
+	}
+	return res
+}
+
+func permissionsBitmask(perms map[PNGrantBitMask]bool) PNGrantBitMask {
+	var mask PNGrantBitMask
+	for bit, ok := range perms {
+		if ok {
+			mask |= bit
+		}
+	}
+	return mask
+}
+
+func resourceTypeName(resourceType PNResourceType) string {
+	switch resourceType {
+	case PNChannels:
+		return "channel"
+	case PNGroups:
+		return "channel group"
+	case PNUUIDs:
+		return "uuid"
+	default:
+		return "resource"
+	}
+}
+
+func permissionName(permission PNGrantBitMask) string {
+	switch permission {
+	case PNRead:
+		return "read"
+	case PNWrite:
+		return "write"
+	case PNManage:
+		return "manage"
+	case PNDelete:
+		return "delete"
+	case PNCreate:
+		return "create"
+	case PNGet:
+		return "get"
+	case PNUpdate:
+		return "update"
+	case PNJoin:
+		return "join"
+	default:
+		return fmt.Sprintf("permissions %d", permission)
+	}
+}
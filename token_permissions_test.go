@@ -0,0 +1,108 @@
+package pubnub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestToken(t *testing.T, decoded PNGrantTokenDecoded) *PNToken {
+	token, err := createTestToken(decoded)
+	require.NoError(t, err)
+	parsed, err := ParseToken(token)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestTokenPermissionsExactResourceBeforePattern(t *testing.T) {
+	assert := assert.New(t)
+	token := parseTestToken(t, PNGrantTokenDecoded{
+		Version:   2,
+		Timestamp: time.Now().Unix(),
+		TTL:       60,
+		Resources: GrantResources{
+			Channels: map[string]int64{"room-private": int64(PNRead)},
+			UUIDs:    map[string]int64{"me": int64(PNGet | PNUpdate)},
+		},
+		Patterns: GrantResources{
+			Channels: map[string]int64{"^room-.*$": int64(PNRead | PNWrite | PNJoin)},
+			Groups:   map[string]int64{"^cg-": int64(PNRead)},
+		},
+	})
+
+	d := token.CanWrite("room-1", "me")
+	assert.True(d.Allowed)
+	assert.True(d.Pattern)
+	assert.Equal("^room-.*$", d.Resource)
+	assert.Equal(`channel pattern "^room-.*$" grants write`, d.Reason)
+	assert.True(token.CanJoin("room-1", "me").Allowed)
+	assert.False(token.CanManage("room-1", "me").Allowed)
+
+	d = token.CanWrite("room-private", "me")
+	assert.False(d.Allowed)
+	assert.False(d.Pattern)
+	assert.Equal(`channel "room-private" doesn't grant write`, d.Reason)
+	assert.True(token.CanRead("room-private", "me").Allowed)
+
+	d = token.CanRead("lobby", "me")
+	assert.False(d.Allowed)
+	assert.Equal(`no channel resource or pattern matches "lobby"`, d.Reason)
+
+	assert.True(token.CanReadChannelGroup("cg-1", "me").Allowed)
+	assert.False(token.CanManageChannelGroup("cg-1", "me").Allowed)
+	assert.True(token.CanUpdateUUID("me", "me").Allowed)
+	assert.False(token.CanUpdateUUID("other", "me").Allowed)
+}
+
+func TestTokenPermissionsExpiryAndAuthorizedUUID(t *testing.T) {
+	assert := assert.New(t)
+	decoded := PNGrantTokenDecoded{
+		Version:        2,
+		Timestamp:      time.Now().Add(-2 * time.Hour).Unix(),
+		TTL:            60,
+		AuthorizedUUID: "alice",
+		Resources:      GrantResources{Channels: map[string]int64{"ch": int64(PNRead)}},
+	}
+
+	d := parseTestToken(t, decoded).CanRead("ch", "alice")
+	assert.False(d.Allowed)
+	assert.Contains(d.Reason, "token expired at")
+
+	decoded.Timestamp = time.Now().Unix()
+	token := parseTestToken(t, decoded)
+	assert.True(token.CanRead("ch", "alice").Allowed)
+	d = token.CanRead("ch", "bob")
+	assert.False(d.Allowed)
+	assert.Equal(`token is authorized for uuid "alice", not "bob"`, d.Reason)
+}
+
+func TestTokenPermissionsCombinesMatchingPatterns(t *testing.T) {
+	assert := assert.New(t)
+	token := parseTestToken(t, PNGrantTokenDecoded{
+		Version:   2,
+		Timestamp: time.Now().Unix(),
+		TTL:       60,
+		Patterns: GrantResources{
+			Channels: map[string]int64{
+				"^room-":     int64(PNRead),
+				"^room-dm-":  int64(PNWrite),
+				"^room-dm-x": int64(PNJoin),
+			},
+		},
+	})
+
+	// The denial of "^room-" doesn't hide the grant of "^room-dm-".
+	d := token.CanWrite("room-dm-1", "me")
+	assert.True(d.Allowed)
+	assert.Equal("^room-dm-", d.Resource)
+	assert.True(token.CanRead("room-dm-1", "me").Allowed)
+	assert.False(token.CanJoin("room-dm-1", "me").Allowed)
+	assert.True(token.CanJoin("room-dm-x", "me").Allowed)
+
+	d = token.CanManage("room-dm-1", "me")
+	assert.False(d.Allowed)
+	assert.Equal(`channel pattern "^room-" doesn't grant manage`, d.Reason)
+	assert.False(token.CanWrite("room-1", "me").Allowed)
+}
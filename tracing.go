@@ -0,0 +1,176 @@
+package pubnub
+
+import (
+	"fmt"
+)
+
+// traceMetaKey is the Meta key of the trace context of the published messages.
+const traceMetaKey = "pn_trace"
+
+// Attributes of the spans of the operations.
+const (
+	SpanAttrOperation     = "pubnub.operation"
+	SpanAttrChannel       = "pubnub.channel"
+	SpanAttrChannelCount  = "pubnub.channel_count"
+	SpanAttrStatusCode    = "http.response.status_code"
+	SpanAttrCategory      = "pubnub.category"
+	SpanAttrRetryAttempt  = "pubnub.retry_attempt"
+	SpanAttrRequestBytes  = "pubnub.request.bytes"
+	SpanAttrResponseBytes = "pubnub.response.bytes"
+)
+
+// SpanNameReceiveMessage is the name of the spans of the received messages
+// with a trace context. The spans of the requests are named after their
+// OperationType.
+const SpanNameReceiveMessage = "ReceiveMessage"
+
+// Tracer starts the spans of the operations of the client and propagates the
+// trace context, see Config.Tracer. The pnotel module adapts OpenTelemetry.
+type Tracer interface {
+	// Start starts a span, child of the span of ctx, and returns its context.
+	Start(ctx Context, name string, attrs ...SpanAttribute) (Context, Span)
+	// Inject adds the trace context of ctx to carrier.
+	Inject(ctx Context, carrier map[string]string)
+	// Extract returns ctx with the trace context of carrier.
+	Extract(ctx Context, carrier map[string]string) Context
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...SpanAttribute)
+	RecordError(err error)
+	End()
+}
+
+// SpanAttribute is an attribute of a span. Value is a string, a bool, an int,
+// an int64 or a float64.
+type SpanAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx Context, name string, attrs ...SpanAttribute) (Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopTracer) Inject(ctx Context, carrier map[string]string) {}
+
+func (noopTracer) Extract(ctx Context, carrier map[string]string) Context {
+	return ctx
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...SpanAttribute) {}
+
+func (noopSpan) RecordError(err error) {}
+
+func (noopSpan) End() {}
+
+// tracer returns Config.Tracer, or a no-op tracer when it isn't set.
+func (c *Config) tracer() Tracer {
+	if c.Tracer == nil {
+		return noopTracer{}
+	}
+	return c.Tracer
+}
+
+// channelsEndpoint is implemented by the endpoints of channels, for the
+// channel count attribute of their span.
+type channelsEndpoint interface {
+	channelCount() int
+}
+
+// startRequestSpan starts the span of the request of opts.
+func startRequestSpan(opts endpoint) Span {
+	op := opts.operationType()
+	attrs := []SpanAttribute{{SpanAttrOperation, op.String()}}
+	if e, ok := opts.(channelsEndpoint); ok {
+		attrs = append(attrs, SpanAttribute{SpanAttrChannelCount, e.channelCount()})
+	}
+	ctx := opts.context()
+	if ctx == nil {
+		ctx = backgroundContext
+	}
+	_, span := opts.config().tracer().Start(ctx, op.String(), attrs...)
+	return span
+}
+
+// endRequestSpan records the result of a request in its span and ends it.
+func endRequestSpan(span Span, val []byte, status StatusResponse, err error) {
+	attrs := []SpanAttribute{{SpanAttrCategory, status.Category.String()}, {SpanAttrResponseBytes, len(val)}}
+	if status.StatusCode != 0 {
+		attrs = append(attrs, SpanAttribute{SpanAttrStatusCode, status.StatusCode})
+	}
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// tracedMeta returns meta with the trace context of ctx, when
+// Config.PropagateTraceContext is set.
+func (pn *PubNub) tracedMeta(ctx Context, meta interface{}) (interface{}, error) {
+	if !pn.Config.PropagateTraceContext || pn.Config.Tracer == nil || ctx == nil {
+		return meta, nil
+	}
+	carrier := make(map[string]string)
+	pn.Config.Tracer.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return meta, nil
+	}
+	res, err := metaObject(meta, "Meta must be a JSON object to propagate the trace context")
+	if err != nil {
+		return nil, err
+	}
+	res[traceMetaKey] = carrier
+	return res, nil
+}
+
+// ExtractTraceContext returns ctx with the trace context propagated in the
+// Meta of a message, for ex. PNMessage.UserMetadata, so that a subscriber
+// continues the trace of the publisher. See Config.PropagateTraceContext.
+func (pn *PubNub) ExtractTraceContext(ctx Context, meta interface{}) Context {
+	carrier := traceCarrier(meta)
+	if carrier == nil {
+		return ctx
+	}
+	return pn.Config.tracer().Extract(ctx, carrier)
+}
+
+// traceCarrier returns the trace context of meta, nil when it has none.
+func traceCarrier(meta interface{}) map[string]string {
+	m, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	values, ok := m[traceMetaKey].(map[string]interface{})
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	carrier := make(map[string]string, len(values))
+	for k, v := range values {
+		carrier[k] = fmt.Sprint(v)
+	}
+	return carrier
+}
+
+// startReceiveSpan starts the span of a message received on channel, child of
+// the trace context of its meta. The span is a no-op when meta has no trace context.
+func (pn *PubNub) startReceiveSpan(channel string, meta interface{}) Span {
+	if pn.Config.Tracer == nil {
+		return noopSpan{}
+	}
+	carrier := traceCarrier(meta)
+	if carrier == nil {
+		return noopSpan{}
+	}
+	ctx := pn.Config.Tracer.Extract(backgroundContext, carrier)
+	_, span := pn.Config.Tracer.Start(ctx, SpanNameReceiveMessage,
+		SpanAttribute{SpanAttrOperation, PNSubscribeOperation.String()},
+		SpanAttribute{SpanAttrChannel, channel})
+	return span
+}
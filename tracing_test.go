@@ -0,0 +1,178 @@
+package pubnub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTraceKey struct{}
+
+type testSpan struct {
+	name   string
+	parent interface{}
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...SpanAttribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+// testTracer records its spans and propagates the trace ID of the context values.
+type testTracer struct {
+	sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx Context, name string, attrs ...SpanAttribute) (Context, Span) {
+	span := &testSpan{name: name, parent: ctx.Value(testTraceKey{}), attrs: make(map[string]interface{})}
+	span.SetAttributes(attrs...)
+	t.Lock()
+	t.spans = append(t.spans, span)
+	t.Unlock()
+	return context.WithValue(ctx, testTraceKey{}, name), span
+}
+
+func (t *testTracer) Inject(ctx Context, carrier map[string]string) {
+	if v, ok := ctx.Value(testTraceKey{}).(string); ok {
+		carrier["trace"] = v
+	}
+}
+
+func (t *testTracer) Extract(ctx Context, carrier map[string]string) Context {
+	return context.WithValue(ctx, testTraceKey{}, carrier["trace"])
+}
+
+func (t *testTracer) getSpans() []*testSpan {
+	t.Lock()
+	defer t.Unlock()
+	return append([]*testSpan(nil), t.spans...)
+}
+
+func TestTracerRequestSpan(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `[1,"Sent","15000000000000001"]`)
+	})
+	tracer := &testTracer{}
+	pn.Config.Tracer = tracer
+
+	_, _, err := pn.Publish().Channel("ch").Message("hi").UsePost(true).Execute()
+	require.NoError(t, err)
+	require.Len(t, requests(), 1)
+
+	spans := tracer.getSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal("Publish", span.name)
+	assert.True(span.ended)
+	assert.Nil(span.err)
+	assert.Equal(map[string]interface{}{
+		SpanAttrOperation:     "Publish",
+		SpanAttrChannelCount:  1,
+		SpanAttrRetryAttempt:  0,
+		SpanAttrRequestBytes:  len(requests()[0].RequestURI) + len(`"hi"`),
+		SpanAttrResponseBytes: 30,
+		SpanAttrStatusCode:    200,
+		SpanAttrCategory:      PNUnknownCategory.String(),
+	}, span.attrs)
+
+	failing := newTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":400,"error":true}`)
+	})
+	failing.Config.Tracer = tracer
+	_, _, err = failing.Time().Execute()
+	require.Error(t, err)
+	spans = tracer.getSpans()
+	require.Len(t, spans, 2)
+	assert.Equal("Time", spans[1].name)
+	assert.Equal(err, spans[1].err)
+	assert.Equal(http.StatusBadRequest, spans[1].attrs[SpanAttrStatusCode])
+}
+
+func TestTracerPropagatesTraceContextToSubscribers(t *testing.T) {
+	assert := assert.New(t)
+	pn, published := newChunkedPublishTestPubNub(t)
+	tracer := &testTracer{}
+	pn.Config.Tracer = tracer
+	pn.Config.PropagateTraceContext = true
+
+	ctx := context.WithValue(context.Background(), testTraceKey{}, "checkout")
+	_, _, err := pn.PublishWithContext(ctx).Channel("ch").Message("hi").Meta(map[string]interface{}{"a": "b"}).UsePost(true).Execute()
+	require.NoError(t, err)
+	require.Len(t, published(), 1)
+	assert.Equal(map[string]interface{}{"a": "b", traceMetaKey: map[string]interface{}{"trace": "checkout"}}, published()[0].meta)
+	assert.Equal("checkout", tracer.getSpans()[0].parent)
+
+	_, _, err = pn.PublishWithContext(ctx).Channel("ch").Message("hi").Meta("not an object").Execute()
+	assert.ErrorContains(err, "Meta must be a JSON object")
+
+	subscriber := NewPubNub(NewConfigWithUserId(UserId("sub")))
+	defer subscriber.Destroy()
+	subTracer := &testTracer{}
+	subscriber.Config.Tracer = subTracer
+	received := subscribeChunks(t, subscriber, published())
+	require.Len(t, received, 1)
+
+	spans := subTracer.getSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(SpanNameReceiveMessage, spans[0].name)
+	assert.Equal("checkout", spans[0].parent)
+	assert.Equal("ch", spans[0].attrs[SpanAttrChannel])
+	assert.True(spans[0].ended)
+
+	extracted := subscriber.ExtractTraceContext(context.Background(), received[0].UserMetadata)
+	assert.Equal("checkout", extracted.Value(testTraceKey{}))
+}
+
+func TestNoopTracer(t *testing.T) {
+	config := NewConfigWithUserId(UserId("me"))
+	ctx := context.Background()
+	spanCtx, span := config.tracer().Start(ctx, "Publish")
+	span.SetAttributes(SpanAttribute{SpanAttrChannelCount, 1})
+	span.RecordError(errors.New("e"))
+	span.End()
+	assert.Equal(t, ctx, spanCtx)
+}
+
+func TestEstimatePublishSizeWithTraceContext(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newPublishSizeTestPubNub(t)
+	pn.Config.Tracer = &testTracer{}
+	pn.Config.PropagateTraceContext = true
+	ctx := context.WithValue(context.Background(), testTraceKey{}, "checkout")
+	meta := map[string]interface{}{"a": "b"}
+
+	size, err := pn.EstimatePublishSizeWithContext(ctx, "ch", "hi", meta)
+	assert.Nil(err)
+	_, _, err = pn.PublishWithContext(ctx).Channel("ch").Message("hi").Meta(meta).Execute()
+	require.NoError(t, err)
+	require.Len(t, requests(), 1)
+	r := requests()[0]
+	assert.Contains(r.meta, traceMetaKey)
+	assert.Equal(len("ch")+len(r.message)+len(r.meta), size)
+
+	// Without a trace context the meta is sent as is.
+	size, err = pn.EstimatePublishSize("ch", "hi", meta)
+	assert.Nil(err)
+	assert.Less(size, len("ch")+len(r.message)+len(r.meta))
+}
@@ -0,0 +1,308 @@
+package pubnub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pubnub/go/v9/pnerr"
+)
+
+const (
+	// DefaultTypingOnEvent is the Signal payload sent when the user starts typing.
+	DefaultTypingOnEvent = "typing_on"
+	// DefaultTypingOffEvent is the Signal payload sent when the user stops typing.
+	DefaultTypingOffEvent = "typing_off"
+	// DefaultTypingThrottleInterval is the min interval between two typing_on signals on a channel.
+	DefaultTypingThrottleInterval = 2 * time.Second
+	// DefaultTypingTimeout is the time after which a typer is considered to have stopped typing.
+	DefaultTypingTimeout = 5 * time.Second
+)
+
+// TypingIndicatorConfig configures a TypingIndicator. Zero values use the defaults.
+type TypingIndicatorConfig struct {
+	TypingOnEvent    string        // Signal payload sent when the user starts typing.
+	TypingOffEvent   string        // Signal payload sent when the user stops typing.
+	ThrottleInterval time.Duration // Min interval between two typing_on signals on a channel.
+	Timeout          time.Duration // Expiry of remote typers, and of the local typing state without a new StartTyping call.
+}
+
+// TypingIndicator sends and receives typing events through Signal.
+// Outgoing typing_on signals are throttled, the local typing state is stopped
+// automatically after Timeout and remote typers expire after Timeout unless
+// they send a new typing_on signal.
+type TypingIndicator struct {
+	sync.RWMutex
+
+	pubnub *PubNub
+	config TypingIndicatorConfig
+	stop   func()
+
+	// channel -> time of the last typing_on signal sent
+	sent      map[string]time.Time
+	autoStop  map[string]*time.Timer
+	typers    map[string]map[string]*time.Timer
+	callbacks []func(channel string, typers []string)
+}
+
+// NewTypingIndicator creates a TypingIndicator. An error is returned when the
+// encoded typing events exceed the Signal payload size limit.
+func NewTypingIndicator(pubnub *PubNub, config TypingIndicatorConfig) (*TypingIndicator, error) {
+	if config.TypingOnEvent == "" {
+		config.TypingOnEvent = DefaultTypingOnEvent
+	}
+	if config.TypingOffEvent == "" {
+		config.TypingOffEvent = DefaultTypingOffEvent
+	}
+	if config.ThrottleInterval <= 0 {
+		config.ThrottleInterval = DefaultTypingThrottleInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTypingTimeout
+	}
+	if config.TypingOnEvent == config.TypingOffEvent {
+		return nil, pnerr.NewValidationError(PNSignalOperation.String(), "TypingOnEvent and TypingOffEvent must be different")
+	}
+	for _, event := range []string{config.TypingOnEvent, config.TypingOffEvent} {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > maxSignalPayloadSize {
+			return nil, pnerr.NewValidationError(PNSignalOperation.String(),
+				fmt.Sprintf("typing event %q exceeds the Signal payload limit of %d bytes", event, maxSignalPayloadSize))
+		}
+	}
+
+	return &TypingIndicator{
+		pubnub:   pubnub,
+		config:   config,
+		sent:     make(map[string]time.Time),
+		autoStop: make(map[string]*time.Timer),
+		typers:   make(map[string]map[string]*time.Timer),
+	}, nil
+}
+
+// OnTypingChange registers a callback which is called every time the set of typers of a channel changes.
+func (t *TypingIndicator) OnTypingChange(callback func(channel string, typers []string)) {
+	t.Lock()
+	t.callbacks = append(t.callbacks, callback)
+	t.Unlock()
+}
+
+// Start updates the typers with the signals received on the subscribe loop.
+func (t *TypingIndicator) Start() {
+	t.Lock()
+	defer t.Unlock()
+	if t.stop != nil {
+		return
+	}
+	t.stop = t.pubnub.addEventHandlers(eventHandlers{
+		Signal: t.HandleSignal,
+	})
+}
+
+// Stop stops listening for signals and cancels all timers. A typing_off signal
+// is sent on the channels the user is typing on, and the remote typers are
+// cleared.
+func (t *TypingIndicator) Stop() {
+	t.Lock()
+	stop := t.stop
+	t.stop = nil
+	for ch, timer := range t.autoStop {
+		timer.Stop()
+		delete(t.autoStop, ch)
+	}
+	var typing []string
+	for ch := range t.sent {
+		typing = append(typing, ch)
+		delete(t.sent, ch)
+	}
+	var cleared []string
+	for ch, uuids := range t.typers {
+		for _, timer := range uuids {
+			timer.Stop()
+		}
+		cleared = append(cleared, ch)
+		delete(t.typers, ch)
+	}
+	t.Unlock()
+	if stop != nil {
+		stop()
+	}
+
+	for _, ch := range cleared {
+		t.notify(ch)
+	}
+	for _, ch := range typing {
+		if _, _, err := t.pubnub.Signal().Channel(ch).Message(t.config.TypingOffEvent).Execute(); err != nil {
+			t.pubnub.loggerManager.LogError(err, "TypingIndicatorStopFailed", PNSignalOperation, true)
+		}
+	}
+}
+
+// StartTyping sends a typing_on signal unless one was sent on the channel within
+// ThrottleInterval. A typing_off signal is sent automatically when StartTyping is
+// not called again within Timeout.
+func (t *TypingIndicator) StartTyping(channel string) error {
+	t.Lock()
+	if timer, ok := t.autoStop[channel]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(t.config.Timeout, func() {
+		t.RLock()
+		current := t.autoStop[channel] == timer
+		t.RUnlock()
+		if !current {
+			return
+		}
+		if err := t.StopTyping(channel); err != nil {
+			t.pubnub.loggerManager.LogError(err, "TypingIndicatorAutoStopFailed", PNSignalOperation, true)
+		}
+	})
+	t.autoStop[channel] = timer
+	last, typing := t.sent[channel]
+	if typing && time.Since(last) < t.config.ThrottleInterval {
+		t.Unlock()
+		return nil
+	}
+	t.sent[channel] = time.Now()
+	t.Unlock()
+
+	_, _, err := t.pubnub.Signal().Channel(channel).Message(t.config.TypingOnEvent).Execute()
+	if err != nil {
+		t.Lock()
+		if !typing {
+			delete(t.sent, channel)
+		} else {
+			t.sent[channel] = last
+		}
+		t.Unlock()
+	}
+	return err
+}
+
+// StopTyping sends a typing_off signal if the user is typing on the channel.
+func (t *TypingIndicator) StopTyping(channel string) error {
+	t.Lock()
+	if timer, ok := t.autoStop[channel]; ok {
+		timer.Stop()
+		delete(t.autoStop, channel)
+	}
+	if _, typing := t.sent[channel]; !typing {
+		t.Unlock()
+		return nil
+	}
+	delete(t.sent, channel)
+	t.Unlock()
+
+	_, _, err := t.pubnub.Signal().Channel(channel).Message(t.config.TypingOffEvent).Execute()
+	return err
+}
+
+// IsTyping reports whether the user is typing on the channel.
+func (t *TypingIndicator) IsTyping(channel string) bool {
+	t.RLock()
+	defer t.RUnlock()
+	_, ok := t.sent[channel]
+	return ok
+}
+
+// HandleSignal applies a received typing signal. Signals published by the client
+// UUID and signals with other payloads are ignored.
+func (t *TypingIndicator) HandleSignal(signal *PNMessage) {
+	if signal == nil || signal.Publisher == "" || signal.Publisher == t.pubnub.Config.UUID {
+		return
+	}
+	event, ok := signal.Message.(string)
+	if !ok {
+		return
+	}
+
+	switch event {
+	case t.config.TypingOnEvent:
+		t.addTyper(signal.Channel, signal.Publisher)
+	case t.config.TypingOffEvent:
+		t.removeTyper(signal.Channel, signal.Publisher)
+	}
+}
+
+// Typers returns the sorted list of UUIDs typing on the channel.
+func (t *TypingIndicator) Typers(channel string) []string {
+	t.RLock()
+	defer t.RUnlock()
+	return t.typerList(channel)
+}
+
+func (t *TypingIndicator) addTyper(channel, uuid string) {
+	t.Lock()
+	uuids, ok := t.typers[channel]
+	if !ok {
+		uuids = make(map[string]*time.Timer)
+		t.typers[channel] = uuids
+	}
+	previous, known := uuids[uuid]
+	if known {
+		previous.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(t.config.Timeout, func() {
+		t.expireTyper(channel, uuid, timer)
+	})
+	uuids[uuid] = timer
+	t.Unlock()
+
+	if !known {
+		t.notify(channel)
+	}
+}
+
+// expireTyper removes the typer unless its timer was replaced by a newer typing_on signal.
+func (t *TypingIndicator) expireTyper(channel, uuid string, timer *time.Timer) {
+	t.RLock()
+	current := t.typers[channel][uuid] == timer
+	t.RUnlock()
+	if current {
+		t.removeTyper(channel, uuid)
+	}
+}
+
+func (t *TypingIndicator) removeTyper(channel, uuid string) {
+	t.Lock()
+	timer, ok := t.typers[channel][uuid]
+	if !ok {
+		t.Unlock()
+		return
+	}
+	timer.Stop()
+	delete(t.typers[channel], uuid)
+	if len(t.typers[channel]) == 0 {
+		delete(t.typers, channel)
+	}
+	t.Unlock()
+
+	t.notify(channel)
+}
+
+func (t *TypingIndicator) notify(channel string) {
+	t.RLock()
+	typers := t.typerList(channel)
+	callbacks := t.callbacks
+	t.RUnlock()
+
+	for _, cb := range callbacks {
+		cb(channel, typers)
+	}
+}
+
+func (t *TypingIndicator) typerList(channel string) []string {
+	res := make([]string, 0, len(t.typers[channel]))
+	for uuid := range t.typers[channel] {
+		res = append(res, uuid)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,152 @@
+package pubnub
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTypingIndicatorTestPubNub(t *testing.T) (*PubNub, func() []string) {
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[1,"Sent","15000000000000000"]`))
+	})
+
+	return pn, func() []string {
+		var signals []string
+		for _, req := range requests() {
+			parts := strings.Split(req.URL.EscapedPath(), "/")
+			signals = append(signals, parts[len(parts)-1])
+		}
+		return signals
+	}
+}
+
+func TestNewTypingIndicatorValidation(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+
+	_, err := NewTypingIndicator(pn, TypingIndicatorConfig{TypingOnEvent: strings.Repeat("a", 63)})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "exceeds the Signal payload limit")
+
+	_, err = NewTypingIndicator(pn, TypingIndicatorConfig{TypingOnEvent: "x", TypingOffEvent: "x"})
+	assert.NotNil(err)
+
+	ti, err := NewTypingIndicator(pn, TypingIndicatorConfig{})
+	assert.Nil(err)
+	assert.Equal(DefaultTypingOnEvent, ti.config.TypingOnEvent)
+	assert.Equal(DefaultTypingTimeout, ti.config.Timeout)
+}
+
+func TestTypingIndicatorThrottlesAndAutoStops(t *testing.T) {
+	assert := assert.New(t)
+	pn, signals := newTypingIndicatorTestPubNub(t)
+
+	ti, err := NewTypingIndicator(pn, TypingIndicatorConfig{
+		ThrottleInterval: time.Hour,
+		Timeout:          100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.Nil(ti.StartTyping("ch"))
+	assert.Nil(ti.StartTyping("ch"))
+	assert.Nil(ti.StartTyping("ch"))
+	assert.True(ti.IsTyping("ch"))
+	assert.Equal([]string{"%22typing_on%22"}, signals())
+
+	assert.Eventually(func() bool {
+		return !ti.IsTyping("ch") && len(signals()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal([]string{"%22typing_on%22", "%22typing_off%22"}, signals())
+
+	// Stopping when not typing does not send a signal.
+	assert.Nil(ti.StopTyping("ch"))
+	assert.Len(signals(), 2)
+}
+
+func TestTypingIndicatorStopTyping(t *testing.T) {
+	assert := assert.New(t)
+	pn, signals := newTypingIndicatorTestPubNub(t)
+
+	ti, err := NewTypingIndicator(pn, TypingIndicatorConfig{ThrottleInterval: time.Nanosecond})
+	require.NoError(t, err)
+
+	assert.Nil(ti.StartTyping("ch"))
+	time.Sleep(time.Millisecond)
+	assert.Nil(ti.StartTyping("ch"))
+	assert.Nil(ti.StopTyping("ch"))
+	assert.False(ti.IsTyping("ch"))
+	assert.Equal([]string{"%22typing_on%22", "%22typing_on%22", "%22typing_off%22"}, signals())
+	ti.Stop()
+}
+
+func TestTypingIndicatorHandleSignal(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+
+	ti, err := NewTypingIndicator(pn, TypingIndicatorConfig{Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var changes [][]string
+	ti.OnTypingChange(func(channel string, typers []string) {
+		mu.Lock()
+		changes = append(changes, typers)
+		mu.Unlock()
+	})
+
+	ti.HandleSignal(&PNMessage{Channel: "ch", Publisher: "a", Message: "typing_on"})
+	ti.HandleSignal(&PNMessage{Channel: "ch", Publisher: "b", Message: "typing_on"})
+	ti.HandleSignal(&PNMessage{Channel: "ch", Publisher: "b", Message: "typing_on"})
+	ti.HandleSignal(&PNMessage{Channel: "ch", Publisher: "me", Message: "typing_on"})
+	ti.HandleSignal(&PNMessage{Channel: "ch", Publisher: "c", Message: map[string]interface{}{"other": true}})
+	ti.HandleSignal(nil)
+	assert.Equal([]string{"a", "b"}, ti.Typers("ch"))
+
+	ti.HandleSignal(&PNMessage{Channel: "ch", Publisher: "a", Message: "typing_off"})
+	assert.Equal([]string{"b"}, ti.Typers("ch"))
+
+	assert.Eventually(func() bool {
+		return len(ti.Typers("ch")) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal([][]string{{"a"}, {"a", "b"}, {"b"}, {}}, changes)
+	mu.Unlock()
+}
+
+func TestTypingIndicatorStartStop(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	ti, err := NewTypingIndicator(pn, TypingIndicatorConfig{})
+	require.NoError(t, err)
+
+	ti.Start()
+	pn.subscriptionManager.listenerManager.announceSignal(&PNMessage{Channel: "ch", Publisher: "a", Message: "typing_on"})
+	assert.Eventually(func() bool {
+		return len(ti.Typers("ch")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	ti.Stop()
+	assert.Len(pn.GetListeners(), 0)
+}
+
+func TestTypingIndicatorStopClearsTyping(t *testing.T) {
+	assert := assert.New(t)
+	pn, signals := newTypingIndicatorTestPubNub(t)
+	ti, err := NewTypingIndicator(pn, TypingIndicatorConfig{})
+	require.NoError(t, err)
+
+	assert.Nil(ti.StartTyping("ch"))
+	ti.HandleSignal(&PNMessage{Channel: "other", Publisher: "a", Message: "typing_on"})
+
+	ti.Stop()
+	assert.False(ti.IsTyping("ch"))
+	assert.Empty(ti.Typers("other"))
+	assert.Equal([]string{"%22typing_on%22", "%22typing_off%22"}, signals())
+}
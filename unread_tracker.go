@@ -0,0 +1,278 @@
+package pubnub
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// maxMessageCountsChannels is the max number of channels sent in a single MessageCounts request.
+const maxMessageCountsChannels = 100
+
+// UnreadTracker keeps unread message counts for a set of channels. Read positions
+// are persisted through a ReadPositionStore, counts are loaded with MessageCounts
+// and bumped by the messages received on the subscribe loop.
+type UnreadTracker struct {
+	sync.RWMutex
+
+	pubnub *PubNub
+	store  ReadPositionStore
+	stop   func()
+
+	channels  map[string]bool
+	positions map[string]int64
+	latest    map[string]int64
+	counts    map[string]int
+	callbacks []func(channel string, unread int)
+}
+
+// NewUnreadTracker creates an unread tracker which persists read positions in store.
+func NewUnreadTracker(pubnub *PubNub, store ReadPositionStore) *UnreadTracker {
+	return &UnreadTracker{
+		pubnub:    pubnub,
+		store:     store,
+		channels:  make(map[string]bool),
+		positions: make(map[string]int64),
+		latest:    make(map[string]int64),
+		counts:    make(map[string]int),
+	}
+}
+
+// AddChannels adds channels to the set of tracked channels. Call Refresh to load their counts.
+func (t *UnreadTracker) AddChannels(channels ...string) {
+	t.Lock()
+	for _, ch := range channels {
+		t.channels[ch] = true
+	}
+	t.Unlock()
+}
+
+// RemoveChannels stops tracking the channels and drops their counts.
+func (t *UnreadTracker) RemoveChannels(channels ...string) {
+	t.Lock()
+	for _, ch := range channels {
+		delete(t.channels, ch)
+		delete(t.positions, ch)
+		delete(t.latest, ch)
+		delete(t.counts, ch)
+	}
+	t.Unlock()
+}
+
+// Channels returns the sorted list of tracked channels.
+func (t *UnreadTracker) Channels() []string {
+	t.RLock()
+	defer t.RUnlock()
+	return t.channelList()
+}
+
+// OnUnreadCountChange registers a callback which is called every time the unread count of a channel changes.
+func (t *UnreadTracker) OnUnreadCountChange(callback func(channel string, unread int)) {
+	t.Lock()
+	t.callbacks = append(t.callbacks, callback)
+	t.Unlock()
+}
+
+// Start bumps the unread counts with the messages received on the subscribe loop.
+func (t *UnreadTracker) Start() {
+	t.Lock()
+	defer t.Unlock()
+	if t.stop != nil {
+		return
+	}
+	t.stop = t.pubnub.addEventHandlers(eventHandlers{
+		Message: t.HandleMessage,
+	})
+}
+
+// Stop stops listening for messages. The counts are kept.
+func (t *UnreadTracker) Stop() {
+	t.Lock()
+	stop := t.stop
+	t.stop = nil
+	t.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// Refresh loads the read positions of the tracked channels from the store and
+// their unread counts with MessageCounts, in batches of 100 channels.
+// Channels without a stored read position count all the messages in storage.
+func (t *UnreadTracker) Refresh() error {
+	channels := t.Channels()
+	if len(channels) == 0 {
+		return nil
+	}
+
+	positions, err := t.store.GetReadPositions(channels)
+	if err != nil {
+		return err
+	}
+
+	t.Lock()
+	for ch, tt := range positions {
+		if tt > t.positions[ch] {
+			t.positions[ch] = tt
+		}
+	}
+	t.Unlock()
+
+	for i := 0; i < len(channels); i += maxMessageCountsChannels {
+		end := i + maxMessageCountsChannels
+		if end > len(channels) {
+			end = len(channels)
+		}
+		if err := t.refreshBatch(channels[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *UnreadTracker) refreshBatch(channels []string) error {
+	t.RLock()
+	timetokens := make([]int64, len(channels))
+	for i, ch := range channels {
+		timetokens[i] = t.position(ch)
+	}
+	t.RUnlock()
+
+	resp, _, err := t.pubnub.MessageCounts().
+		Channels(channels).
+		ChannelsTimetoken(timetokens).
+		Execute()
+	if err != nil {
+		return err
+	}
+	t.pubnub.loggerManager.LogSimple(PNLogLevelTrace, fmt.Sprintf("UnreadTracker: message counts=%v", resp.Channels), false)
+
+	for i, ch := range channels {
+		// Skip channels marked as read while the request was in flight.
+		t.RLock()
+		stale := t.position(ch) != timetokens[i]
+		t.RUnlock()
+		if !stale {
+			t.setCount(ch, resp.Channels[ch])
+		}
+	}
+	return nil
+}
+
+// HandleMessage bumps the unread count of a tracked channel for a message newer
+// than its read position. Messages published by the client UUID are not counted.
+func (t *UnreadTracker) HandleMessage(message *PNMessage) {
+	if message == nil || message.Publisher == t.pubnub.Config.UUID {
+		return
+	}
+
+	t.Lock()
+	ch := message.Channel
+	if !t.channels[ch] || message.Timetoken <= t.position(ch) {
+		t.Unlock()
+		return
+	}
+	if message.Timetoken > t.latest[ch] {
+		t.latest[ch] = message.Timetoken
+	}
+	t.counts[ch]++
+	count := t.counts[ch]
+	callbacks := t.callbacks
+	t.Unlock()
+
+	for _, cb := range callbacks {
+		cb(ch, count)
+	}
+}
+
+// MarkRead stores timetoken as the read position of the channel. Read positions
+// only move forward; an older timetoken is ignored. When messages newer than
+// timetoken were received the unread count is reloaded with MessageCounts.
+func (t *UnreadTracker) MarkRead(channel string, timetoken int64) error {
+	t.Lock()
+	if timetoken <= t.positions[channel] {
+		t.Unlock()
+		return nil
+	}
+	previous := t.positions[channel]
+	t.positions[channel] = timetoken
+	reload := t.latest[channel] > timetoken
+	t.Unlock()
+
+	if err := t.store.SetReadPosition(channel, timetoken); err != nil {
+		// The position wasn't saved, unless a newer one was marked meanwhile.
+		t.Lock()
+		if t.positions[channel] == timetoken {
+			t.positions[channel] = previous
+		}
+		t.Unlock()
+		return err
+	}
+
+	if reload {
+		return t.refreshBatch([]string{channel})
+	}
+	t.setCount(channel, 0)
+	return nil
+}
+
+// ReadPosition returns the read position of the channel, 0 if it is unknown.
+func (t *UnreadTracker) ReadPosition(channel string) int64 {
+	t.RLock()
+	defer t.RUnlock()
+	return t.positions[channel]
+}
+
+// UnreadCount returns the unread count of the channel.
+func (t *UnreadTracker) UnreadCount(channel string) int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.counts[channel]
+}
+
+// UnreadCounts returns the unread counts of all tracked channels.
+func (t *UnreadTracker) UnreadCounts() map[string]int {
+	t.RLock()
+	defer t.RUnlock()
+	res := make(map[string]int, len(t.channels))
+	for ch := range t.channels {
+		res[ch] = t.counts[ch]
+	}
+	return res
+}
+
+func (t *UnreadTracker) setCount(channel string, count int) {
+	t.Lock()
+	if !t.channels[channel] {
+		t.Unlock()
+		return
+	}
+	prev, ok := t.counts[channel]
+	t.counts[channel] = count
+	callbacks := t.callbacks
+	t.Unlock()
+
+	if ok && prev == count {
+		return
+	}
+	for _, cb := range callbacks {
+		cb(channel, count)
+	}
+}
+
+// position returns the timetoken MessageCounts counts from. Must be called with the lock held.
+func (t *UnreadTracker) position(channel string) int64 {
+	if tt := t.positions[channel]; tt > 0 {
+		return tt
+	}
+	return 1
+}
+
+func (t *UnreadTracker) channelList() []string {
+	res := make([]string, 0, len(t.channels))
+	for ch := range t.channels {
+		res = append(res, ch)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,142 @@
+package pubnub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unreadChange struct {
+	channel string
+	unread  int
+}
+
+func TestUnreadTrackerRefreshBatches(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(req.URL.Path, "/")
+		channels := strings.Split(parts[len(parts)-1], ",")
+
+		counts := make([]string, len(channels))
+		for i, ch := range channels {
+			counts[i] = fmt.Sprintf("%q:2", ch)
+		}
+		fmt.Fprintf(w, `{"status":200,"error":false,"channels":{%s}}`, strings.Join(counts, ","))
+	})
+
+	store := NewMemoryReadPositionStore()
+	require.NoError(t, store.SetReadPosition("ch-000", 15000000000000000))
+
+	tracker := NewUnreadTracker(pn, store)
+	for i := 0; i < 150; i++ {
+		tracker.AddChannels(fmt.Sprintf("ch-%03d", i))
+	}
+	assert.Nil(tracker.Refresh())
+
+	require.Len(t, requests(), 2)
+	first := requests()[0].URL.Query().Get("channelsTimetoken")
+	assert.True(strings.HasPrefix(first, "15000000000000000,1,1"))
+	assert.Len(strings.Split(first, ","), 100)
+	assert.Len(strings.Split(requests()[1].URL.Query().Get("channelsTimetoken"), ","), 50)
+	assert.Equal(2, tracker.UnreadCount("ch-149"))
+	assert.Equal(int64(15000000000000000), tracker.ReadPosition("ch-000"))
+	assert.Len(tracker.UnreadCounts(), 150)
+}
+
+func TestUnreadTrackerHandleMessage(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	tracker := NewUnreadTracker(pn, NewMemoryReadPositionStore())
+	tracker.AddChannels("ch")
+
+	var changes []unreadChange
+	tracker.OnUnreadCountChange(func(channel string, unread int) {
+		changes = append(changes, unreadChange{channel, unread})
+	})
+
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 10})
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 11})
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "me", Timetoken: 12})
+	tracker.HandleMessage(&PNMessage{Channel: "untracked", Publisher: "other", Timetoken: 13})
+	tracker.HandleMessage(nil)
+
+	assert.Equal(2, tracker.UnreadCount("ch"))
+	assert.Equal([]unreadChange{{"ch", 1}, {"ch", 2}}, changes)
+
+	assert.Nil(tracker.MarkRead("ch", 11))
+	assert.Equal(0, tracker.UnreadCount("ch"))
+	assert.Equal(unreadChange{"ch", 0}, changes[len(changes)-1])
+
+	// Older messages and read positions are ignored.
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 9})
+	assert.Nil(tracker.MarkRead("ch", 5))
+	assert.Equal(0, tracker.UnreadCount("ch"))
+	assert.Equal(int64(11), tracker.ReadPosition("ch"))
+
+	tracker.RemoveChannels("ch")
+	assert.Empty(tracker.Channels())
+}
+
+func TestUnreadTrackerMarkReadReloadsNewerMessages(t *testing.T) {
+	assert := assert.New(t)
+	pn, requests := newRecordingTestServerPubNub(t, func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"status":200,"error":false,"channels":{"ch":1}}`)
+	})
+
+	store := NewMemoryReadPositionStore()
+	tracker := NewUnreadTracker(pn, store)
+	tracker.AddChannels("ch")
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 10})
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 20})
+
+	assert.Nil(tracker.MarkRead("ch", 10))
+	require.Len(t, requests(), 1)
+	assert.Equal("10", requests()[0].URL.Query().Get("timetoken"))
+	assert.Equal(1, tracker.UnreadCount("ch"))
+
+	positions, err := store.GetReadPositions([]string{"ch", "other"})
+	assert.Nil(err)
+	assert.Equal(map[string]int64{"ch": 10}, positions)
+}
+
+type failingReadPositionStore struct {
+	*MemoryReadPositionStore
+}
+
+func (s failingReadPositionStore) SetReadPosition(channel string, timetoken int64) error {
+	return errors.New("store unavailable")
+}
+
+func TestUnreadTrackerMarkReadStoreError(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	tracker := NewUnreadTracker(pn, failingReadPositionStore{NewMemoryReadPositionStore()})
+	tracker.AddChannels("ch")
+	tracker.HandleMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 10})
+
+	assert.NotNil(tracker.MarkRead("ch", 10))
+	assert.Equal(int64(0), tracker.ReadPosition("ch"))
+	assert.Equal(1, tracker.UnreadCount("ch"))
+}
+
+func TestUnreadTrackerStartStop(t *testing.T) {
+	assert := assert.New(t)
+	pn := NewPubNub(NewConfigWithUserId(UserId("me")))
+	tracker := NewUnreadTracker(pn, NewMemoryReadPositionStore())
+	tracker.AddChannels("ch")
+
+	tracker.Start()
+	pn.subscriptionManager.listenerManager.announceMessage(&PNMessage{Channel: "ch", Publisher: "other", Timetoken: 10})
+	assert.Eventually(func() bool {
+		return tracker.UnreadCount("ch") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	tracker.Stop()
+	assert.Len(pn.GetListeners(), 0)
+}